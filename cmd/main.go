@@ -1,26 +1,45 @@
 package main
 
 import (
+	"anemone_notes/internal/api/attachment_api"
 	"anemone_notes/internal/api/auth_api"
+	"anemone_notes/internal/api/export_api"
 	"anemone_notes/internal/api/mail_api"
 	"anemone_notes/internal/api/notes_api"
+	"anemone_notes/internal/api/sidebar_api"
 	"anemone_notes/internal/api/trello_api"
 	"anemone_notes/internal/config"
 	"anemone_notes/internal/database"
+	"anemone_notes/internal/jobs"
+	"anemone_notes/internal/lockout"
+	"anemone_notes/internal/mailer"
+	"anemone_notes/internal/oidc"
+	"anemone_notes/internal/passwords"
+	"anemone_notes/internal/repository/attachment_repository"
 	"anemone_notes/internal/repository/auth_repository"
+	"anemone_notes/internal/repository/export_repository"
 	"anemone_notes/internal/repository/mail_repository"
 	"anemone_notes/internal/repository/notes_repository"
+	"anemone_notes/internal/repository/sidebar_repository"
 	"anemone_notes/internal/repository/trello_repository"
+	"anemone_notes/internal/services/attachment_services"
 	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/export_services"
 	"anemone_notes/internal/services/mail_services"
 	"anemone_notes/internal/services/notes_services"
+	"anemone_notes/internal/services/sidebar_services"
 	"anemone_notes/internal/services/trello_services"
 	"anemone_notes/internal/smtp_server"
+	"anemone_notes/internal/storage"
+	"context"
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 	"log"
 	"net/http"
 	"sync"
+	"time"
+	// Часовые пояса ежедневных заметок не должны зависеть от tzdata в образе
+	_ "time/tzdata"
 )
 
 func setupCORS(router http.Handler) http.Handler {
@@ -29,7 +48,7 @@ func setupCORS(router http.Handler) http.Handler {
 	c := cors.New(cors.Options{
 		// AllowedOrigins: []string{cfg.CorsDev}, // FOR DEV
 		AllowedOrigins: []string{cfg.CorsProd}, // FOR PROD
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{"Content-Type", "Authorization"},
 		AllowCredentials: true,
 		Debug: false,
@@ -51,20 +70,65 @@ func main() {
 
 	// AUTH
 	userRepo := auth_repository.NewUserRepo(db)
+	profileRepo := auth_repository.NewProfileRepo(db)
 	refreshRepo := auth_repository.NewRefreshRepo(db)
-	authSvc := auth_services.NewAuthService(userRepo, refreshRepo)
+	userTokenRepo := auth_repository.NewUserTokenRepo(db)
+	totpRepo := auth_repository.NewTOTPRepo(db)
+	outMailer, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatalf("FATAL: mailer setup failed: %v", err)
+	}
+	identityRepo := auth_repository.NewIdentityRepo(db)
+	personalTokenRepo := auth_repository.NewPersonalTokenRepo(db)
+	var attemptStore lockout.Store = lockout.NewPostgresStore(db)
+	if cfg.LoginAttemptsStore == "memory" {
+		attemptStore = lockout.NewMemoryStore()
+	}
+	passwordPolicy := &passwords.Policy{MinLength: cfg.PasswordMinLength, MinClasses: cfg.PasswordMinClasses}
+	if cfg.BreachedPasswordsDir != "" {
+		breached, err := passwords.NewPrefixDir(cfg.BreachedPasswordsDir, cfg.BreachedPasswordsMinCount)
+		if err != nil {
+			log.Fatalf("FATAL: breached passwords list: %v", err)
+		}
+		passwordPolicy.Breached = breached
+	}
+	authSvc := auth_services.NewAuthService(userRepo, profileRepo, refreshRepo, userTokenRepo, totpRepo, identityRepo, oidc.NewProviders(cfg.OIDCProviders), personalTokenRepo, lockout.NewGuard(attemptStore), passwordPolicy, outMailer)
 	authHandler := auth_api.NewAuthHandler(authSvc)
 
+	// SIDEBAR: FAVORITES AND RECENT
+	sidebarRepo := sidebar_repository.NewSidebarRepo(db)
+	sidebarSvc := sidebar_services.NewSidebarService(sidebarRepo, cfg.RecentItemsLimit)
+	sidebarHandler := sidebar_api.NewSidebarHandler(sidebarSvc, authSvc)
+
 	// NOTION NOTES
 	pageRepo := notes_repository.NewPageRepo(db)
 	pageSvc := notes_services.NewPageService(pageRepo)
-	pageHandler := notes_api.NewPageHandler(pageSvc, authSvc)
+	pageHandler := notes_api.NewPageHandler(pageSvc, sidebarSvc, authSvc)
+
+	// NOTION PAGE BLOCKS
+	blockRepo := notes_repository.NewBlockRepo(db)
+	blockSvc := notes_services.NewBlockService(blockRepo)
+	blockHandler := notes_api.NewBlockHandler(blockSvc, authSvc)
+
+	// NOTION TAGS
+	tagRepo := notes_repository.NewTagRepo(db)
+	tagSvc := notes_services.NewTagService(tagRepo)
+	tagHandler := notes_api.NewTagHandler(tagSvc, authSvc)
 
 	// NOTION FOLDERS FOR NOTES
 	folderRepo := notes_repository.NewFolderRepo(db)
 	folderSvc := notes_services.NewFolderService(folderRepo)
 	folderHandler := notes_api.NewFolderHandler(folderSvc, authSvc)
 
+	// NOTION IMPORT / EXPORT
+	transferSvc := notes_services.NewTransferService(pageRepo, folderRepo)
+	transferHandler := notes_api.NewTransferHandler(transferSvc, authSvc)
+
+	// NOTION TEMPLATES AND DAILY NOTES
+	dailyRepo := notes_repository.NewDailyRepo(db)
+	templateSvc := notes_services.NewTemplateService(pageRepo, folderRepo, dailyRepo)
+	templateHandler := notes_api.NewTemplateHandler(templateSvc, authSvc)
+
 	// ANEMONE MAIL SERVICE
 	mailRepo := mail_repository.New(db)
 	mailService := mail_services.New(mailRepo, cfg.DomainName)
@@ -73,7 +137,7 @@ func main() {
 	// TRELLO BOARD
 	boardRepo := trello_repository.NewBoardRepo(db)
 	boardService := trello_services.NewBoardService(boardRepo)
-	boardHandler := trello_api.NewBoardHandler(boardService, authSvc)
+	boardHandler := trello_api.NewBoardHandler(boardService, sidebarSvc, authSvc)
 
 	// TRELLO COLUMN
 	columnRepo := trello_repository.NewColumnRepo(db)
@@ -85,15 +149,54 @@ func main() {
 	cardService := trello_services.NewCardService(cardRepo)
 	cardHandler := trello_api.NewCardHandler(cardService, authSvc, boardRepo)
 
+	// TRELLO CARD COMMENTS
+	commentRepo := trello_repository.NewCommentRepo(db)
+	commentService := trello_services.NewCommentService(commentRepo)
+	commentHandler := trello_api.NewCommentHandler(commentService, authSvc, boardRepo)
+
+	// TRELLO ACTIVITY
+	activityRepo := trello_repository.NewActivityRepo(db)
+	activityService := trello_services.NewActivityService(activityRepo)
+	activityHandler := trello_api.NewActivityHandler(activityService, authSvc, boardRepo)
+
+	// ATTACHMENTS
+	// Ключ подписывает ссылки на скачивание: с ключом по умолчанию или общим
+	// с JWT их может подделать любой
+	if key := cfg.AttachmentsSigningKey; key == "" || key == "default_secret" || key == cfg.JWTSecret {
+		log.Fatalf("FATAL: ATTACHMENTS_SIGNING_KEY must be set to its own secret")
+	}
+	attachmentStore, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("FATAL: attachments storage init failed: %v", err)
+	}
+	attachmentRepo := attachment_repository.NewAttachmentRepo(db)
+	attachmentService := attachment_services.NewAttachmentService(attachmentRepo, attachmentStore,
+		cfg.AttachmentsMaxBytes, cfg.AttachmentsSigningKey, time.Duration(cfg.AttachmentsURLTTLMinutes)*time.Minute)
+	attachmentHandler := attachment_api.NewAttachmentHandler(attachmentService, authSvc, boardRepo)
+
+	// ACCOUNT DATA EXPORT
+	exportRepo := export_repository.NewExportRepo(db)
+	exportService := export_services.NewExportService(exportRepo, attachmentStore, time.Duration(cfg.DataExportTTLHours)*time.Hour)
+	exportHandler := export_api.NewExportHandler(exportService, authSvc)
+
 	r := mux.NewRouter()
 
 	authHandler.RegisterRoutes(r)
+	transferHandler.TransferRoutes(r)
+	templateHandler.TemplateRoutes(r)
 	pageHandler.PagesRoutes(r)
+	blockHandler.BlockRoutes(r)
+	tagHandler.TagRoutes(r)
 	folderHandler.FolderRoutes(r)
 	mailHandler.RegisterRoutes(r)
 	boardHandler.BoardRoutes(r)
 	columnHandler.ColumnRoutes(r)
 	cardHandler.CardRoutes(r)
+	commentHandler.CommentRoutes(r)
+	activityHandler.ActivityRoutes(r)
+	attachmentHandler.AttachmentRoutes(r)
+	exportHandler.ExportRoutes(r)
+	sidebarHandler.SidebarRoutes(r)
 
 	handlerWithCORS := setupCORS(r)
 
@@ -114,6 +217,86 @@ func main() {
 		}
 	}()
 
+	// BACKGROUND JOBS
+	go jobs.Every(context.Background(), "trello archive purge", time.Hour, func(ctx context.Context) error {
+		purged, err := boardService.PurgeArchived(ctx, cfg.TrelloArchiveRetentionDays)
+		if purged > 0 {
+			log.Printf("INFO: Purged %d archived trello items", purged)
+		}
+		return err
+	})
+
+	go jobs.Every(context.Background(), "notes trash purge", time.Hour, func(ctx context.Context) error {
+		purged, err := pageSvc.PurgeExpiredTrash(ctx, cfg.NotesTrashRetentionDays)
+		if purged > 0 {
+			log.Printf("INFO: Purged %d notes from trash", purged)
+		}
+		return err
+	})
+
+	go jobs.Every(context.Background(), "attachments orphan cleanup", 15*time.Minute, func(ctx context.Context) error {
+		removed, err := attachmentService.CleanupOrphans(ctx)
+		if removed > 0 {
+			log.Printf("INFO: Removed %d orphaned attachment files", removed)
+		}
+		return err
+	})
+
+	go jobs.Every(context.Background(), "auth tokens cleanup", time.Hour, func(ctx context.Context) error {
+		removed, err := authSvc.CleanupTokens(ctx)
+		if removed > 0 {
+			log.Printf("INFO: Removed %d stale email tokens", removed)
+		}
+		return err
+	})
+
+	go jobs.Every(context.Background(), "sso login states cleanup", time.Hour, func(ctx context.Context) error {
+		_, err := authSvc.CleanupSSOStates(ctx)
+		return err
+	})
+
+	go jobs.Every(context.Background(), "personal tokens cleanup", 24*time.Hour, func(ctx context.Context) error {
+		_, err := authSvc.PurgeExpiredPersonalTokens(ctx)
+		return err
+	})
+
+	go jobs.Every(context.Background(), "login attempts cleanup", time.Hour, func(ctx context.Context) error {
+		_, err := authSvc.CleanupLoginAttempts(ctx)
+		return err
+	})
+
+	go jobs.Every(context.Background(), "refresh tokens cleanup", time.Hour, func(ctx context.Context) error {
+		removed, err := authSvc.PurgeExpiredRefresh(ctx)
+		if removed > 0 {
+			log.Printf("INFO: Removed %d expired refresh tokens", removed)
+		}
+		return err
+	})
+
+	go jobs.Every(context.Background(), "account deletion", time.Hour, func(ctx context.Context) error {
+		deleted, err := authSvc.PurgeDeletedAccounts(ctx)
+		if deleted > 0 {
+			log.Printf("INFO: Deleted %d accounts after grace period", deleted)
+		}
+		return err
+	})
+
+	go jobs.Every(context.Background(), "data exports", time.Minute, func(ctx context.Context) error {
+		built, err := exportService.ProcessPending(ctx)
+		if built > 0 {
+			log.Printf("INFO: Built %d data exports", built)
+		}
+		return err
+	})
+
+	go jobs.Every(context.Background(), "data exports cleanup", time.Hour, func(ctx context.Context) error {
+		removed, err := exportService.CleanupExpired(ctx)
+		if removed > 0 {
+			log.Printf("INFO: Removed %d expired data exports", removed)
+		}
+		return err
+	})
+
 	log.Println("INFO: All services are running")
 
 	wg.Wait()
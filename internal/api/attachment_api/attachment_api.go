@@ -0,0 +1,253 @@
+package attachment_api
+
+import (
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/attachment_repository"
+	"anemone_notes/internal/services/attachment_services"
+	"anemone_notes/internal/services/auth_services"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Запас на заголовки multipart сверх максимального размера файла
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	Service     *attachment_services.AttachmentService
+	AuthService *auth_services.AuthService
+	BoardRepo   middlewares.BoardRepoInterface
+}
+
+func NewAttachmentHandler(s *attachment_services.AttachmentService, a *auth_services.AuthService, br middlewares.BoardRepoInterface) *AttachmentHandler {
+	return &AttachmentHandler{Service: s, AuthService: a, BoardRepo: br}
+}
+
+func (h *AttachmentHandler) AttachmentRoutes(r *mux.Router) {
+	boardRepo := h.BoardRepo
+
+	// Page attachments - Status: WORK
+	r.Handle("/api/v1/notes/{id}/attachments",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getPageAttachments)),
+	).Methods("GET")
+	r.Handle("/api/v1/notes/{id}/attachments",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.uploadPageAttachment)),
+	).Methods("POST")
+
+	// Card attachments - Status: WORK
+	r.Handle("/api/v1/trello/column/{columnID}/card/{cardID}/attachments",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.getCardAttachments))),
+	).Methods("GET")
+	r.Handle("/api/v1/trello/column/{columnID}/card/{cardID}/attachments",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.uploadCardAttachment))),
+	).Methods("POST")
+
+	// Signed download url - Status: WORK
+	r.Handle("/api/v1/attachments/{attachmentID}/url",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getSignedURL)),
+	).Methods("GET")
+	// Download by signed url, no auth header needed - Status: WORK
+	r.HandleFunc("/api/v1/attachments/{attachmentID}/download", h.download).Methods("GET")
+	// Delete attachment - Status: WORK
+	r.Handle("/api/v1/attachments/{attachmentID}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.deleteAttachment)),
+	).Methods("DELETE")
+}
+
+func handleAttachmentError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	message := "Internal server error"
+
+	switch {
+	case errors.Is(err, attachment_repository.ErrAttachmentNotFound):
+		status, message = http.StatusNotFound, "Attachment not found"
+	case errors.Is(err, attachment_repository.ErrParentNotFound):
+		status, message = http.StatusNotFound, "Page or card not found"
+	case errors.Is(err, attachment_services.ErrFileTooLarge):
+		status, message = http.StatusRequestEntityTooLarge, "File is too large"
+	case errors.Is(err, attachment_services.ErrMimeNotAllowed):
+		status, message = http.StatusUnsupportedMediaType, "File type is not allowed"
+	case errors.Is(err, attachment_services.ErrEmptyFile):
+		status, message = http.StatusBadRequest, "File is empty"
+	case errors.Is(err, attachment_services.ErrInvalidSignature):
+		status, message = http.StatusForbidden, "Download link is invalid or expired"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// filePart находит поле file в multipart-запросе и отдаёт его потоком,
+// не складывая весь запрос в память.
+func (h *AttachmentHandler) filePart(w http.ResponseWriter, r *http.Request) (io.Reader, string, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.Service.MaxBytes+multipartOverhead)
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "multipart/form-data with a file field is required", http.StatusBadRequest)
+		return nil, "", false
+	}
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return nil, "", false
+		}
+		if part.FormName() == "file" {
+			return part, part.FileName(), true
+		}
+		part.Close()
+	}
+}
+
+func (h *AttachmentHandler) getPageAttachments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	pageID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.Service.GetPageAttachments(r.Context(), userID, pageID)
+	if err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *AttachmentHandler) uploadPageAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	pageID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	file, name, ok := h.filePart(w, r)
+	if !ok {
+		return
+	}
+
+	a, err := h.Service.UploadToPage(r.Context(), userID, pageID, name, file)
+	if err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+func (h *AttachmentHandler) getCardAttachments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	items, err := h.Service.GetCardAttachments(r.Context(), vars["columnID"], vars["cardID"])
+	if err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *AttachmentHandler) uploadCardAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	vars := mux.Vars(r)
+
+	file, name, ok := h.filePart(w, r)
+	if !ok {
+		return
+	}
+
+	a, err := h.Service.UploadToCard(r.Context(), userID, vars["columnID"], vars["cardID"], name, file)
+	if err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+func (h *AttachmentHandler) getSignedURL(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	url, err := h.Service.SignedURL(r.Context(), userID, mux.Vars(r)["attachmentID"])
+	if err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(url)
+}
+
+func (h *AttachmentHandler) download(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	a, body, err := h.Service.Open(r.Context(), mux.Vars(r)["attachmentID"], q.Get("expires"), q.Get("sig"))
+	if err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+	defer body.Close()
+
+	// Картинки и PDF показываем в браузере, остальное — только скачиванием
+	disposition := "attachment"
+	if strings.HasPrefix(a.MimeType, "image/") || a.MimeType == "application/pdf" {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", a.MimeType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	io.Copy(w, body)
+}
+
+func (h *AttachmentHandler) deleteAttachment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Service.DeleteAttachment(r.Context(), userID, mux.Vars(r)["attachmentID"]); err != nil {
+		handleAttachmentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Attachment deleted"})
+}
@@ -1,10 +1,19 @@
 package auth_api
 
 import (
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/model/auth_model"
+	"anemone_notes/internal/oidc"
+	"anemone_notes/internal/repository/auth_repository"
 	"anemone_notes/internal/services/auth_services"
 	"encoding/json"
+	"errors"
+	"log"
+	"net"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -20,9 +29,76 @@ func NewAuthHandler(s *auth_services.AuthService) *AuthHandler {
 func (h *AuthHandler) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/api/v1/auth/register", h.register).Methods("POST")
 	r.HandleFunc("/api/v1/auth/login", h.login).Methods("POST")
+	r.HandleFunc("/api/v1/auth/login/mfa", h.loginMFA).Methods("POST")
 	r.HandleFunc("/api/v1/auth/change-password", h.changePassword).Methods("POST")
 	r.HandleFunc("/api/v1/auth/refresh", h.refresh).Methods("POST")
 	r.HandleFunc("/api/v1/auth/logout", h.logout).Methods("POST")
+	r.HandleFunc("/api/v1/auth/verify-email", h.verifyEmail).Methods("POST")
+	r.HandleFunc("/api/v1/auth/resend-verification", h.resendVerification).Methods("POST")
+	r.HandleFunc("/api/v1/auth/forgot-password", h.forgotPassword).Methods("POST")
+	r.HandleFunc("/api/v1/auth/reset-password", h.resetPassword).Methods("POST")
+	r.HandleFunc("/api/v1/auth/unlock", h.unlockAccount).Methods("POST")
+
+	// Active sessions - Status: WORK
+	r.Handle("/api/v1/auth/sessions",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.getSessions)),
+	).Methods("GET")
+	// Revoke all sessions except the current one - Status: WORK
+	r.Handle("/api/v1/auth/sessions",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.revokeOtherSessions)),
+	).Methods("DELETE")
+	r.Handle("/api/v1/auth/sessions/{sessionID}",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.revokeSession)),
+	).Methods("DELETE")
+
+	// Personal access tokens for scripts and CI - Status: WORK
+	r.Handle("/api/v1/auth/tokens",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.getPersonalTokens)),
+	).Methods("GET")
+	r.Handle("/api/v1/auth/tokens",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.createPersonalToken)),
+	).Methods("POST")
+	r.Handle("/api/v1/auth/tokens/{tokenID:[0-9]+}",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.revokePersonalToken)),
+	).Methods("DELETE")
+
+	// Profile and UI preferences - Status: WORK
+	r.Handle("/api/v1/auth/profile",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.getProfile)),
+	).Methods("GET")
+	r.Handle("/api/v1/auth/profile",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.updateProfile)),
+	).Methods("PATCH")
+	// Email change, confirmed by a link sent to the new address - Status: WORK
+	r.Handle("/api/v1/auth/profile/email",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.requestEmailChange)),
+	).Methods("POST")
+	r.HandleFunc("/api/v1/auth/profile/email/confirm", h.confirmEmailChange).Methods("POST")
+
+	// Account deletion with grace period - Status: WORK
+	r.Handle("/api/v1/auth/account",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.deleteAccount)),
+	).Methods("DELETE")
+	r.Handle("/api/v1/auth/account/restore",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.restoreAccount)),
+	).Methods("POST")
+
+	// SSO via OpenID Connect - Status: WORK
+	r.HandleFunc("/api/v1/auth/oidc/providers", h.ssoProviders).Methods("GET")
+	r.HandleFunc("/api/v1/auth/oidc/exchange", h.ssoExchange).Methods("POST")
+	r.HandleFunc("/api/v1/auth/oidc/{provider}/start", h.ssoStart).Methods("GET")
+	r.HandleFunc("/api/v1/auth/oidc/{provider}/callback", h.ssoCallback).Methods("GET")
+
+	// Two-factor authentication (TOTP) - Status: WORK
+	r.Handle("/api/v1/auth/2fa/setup",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.setupTOTP)),
+	).Methods("POST")
+	r.Handle("/api/v1/auth/2fa/confirm",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.confirmTOTP)),
+	).Methods("POST")
+	r.Handle("/api/v1/auth/2fa/disable",
+		middlewares.AuthMiddleware(h.Service, http.HandlerFunc(h.disableTOTP)),
+	).Methods("POST")
 }
 
 func (h *AuthHandler) register(w http.ResponseWriter, r *http.Request) {
@@ -35,17 +111,15 @@ func (h *AuthHandler) register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	accessToken, refreshToken, u, err := h.Service.Register(r.Context(), req.Email, req.Password)
+	accessToken, refreshToken, u, err := h.Service.Register(r.Context(), req.Email, req.Password, clientInfo(r))
+	if writeValidationError(w, err) {
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-		"user_data":     u,
-	})
+	h.writeTokens(w, r, accessToken, refreshToken, u)
 }
 
 func (h *AuthHandler) login(w http.ResponseWriter, r *http.Request) {
@@ -58,18 +132,25 @@ func (h *AuthHandler) login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	access, refresh, user_data, err := h.Service.Login(r.Context(), req.Email, req.Password)
+	access, refresh, user_data, err := h.Service.Login(r.Context(), req.Email, req.Password, clientInfo(r))
+	var mfa *auth_services.MFARequiredError
+	if errors.As(err, &mfa) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"mfa_required": true,
+			"mfa_token":    mfa.Token,
+		})
+		return
+	}
+	if writeTooManyAttempts(w, err) {
+		return
+	}
 	if err != nil {
 		http.Error(w, "invalid credentials", http.StatusUnauthorized)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
-		"access_token":  access,
-		"refresh_token": refresh,
-		"user_data": user_data,
-	})
+	h.writeTokens(w, r, access, refresh, user_data)
 }
 
 func (h *AuthHandler) changePassword(w http.ResponseWriter, r *http.Request) {
@@ -77,13 +158,24 @@ func (h *AuthHandler) changePassword(w http.ResponseWriter, r *http.Request) {
 		Email       string `json:"email"`
 		OldPassword string `json:"old_password"`
 		NewPassword string `json:"new_password"`
+		Code        string `json:"code"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 	
-	if err := h.Service.ChangePassword(r.Context(), req.Email, req.OldPassword, req.NewPassword); err != nil {
+	if err := h.Service.ChangePassword(r.Context(), req.Email, req.OldPassword, req.NewPassword, req.Code); err != nil {
+		if writeTooManyAttempts(w, err) || writeValidationError(w, err) {
+			return
+		}
+		// с включённой 2FA нужен код из приложения или код восстановления
+		if errors.Is(err, auth_services.ErrInvalidMFACode) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]any{"message": err.Error(), "mfa_required": true})
+			return
+		}
 		status := http.StatusBadRequest
 		if strings.Contains(err.Error(), "invalid old password") || strings.Contains(err.Error(), "user not found") {
 			status = http.StatusUnauthorized
@@ -105,13 +197,12 @@ func (h *AuthHandler) refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newAccess, user_data, err := h.Service.RefreshToken(r.Context(), req.RefreshToken)
+	newAccess, newRefresh, user_data, err := h.Service.RefreshToken(r.Context(), req.RefreshToken, clientInfo(r))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{"access_token": newAccess, "user_data": user_data})
+	h.writeTokens(w, r, newAccess, newRefresh, user_data)
 }
 
 func (h *AuthHandler) logout(w http.ResponseWriter, r *http.Request) {
@@ -128,4 +219,686 @@ func (h *AuthHandler) logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	w.WriteHeader(http.StatusOK)
-}
\ No newline at end of file
+}
+
+func (h *AuthHandler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.VerifyEmail(r.Context(), req.Token); err != nil {
+		if errors.Is(err, auth_repository.ErrInvalidToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Email verification failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Ответ всегда 202, чтобы по нему нельзя было узнать, зарегистрирован ли адрес
+func (h *AuthHandler) resendVerification(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.ResendVerification(r.Context(), req.Email); err != nil {
+		http.Error(w, "Failed to send verification email", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) forgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		http.Error(w, "Failed to send password reset email", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) resetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.ResetPassword(r.Context(), req.Token, req.NewPassword); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, auth_repository.ErrInvalidToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Password reset failed", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// clientInfo описывает устройство для списка сессий. IP из X-Forwarded-For
+// подделывается клиентом, поэтому он только для показа пользователю.
+func clientInfo(r *http.Request) auth_model.ClientInfo {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	ip := r.Header.Get("X-Forwarded-For")
+	if i := strings.IndexByte(ip, ','); i >= 0 {
+		ip = ip[:i]
+	}
+	ip = strings.TrimSpace(ip)
+	if ip == "" {
+		ip = remote
+	}
+
+	ua := r.UserAgent()
+	if len(ua) > 512 {
+		ua = ua[:512]
+	}
+	return auth_model.ClientInfo{UserAgent: ua, IPAddress: ip, RemoteIP: remote}
+}
+
+func (h *AuthHandler) getSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	sessions, err := h.Service.ListSessions(r.Context(), userID, middlewares.GetSessionIDFromContext(r.Context()))
+	if err != nil {
+		http.Error(w, "Failed to load sessions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+func (h *AuthHandler) revokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Service.RevokeSession(r.Context(), userID, mux.Vars(r)["sessionID"]); err != nil {
+		if errors.Is(err, auth_repository.ErrSessionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke session", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) revokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	revoked, err := h.Service.RevokeOtherSessions(r.Context(), userID, middlewares.GetSessionIDFromContext(r.Context()))
+	if err != nil {
+		if errors.Is(err, auth_services.ErrUnknownSession) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"revoked": revoked})
+}
+
+func (h *AuthHandler) loginMFA(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	access, refresh, user_data, err := h.Service.CompleteMFALogin(r.Context(), req.MFAToken, req.Code, clientInfo(r))
+	if writeTooManyAttempts(w, err) {
+		return
+	}
+	if err != nil {
+		if errors.Is(err, auth_services.ErrInvalidMFACode) || errors.Is(err, auth_services.ErrInvalidMFAChallenge) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "Login failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokens(w, r, access, refresh, user_data)
+}
+
+func writeTOTPError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth_repository.ErrTOTPNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, auth_repository.ErrTOTPAlreadyEnabled):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, auth_services.ErrInvalidMFACode), errors.Is(err, auth_services.ErrInvalidPassword),
+		errors.Is(err, auth_services.ErrReauthRequired):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	default:
+		http.Error(w, "Two-factor operation failed", http.StatusInternalServerError)
+	}
+}
+
+func (h *AuthHandler) setupTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	setup, err := h.Service.SetupTOTP(r.Context(), userID)
+	if err != nil {
+		writeTOTPError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(setup)
+}
+
+func (h *AuthHandler) confirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.Service.ConfirmTOTP(r.Context(), userID, req.Code)
+	if err != nil {
+		writeTOTPError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"recovery_codes": codes})
+}
+
+func (h *AuthHandler) disableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := h.Service.DisableTOTP(r.Context(), userID, middlewares.GetSessionIDFromContext(r.Context()), req.Password, req.Code)
+	if err != nil {
+		if writeTooManyAttempts(w, err) {
+			return
+		}
+		writeTOTPError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ssoProviders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"providers": h.Service.ProviderNames()})
+}
+
+// ssoStateCookie привязывает вход к браузеру, который его начал.
+const ssoStateCookie = "anemone_sso_state"
+
+func (h *AuthHandler) ssoStart(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	authURL, state, err := h.Service.StartSSO(r.Context(), provider)
+	if err != nil {
+		if errors.Is(err, oidc.ErrUnknownProvider) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR starting SSO login: %v", err)
+		http.Error(w, "Login provider is unavailable", http.StatusBadGateway)
+		return
+	}
+	// Lax: cookie уходит при возврате с провайдера обычным переходом
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Value:    state,
+		Path:     "/api/v1/auth/oidc/" + provider + "/callback",
+		MaxAge:   int(auth_services.SSOStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// ssoCallback всегда возвращает браузер на фронтенд: с одноразовым кодом
+// или с кодом ошибки.
+func (h *AuthHandler) ssoCallback(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	var browserState string
+	if c, err := r.Cookie(ssoStateCookie); err == nil {
+		browserState = c.Value
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     ssoStateCookie,
+		Path:     "/api/v1/auth/oidc/" + provider + "/callback",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	q := r.URL.Query()
+	if providerErr := q.Get("error"); providerErr != "" {
+		err := auth_services.ErrSSOProvider
+		if providerErr == "access_denied" {
+			err = auth_services.ErrSSODenied
+		} else {
+			log.Printf("WARN: SSO provider %q returned error %q: %s", provider, providerErr, q.Get("error_description"))
+		}
+		http.Redirect(w, r, auth_services.SSORedirectURL("", err), http.StatusFound)
+		return
+	}
+
+	code, err := h.Service.FinishSSO(r.Context(), provider, q.Get("state"), browserState, q.Get("code"))
+	if err != nil && auth_services.SSOErrorCode(err) == "sso_failed" {
+		log.Printf("ERROR finishing SSO login: %v", err)
+	}
+	http.Redirect(w, r, auth_services.SSORedirectURL(code, err), http.StatusFound)
+}
+
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func (h *AuthHandler) ssoExchange(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	access, refresh, user_data, err := h.Service.ExchangeSSOCode(r.Context(), req.Code, clientInfo(r))
+	var mfa *auth_services.MFARequiredError
+	if errors.As(err, &mfa) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"mfa_required": true,
+			"mfa_token":    mfa.Token,
+		})
+		return
+	}
+	if err != nil {
+		if errors.Is(err, auth_repository.ErrInvalidToken) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, "Login failed", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeTokens(w, r, access, refresh, user_data)
+}
+
+func (h *AuthHandler) getPersonalTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := h.Service.ListPersonalTokens(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to load tokens", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"tokens":           tokens,
+		"available_scopes": auth_services.PersonalTokenScopes(),
+	})
+}
+
+func (h *AuthHandler) createPersonalToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	token, t, err := h.Service.CreatePersonalToken(r.Context(), userID, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth_services.ErrInvalidTokenName),
+			errors.Is(err, auth_services.ErrInvalidTokenScopes),
+			errors.Is(err, auth_services.ErrInvalidTokenExpiry):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, auth_services.ErrTooManyTokens):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to create token", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"token":      token,
+		"token_data": t,
+	})
+}
+
+func (h *AuthHandler) revokePersonalToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	tokenID, err := strconv.Atoi(mux.Vars(r)["tokenID"])
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.RevokePersonalToken(r.Context(), userID, tokenID); err != nil {
+		if errors.Is(err, auth_repository.ErrPersonalTokenNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeProfileError(w http.ResponseWriter, err error) {
+	if writeValidationError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, auth_repository.ErrInvalidToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, auth_services.ErrInvalidPassword),
+		errors.Is(err, auth_services.ErrReauthRequired):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, auth_repository.ErrProfileNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, auth_repository.ErrEmailTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("ERROR profile operation: %v", err)
+		http.Error(w, "Profile operation failed", http.StatusInternalServerError)
+	}
+}
+
+func (h *AuthHandler) getProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	profile, err := h.Service.GetProfile(r.Context(), userID)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// updateProfile меняет только переданные поля. Пустые avatar_url и
+// default_board_id и нулевой default_folder_id сбрасывают значение.
+func (h *AuthHandler) updateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	var req struct {
+		DisplayName *string `json:"display_name"`
+		AvatarURL   *string `json:"avatar_url"`
+		Timezone    *string `json:"timezone"`
+		Locale      *string `json:"locale"`
+		Preferences struct {
+			Theme           *string `json:"theme"`
+			DefaultBoardID  *string `json:"default_board_id"`
+			DefaultFolderID *int    `json:"default_folder_id"`
+		} `json:"preferences"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := h.Service.UpdateProfile(r.Context(), userID, auth_repository.ProfileUpdate{
+		DisplayName:     req.DisplayName,
+		AvatarURL:       req.AvatarURL,
+		Timezone:        req.Timezone,
+		Locale:          req.Locale,
+		Theme:           req.Preferences.Theme,
+		DefaultBoardID:  req.Preferences.DefaultBoardID,
+		DefaultFolderID: req.Preferences.DefaultFolderID,
+	})
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+func (h *AuthHandler) requestEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := h.Service.RequestEmailChange(r.Context(), userID,
+		middlewares.GetSessionIDFromContext(r.Context()), req.Email, req.Password)
+	if err != nil {
+		if writeTooManyAttempts(w, err) {
+			return
+		}
+		writeProfileError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) confirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.ConfirmEmailChange(r.Context(), req.Token); err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *AuthHandler) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	deleteAt, err := h.Service.RequestAccountDeletion(r.Context(), userID,
+		middlewares.GetSessionIDFromContext(r.Context()), req.Password, req.Code)
+	if err != nil {
+		if writeTooManyAttempts(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, auth_services.ErrInvalidPassword),
+			errors.Is(err, auth_services.ErrInvalidMFACode),
+			errors.Is(err, auth_services.ErrReauthRequired):
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
+			log.Printf("ERROR scheduling account deletion for user %d: %v", userID, err)
+			http.Error(w, "Failed to delete account", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{"deletion_scheduled_at": deleteAt})
+}
+
+func (h *AuthHandler) restoreAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.Service.CancelAccountDeletion(r.Context(), userID); err != nil {
+		if errors.Is(err, auth_services.ErrDeletionNotScheduled) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Failed to restore account", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeTokens отвечает на успешный вход: пара токенов и профиль пользователя.
+func (h *AuthHandler) writeTokens(w http.ResponseWriter, r *http.Request, access, refresh string, u *auth_model.User) {
+	profile, err := h.Service.GetProfile(r.Context(), u.ID)
+	if err != nil {
+		http.Error(w, "Failed to load profile", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token":  access,
+		"refresh_token": refresh,
+		"user_data":     profile,
+	})
+}
+
+// writeValidationError отвечает 422 со списком ошибок по полям.
+func writeValidationError(w http.ResponseWriter, err error) bool {
+	var invalid *auth_services.ValidationError
+	if !errors.As(err, &invalid) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message": "validation failed",
+		"errors":  invalid.Fields,
+	})
+	return true
+}
+
+// writeTooManyAttempts отвечает 429 с Retry-After, если вход временно запрещён.
+func writeTooManyAttempts(w http.ResponseWriter, err error) bool {
+	var tooMany *auth_services.TooManyAttemptsError
+	if !errors.As(err, &tooMany) {
+		return false
+	}
+	seconds := int((tooMany.RetryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, tooMany.Error(), http.StatusTooManyRequests)
+	return true
+}
+
+func (h *AuthHandler) unlockAccount(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.UnlockAccount(r.Context(), req.Token); err != nil {
+		if errors.Is(err, auth_repository.ErrInvalidToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to unlock account", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,120 @@
+package export_api
+
+import (
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/export_repository"
+	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/export_services"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type ExportHandler struct {
+	Service     *export_services.ExportService
+	AuthService *auth_services.AuthService
+}
+
+func NewExportHandler(s *export_services.ExportService, a *auth_services.AuthService) *ExportHandler {
+	return &ExportHandler{Service: s, AuthService: a}
+}
+
+func (h *ExportHandler) ExportRoutes(r *mux.Router) {
+	// Request data export (GDPR) - Status: WORK
+	r.Handle("/api/v1/auth/account/export",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.requestExport)),
+	).Methods("POST")
+	// Export status - Status: WORK
+	r.Handle("/api/v1/auth/account/export/{exportID}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getExport)),
+	).Methods("GET")
+	// Download ready export - Status: WORK
+	r.Handle("/api/v1/auth/account/export/{exportID}/download",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.download)),
+	).Methods("GET")
+}
+
+func handleExportError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	message := "Internal server error"
+
+	switch {
+	case errors.Is(err, export_repository.ErrExportNotFound):
+		status, message = http.StatusNotFound, "Export not found"
+	case errors.Is(err, export_repository.ErrExportInProgress):
+		status, message = http.StatusConflict, "An export is already in progress"
+	case errors.Is(err, export_services.ErrExportNotReady):
+		status, message = http.StatusConflict, "Export is not ready yet"
+	default:
+		log.Printf("ERROR data export: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func (h *ExportHandler) requestExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	e, err := h.Service.RequestExport(r.Context(), userID)
+	if err != nil {
+		handleExportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(e)
+}
+
+func (h *ExportHandler) getExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	e, err := h.Service.GetExport(r.Context(), userID, mux.Vars(r)["exportID"])
+	if err != nil {
+		handleExportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+func (h *ExportHandler) download(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	e, body, err := h.Service.Open(r.Context(), userID, mux.Vars(r)["exportID"])
+	if err != nil {
+		handleExportError(w, err)
+		return
+	}
+	defer body.Close()
+
+	fileName := "anemone-export-" + e.CreatedAt.UTC().Format("2006-01-02") + ".zip"
+	w.Header().Set("Content-Type", "application/zip")
+	if e.Size != nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(*e.Size, 10))
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	w.Header().Set("Cache-Control", "no-store")
+	io.Copy(w, body)
+}
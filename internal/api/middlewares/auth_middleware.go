@@ -1,13 +1,17 @@
 package middlewares
 
 import (
+  "anemone_notes/internal/repository/auth_repository"
   "anemone_notes/internal/services/auth_services"
   "context"
+  "errors"
+  "log"
   "net/http"
   "strings"
 )
 
 const userIDKey contextKey = "user_id"
+const sessionIDKey contextKey = "session_id"
 
 func GetUserIDFromContext(ctx context.Context) (int, bool) {
     userIDVal := ctx.Value(userIDKey)
@@ -15,6 +19,12 @@ func GetUserIDFromContext(ctx context.Context) (int, bool) {
     return userID, ok
 }
 
+// GetSessionIDFromContext возвращает сессию access-токена; у старых токенов её нет.
+func GetSessionIDFromContext(ctx context.Context) string {
+    sessionID, _ := ctx.Value(sessionIDKey).(string)
+    return sessionID
+}
+
 func AuthMiddleware(auth *auth_services.AuthService, next http.Handler) http.Handler {
   return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
     authHeader := r.Header.Get("Authorization")
@@ -24,13 +34,62 @@ func AuthMiddleware(auth *auth_services.AuthService, next http.Handler) http.Han
     }
 
     tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-    userID, err := auth.ParseAccessToken(tokenStr)
+    if strings.HasPrefix(tokenStr, auth_services.PersonalTokenPrefix) {
+      personalTokenAuth(auth, tokenStr, next, w, r)
+      return
+    }
+
+    claims, err := auth.ParseAccess(tokenStr)
     if err != nil {
       http.Error(w, "invalid token", http.StatusUnauthorized)
       return
     }
 
-    ctx := context.WithValue(r.Context(), userIDKey, userID) 
+    revoked, err := auth.IsAccessRevoked(r.Context(), claims.JTI)
+    if err != nil {
+      log.Printf("ERROR checking access token denylist: %v", err)
+      http.Error(w, "failed to check token", http.StatusInternalServerError)
+      return
+    }
+    if revoked {
+      http.Error(w, "session revoked", http.StatusUnauthorized)
+      return
+    }
+
+    if claims.Unverified && !auth.AllowUnverified(r.Method) {
+      http.Error(w, "email not verified", http.StatusForbidden)
+      return
+    }
+
+    ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
+    ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
     next.ServeHTTP(w, r.WithContext(ctx))
   })
-}
\ No newline at end of file
+}
+
+// personalTokenAuth пропускает запрос по персональному токену, если его
+// scope покрывают группу маршрутов, а политика неподтверждённой почты
+// разрешает метод.
+func personalTokenAuth(auth *auth_services.AuthService, tokenStr string, next http.Handler, w http.ResponseWriter, r *http.Request) {
+  pat, err := auth.ParsePersonalToken(r.Context(), tokenStr)
+  if err != nil {
+    if errors.Is(err, auth_repository.ErrPersonalTokenNotFound) {
+      http.Error(w, "invalid token", http.StatusUnauthorized)
+      return
+    }
+    log.Printf("ERROR checking personal access token: %v", err)
+    http.Error(w, "failed to check token", http.StatusInternalServerError)
+    return
+  }
+  if !auth_services.PersonalTokenAllows(pat.Scopes, r.Method, r.URL.Path) {
+    http.Error(w, "token scope does not allow this request", http.StatusForbidden)
+    return
+  }
+  if pat.Unverified && !auth.AllowUnverified(r.Method) {
+    http.Error(w, "email not verified", http.StatusForbidden)
+    return
+  }
+
+  ctx := context.WithValue(r.Context(), userIDKey, pat.UserID)
+  next.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -0,0 +1,200 @@
+package notes_api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/notes_repository"
+	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/notes_services"
+
+	"github.com/gorilla/mux"
+)
+
+type BlockHandler struct {
+	Service     *notes_services.BlockService
+	AuthService *auth_services.AuthService
+}
+
+func NewBlockHandler(s *notes_services.BlockService, a *auth_services.AuthService) *BlockHandler {
+	return &BlockHandler{Service: s, AuthService: a}
+}
+
+func (h *BlockHandler) BlockRoutes(r *mux.Router) {
+	// Get note blocks - Status: WORK
+	r.Handle("/api/v1/notes/{id}/blocks",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getBlocks)),
+	).Methods("GET")
+	// Insert block into note - Status: WORK
+	r.Handle("/api/v1/notes/{id}/blocks",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.insertBlock)),
+	).Methods("POST")
+	// Update block - Status: WORK
+	r.Handle("/api/v1/notes/{id}/blocks/{blockID}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.updateBlock)),
+	).Methods("PUT")
+	// Move block inside note - Status: WORK
+	r.Handle("/api/v1/notes/{id}/blocks/{blockID}/move",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.moveBlock)),
+	).Methods("PUT")
+	// Delete block - Status: WORK
+	r.Handle("/api/v1/notes/{id}/blocks/{blockID}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.deleteBlock)),
+	).Methods("DELETE")
+}
+
+func writeBlockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notes_repository.ErrPageNotFound),
+		errors.Is(err, notes_repository.ErrBlockNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, notes_services.ErrInvalidBlockType),
+		errors.Is(err, notes_services.ErrInvalidBlockProps):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// blockPathIDs достаёт пользователя, id страницы и (если есть в пути) id блока.
+func blockPathIDs(w http.ResponseWriter, r *http.Request) (int, int, int, bool) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return 0, 0, 0, false
+	}
+
+	vars := mux.Vars(r)
+	pageID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+
+	blockID := 0
+	if raw, ok := vars["blockID"]; ok {
+		blockID, err = strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "invalid block id", http.StatusBadRequest)
+			return 0, 0, 0, false
+		}
+	}
+	return userID, pageID, blockID, true
+}
+
+func (h *BlockHandler) getBlocks(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, _, ok := blockPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	blocks, err := h.Service.GetPageBlocks(r.Context(), userID, pageID)
+	if err != nil {
+		writeBlockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(blocks)
+}
+
+func (h *BlockHandler) insertBlock(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, _, ok := blockPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Type     string          `json:"type"`
+		Content  string          `json:"content"`
+		Props    json.RawMessage `json:"props"`
+		Position int             `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	b, err := h.Service.InsertBlock(r.Context(), userID, pageID, req.Type, req.Content, req.Props, req.Position)
+	if err != nil {
+		writeBlockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(b)
+}
+
+func (h *BlockHandler) updateBlock(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, blockID, ok := blockPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Type    *string         `json:"type"`
+		Content *string         `json:"content"`
+		Props   json.RawMessage `json:"props"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	upd := notes_repository.BlockUpdate{Type: req.Type, Content: req.Content, Props: req.Props}
+	b, err := h.Service.UpdateBlock(r.Context(), userID, pageID, blockID, upd)
+	if err != nil {
+		writeBlockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b)
+}
+
+func (h *BlockHandler) moveBlock(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, blockID, ok := blockPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Position int `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	b, err := h.Service.MoveBlock(r.Context(), userID, pageID, blockID, req.Position)
+	if err != nil {
+		writeBlockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b)
+}
+
+func (h *BlockHandler) deleteBlock(w http.ResponseWriter, r *http.Request) {
+	userID, pageID, blockID, ok := blockPathIDs(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteBlock(r.Context(), userID, pageID, blockID); err != nil {
+		writeBlockError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := Response{Status: "Success"}
+	json.NewEncoder(w).Encode(response)
+}
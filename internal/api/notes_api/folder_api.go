@@ -2,9 +2,11 @@ package notes_api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/notes_repository"
 	"anemone_notes/internal/services/auth_services"
 	"anemone_notes/internal/services/notes_services"
 
@@ -25,7 +27,7 @@ func (h *FolderHandler) FolderRoutes(r *mux.Router) {
 	r.Handle("/api/v1/folder/create",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.createFolder)),
 	).Methods("POST")
-	// Get all folders by user id - Status: WORK
+	// Get folders tree with page counts by user id - Status: WORK
 	r.Handle("/api/v1/folder/{id}",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getAllFolders)),
 	).Methods("GET")
@@ -33,7 +35,11 @@ func (h *FolderHandler) FolderRoutes(r *mux.Router) {
 	r.Handle("/api/v1/folder/update",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.updateTitleFolder)),
 	).Methods("PUT")
-	// Delete folder by id - Status: WORK
+	// Move folder with contents - Status: WORK
+	r.Handle("/api/v1/folder/{id}/move",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.moveFolder)),
+	).Methods("PUT")
+	// Delete folder by id, ?strategy=move|trash - Status: WORK
 	r.Handle("/api/v1/folder/delete/{id}",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.deleteFolder)),
 	).Methods("DELETE")
@@ -41,8 +47,9 @@ func (h *FolderHandler) FolderRoutes(r *mux.Router) {
 
 func (h *FolderHandler) createFolder(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		UserID int    `json:"user_id"`
-		Title  string `json:"title"`
+		UserID         int    `json:"user_id"`
+		Title          string `json:"title"`
+		ParentFolderID *int   `json:"parent_folder_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -50,8 +57,12 @@ func (h *FolderHandler) createFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := h.FolderService.CreateFolder(r.Context(), req.UserID, req.Title)
+	p, err := h.FolderService.CreateFolder(r.Context(), req.UserID, req.Title, req.ParentFolderID)
 	if err != nil {
+		if errors.Is(err, notes_repository.ErrInvalidParentFolder) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -68,9 +79,9 @@ func (h *FolderHandler) getAllFolders(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := h.FolderService.GetAllFolders(r.Context(), id)
+	p, err := h.FolderService.GetFolderTree(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -99,7 +110,59 @@ func (h *FolderHandler) updateTitleFolder(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(p)
 }
 
+func writeFolderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notes_repository.ErrFolderNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, notes_repository.ErrInvalidParentFolder),
+		errors.Is(err, notes_repository.ErrInvalidDeleteStrategy):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *FolderHandler) moveFolder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ParentFolderID *int `json:"parent_folder_id"`
+		Position       int  `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	p, err := h.FolderService.MoveFolder(r.Context(), userID, id, req.ParentFolderID, req.Position)
+	if err != nil {
+		writeFolderError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
 func (h *FolderHandler) deleteFolder(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -107,9 +170,9 @@ func (h *FolderHandler) deleteFolder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.FolderService.DeleteFolders(r.Context(), id)
+	err = h.FolderService.DeleteFolders(r.Context(), userID, id, r.URL.Query().Get("strategy"))
 	if err != nil {
-		http.Error(w, "not found", http.StatusNotFound)
+		writeFolderError(w, err)
 		return
 	}
 
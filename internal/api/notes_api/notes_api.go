@@ -2,12 +2,17 @@ package notes_api
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/notes_repository"
 	"anemone_notes/internal/services/auth_services"
 	"anemone_notes/internal/services/notes_services"
+	"anemone_notes/internal/services/sidebar_services"
 
 	"github.com/gorilla/mux"
 )
@@ -18,11 +23,23 @@ type Response struct {
 
 type PageHandler struct {
 	Service     *notes_services.PageService
+	Sidebar     *sidebar_services.SidebarService
 	AuthService *auth_services.AuthService
 }
 
-func NewPageHandler(s *notes_services.PageService, a *auth_services.AuthService) *PageHandler {
-	return &PageHandler{Service: s, AuthService: a}
+func NewPageHandler(s *notes_services.PageService, sb *sidebar_services.SidebarService, a *auth_services.AuthService) *PageHandler {
+	return &PageHandler{Service: s, Sidebar: sb, AuthService: a}
+}
+
+// recordView добавляет страницу в недавние; ошибка не должна ломать чтение страницы.
+func (h *PageHandler) recordView(r *http.Request, pageID int) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok || h.Sidebar == nil {
+		return
+	}
+	if err := h.Sidebar.RecordPageView(r.Context(), userID, pageID); err != nil {
+		log.Printf("WARN: failed to record page view %d: %v", pageID, err)
+	}
 }
 
 func (h *PageHandler) PagesRoutes(r *mux.Router) {
@@ -30,11 +47,19 @@ func (h *PageHandler) PagesRoutes(r *mux.Router) {
 	r.Handle("/api/v1/notes/create_note",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.createPage)),
 	).Methods("POST")
+	// Get user notes tree - Status: WORK
+	r.Handle("/api/v1/notes/tree",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getTree)),
+	).Methods("GET")
+	// Get user trash with deletion time - Status: WORK
+	r.Handle("/api/v1/notes/trash",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getTrash)),
+	).Methods("GET")
 	// Get one note by id - Status: WORK
 	r.Handle("/api/v1/notes/{id}",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getPage)),
 	).Methods("GET")
-	// Get all user notes - Status: WORK
+	// Get all user notes, ?tags=1,2&match=any|all&include_deleted=true - Status: WORK
 	r.Handle("/api/v1/notes",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getAllPages)),
 	).Methods("GET")
@@ -84,6 +109,34 @@ func (h *PageHandler) PagesRoutes(r *mux.Router) {
 	r.Handle("/api/v1/notes/all_items_undelete/{id}",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.unmarkDeletedAllNotes)),
 	).Methods("PUT")
+	// Get subtree of note - Status: WORK
+	r.Handle("/api/v1/notes/{id}/tree",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getSubtree)),
+	).Methods("GET")
+	// Get note ancestors - Status: WORK
+	r.Handle("/api/v1/notes/{id}/breadcrumbs",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getBreadcrumbs)),
+	).Methods("GET")
+	// Move note with subtree - Status: WORK
+	r.Handle("/api/v1/notes/{id}/move",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.movePage)),
+	).Methods("PUT")
+	// Outgoing [[links]] of note - Status: WORK
+	r.Handle("/api/v1/notes/{id}/links",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getPageLinks)),
+	).Methods("GET")
+	// Notes linking to this note - Status: WORK
+	r.Handle("/api/v1/notes/{id}/backlinks",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getBacklinks)),
+	).Methods("GET")
+	// Links to missing or trashed notes - Status: WORK
+	r.Handle("/api/v1/notes/links/broken",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getBrokenLinks)),
+	).Methods("GET")
+	// Permanently delete one note from trash - Status: WORK
+	r.Handle("/api/v1/notes/trash/{id}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.purgeTrashedNote)),
+	).Methods("DELETE")
 	// Clear trash bin user by id - Status: WORK
 	r.Handle("/api/v1/notes/trash/clear/{id}",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.deleteAllMarkNotes)),
@@ -92,9 +145,10 @@ func (h *PageHandler) PagesRoutes(r *mux.Router) {
 
 func (h *PageHandler) createPage(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		UserID  int    `json:"user_id"`
-		Title   string `json:"title"`
-		Content string `json:"content"`
+		UserID       int    `json:"user_id"`
+		Title        string `json:"title"`
+		Content      string `json:"content"`
+		ParentPageID *int   `json:"parent_page_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -102,8 +156,12 @@ func (h *PageHandler) createPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := h.Service.CreatePage(r.Context(), req.UserID, req.Title, req.Content)
+	p, err := h.Service.CreatePage(r.Context(), req.UserID, req.Title, req.Content, req.ParentPageID)
 	if err != nil {
+		if errors.Is(err, notes_repository.ErrInvalidParent) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -120,11 +178,26 @@ func (h *PageHandler) getPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := h.Service.GetPage(r.Context(), id)
+	// ?format=text — старый ответ без блоков, только текст в Content
+	if r.URL.Query().Get("format") == "text" {
+		p, err := h.Service.GetPage(r.Context(), id)
+		if err != nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		h.recordView(r, id)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+		return
+	}
+
+	p, err := h.Service.GetPageWithBlocks(r.Context(), id)
 	if err != nil {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
 	}
+	h.recordView(r, id)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(p)
@@ -138,7 +211,38 @@ func (h *PageHandler) getAllPages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := h.Service.GetAllPages(r.Context(), userID)
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
+
+	// ?tags=1,2&match=any|all — фильтр по тегам
+	if raw := r.URL.Query().Get("tags"); raw != "" {
+		var tagIDs []int
+		for _, part := range strings.Split(raw, ",") {
+			tagID, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				http.Error(w, "invalid tag id", http.StatusBadRequest)
+				return
+			}
+			tagIDs = append(tagIDs, tagID)
+		}
+
+		match := r.URL.Query().Get("match")
+		if match != "" && match != "any" && match != "all" {
+			http.Error(w, "match must be any or all", http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.Service.GetPagesByTags(r.Context(), userID, tagIDs, match == "all", includeDeleted)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+		return
+	}
+
+	p, err := h.Service.GetAllPages(r.Context(), userID, includeDeleted)
 	if err != nil {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
@@ -149,9 +253,16 @@ func (h *PageHandler) getAllPages(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *PageHandler) updateTitle(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
 	var req struct {
-		ID       int    `json:"id"`
-		NewTitle string `json:"new_title"`
+		ID           int    `json:"id"`
+		NewTitle     string `json:"new_title"`
+		RewriteLinks bool   `json:"rewrite_links"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -160,9 +271,9 @@ func (h *PageHandler) updateTitle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := h.Service.UpdateTitle(r.Context(), req.ID, req.NewTitle)
+	p, err := h.Service.UpdateTitle(r.Context(), userID, req.ID, req.NewTitle, req.RewriteLinks)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writePageTreeError(w, err)
 		return
 	}
 
@@ -184,7 +295,12 @@ func (h *PageHandler) updateContent(w http.ResponseWriter, r *http.Request) {
 
 	p, err := h.Service.UpdateContent(r.Context(), req.ID, req.NewContent)
 	if err != nil {
+		if errors.Is(err, notes_repository.ErrPageHasBlocks) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -384,3 +500,219 @@ func (h *PageHandler) deleteAllMarkNotes(w http.ResponseWriter, r *http.Request)
 	response := Response{Status: "Success"}
 	json.NewEncoder(w).Encode(response)
 }
+
+func (h *PageHandler) getTrash(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	items, err := h.Service.GetTrash(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *PageHandler) purgeTrashedNote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.PurgeTrashedNote(r.Context(), userID, id); err != nil {
+		writePageTreeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := Response{Status: "Success"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func writePageTreeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notes_repository.ErrPageNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, notes_repository.ErrInvalidParent):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *PageHandler) getTree(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	tree, err := h.Service.GetTree(r.Context(), userID, nil)
+	if err != nil {
+		writePageTreeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree)
+}
+
+func (h *PageHandler) getSubtree(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	tree, err := h.Service.GetTree(r.Context(), userID, &id)
+	if err != nil {
+		writePageTreeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tree[0])
+}
+
+func (h *PageHandler) getBreadcrumbs(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	crumbs, err := h.Service.GetBreadcrumbs(r.Context(), userID, id)
+	if err != nil {
+		writePageTreeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(crumbs)
+}
+
+func (h *PageHandler) movePage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ParentPageID *int `json:"parent_page_id"`
+		Position     int  `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	p, err := h.Service.MovePage(r.Context(), userID, id, req.ParentPageID, req.Position)
+	if err != nil {
+		writePageTreeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+func (h *PageHandler) getPageLinks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	links, err := h.Service.GetPageLinks(r.Context(), userID, id)
+	if err != nil {
+		writePageTreeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(links)
+}
+
+func (h *PageHandler) getBacklinks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	backlinks, err := h.Service.GetBacklinks(r.Context(), userID, id)
+	if err != nil {
+		writePageTreeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backlinks)
+}
+
+func (h *PageHandler) getBrokenLinks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	broken, err := h.Service.GetBrokenLinks(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(broken)
+}
@@ -0,0 +1,316 @@
+package notes_api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/notes_repository"
+	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/notes_services"
+
+	"github.com/gorilla/mux"
+)
+
+type TagHandler struct {
+	Service     *notes_services.TagService
+	AuthService *auth_services.AuthService
+}
+
+func NewTagHandler(s *notes_services.TagService, a *auth_services.AuthService) *TagHandler {
+	return &TagHandler{Service: s, AuthService: a}
+}
+
+func (h *TagHandler) TagRoutes(r *mux.Router) {
+	// Get user tags with page counts - Status: WORK
+	r.Handle("/api/v1/tags",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getTags)),
+	).Methods("GET")
+	// Tag cloud, ?limit=N - Status: WORK
+	r.Handle("/api/v1/tags/cloud",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getTagCloud)),
+	).Methods("GET")
+	// Create tag - Status: WORK
+	r.Handle("/api/v1/tags",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.createTag)),
+	).Methods("POST")
+	// Rename tag - Status: WORK
+	r.Handle("/api/v1/tags/{tagID}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.renameTag)),
+	).Methods("PUT")
+	// Merge tag into another - Status: WORK
+	r.Handle("/api/v1/tags/{tagID}/merge",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.mergeTags)),
+	).Methods("POST")
+	// Delete tag - Status: WORK
+	r.Handle("/api/v1/tags/{tagID}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.deleteTag)),
+	).Methods("DELETE")
+	// Get note tags - Status: WORK
+	r.Handle("/api/v1/notes/{id}/tags",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getPageTags)),
+	).Methods("GET")
+	// Attach tag to note by id or name - Status: WORK
+	r.Handle("/api/v1/notes/{id}/tags",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.attachTag)),
+	).Methods("POST")
+	// Detach tag from note - Status: WORK
+	r.Handle("/api/v1/notes/{id}/tags/{tagID}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.detachTag)),
+	).Methods("DELETE")
+}
+
+func writeTagError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notes_repository.ErrTagNotFound),
+		errors.Is(err, notes_repository.ErrPageNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, notes_repository.ErrTagExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, notes_services.ErrInvalidTagName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// pathInt достаёт числовой параметр пути, отвечая 400 при ошибке.
+func pathInt(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	v, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		http.Error(w, "invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+	return v, true
+}
+
+func (h *TagHandler) getTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	tags, err := h.Service.GetTags(r.Context(), userID)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+func (h *TagHandler) getTagCloud(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	tags, err := h.Service.GetTagCloud(r.Context(), userID, limit)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+func (h *TagHandler) createTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	tag, err := h.Service.CreateTag(r.Context(), userID, req.Name)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tag)
+}
+
+func (h *TagHandler) renameTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	tagID, ok := pathInt(w, r, "tagID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	tag, err := h.Service.RenameTag(r.Context(), userID, tagID, req.Name)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+func (h *TagHandler) mergeTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	tagID, ok := pathInt(w, r, "tagID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IntoTagID int `json:"into_tag_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	tag, err := h.Service.MergeTags(r.Context(), userID, tagID, req.IntoTagID)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+func (h *TagHandler) deleteTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	tagID, ok := pathInt(w, r, "tagID")
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteTag(r.Context(), userID, tagID); err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := Response{Status: "Success"}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (h *TagHandler) getPageTags(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	pageID, ok := pathInt(w, r, "id")
+	if !ok {
+		return
+	}
+
+	tags, err := h.Service.GetPageTags(r.Context(), userID, pageID)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+func (h *TagHandler) attachTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	pageID, ok := pathInt(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		TagID *int   `json:"tag_id"`
+		Name  string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	tag, err := h.Service.AttachTag(r.Context(), userID, pageID, req.TagID, req.Name)
+	if err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tag)
+}
+
+func (h *TagHandler) detachTag(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	pageID, ok := pathInt(w, r, "id")
+	if !ok {
+		return
+	}
+	tagID, ok := pathInt(w, r, "tagID")
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DetachTag(r.Context(), userID, pageID, tagID); err != nil {
+		writeTagError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := Response{Status: "Success"}
+	json.NewEncoder(w).Encode(response)
+}
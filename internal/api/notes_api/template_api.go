@@ -0,0 +1,209 @@
+package notes_api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/notes_repository"
+	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/notes_services"
+
+	"github.com/gorilla/mux"
+)
+
+type TemplateHandler struct {
+	Service     *notes_services.TemplateService
+	AuthService *auth_services.AuthService
+}
+
+func NewTemplateHandler(s *notes_services.TemplateService, a *auth_services.AuthService) *TemplateHandler {
+	return &TemplateHandler{Service: s, AuthService: a}
+}
+
+// TemplateRoutes регистрируется до PagesRoutes: иначе /api/v1/notes/templates
+// перехватит маршрут /api/v1/notes/{id}.
+func (h *TemplateHandler) TemplateRoutes(r *mux.Router) {
+	// Get user templates - Status: WORK
+	r.Handle("/api/v1/notes/templates",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getTemplates)),
+	).Methods("GET")
+	// Create note from template, ?tz=Europe/Moscow - Status: WORK
+	r.Handle("/api/v1/notes/templates/{id}/pages",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.createFromTemplate)),
+	).Methods("POST")
+	// Mark or unmark note as template - Status: WORK
+	r.Handle("/api/v1/notes/{id}/template",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.setTemplate)),
+	).Methods("PUT")
+	// Find or create today's daily note, ?tz=Europe/Moscow - Status: WORK
+	r.Handle("/api/v1/notes/daily/today",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.todayNote)),
+	).Methods("POST")
+	// Daily notes folder, template and timezone - Status: WORK
+	r.Handle("/api/v1/notes/daily/settings",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getDailySettings)),
+	).Methods("GET")
+	r.Handle("/api/v1/notes/daily/settings",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.updateDailySettings)),
+	).Methods("PUT")
+}
+
+func writeTemplateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notes_repository.ErrTemplateNotFound),
+		errors.Is(err, notes_repository.ErrPageNotFound),
+		errors.Is(err, notes_repository.ErrFolderNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, notes_repository.ErrInvalidParent),
+		errors.Is(err, notes_services.ErrInvalidTimezone):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *TemplateHandler) getTemplates(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	templates, err := h.Service.GetTemplates(r.Context(), userID)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templates)
+}
+
+func (h *TemplateHandler) createFromTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	templateID, ok := pathInt(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Title        string `json:"title"`
+		ParentPageID *int   `json:"parent_page_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	p, err := h.Service.CreateFromTemplate(r.Context(), userID, templateID, req.Title, req.ParentPageID, r.URL.Query().Get("tz"))
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+func (h *TemplateHandler) setTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	pageID, ok := pathInt(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		IsTemplate bool `json:"is_template"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	p, err := h.Service.SetTemplate(r.Context(), userID, pageID, req.IsTemplate)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+func (h *TemplateHandler) todayNote(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	p, created, err := h.Service.TodayNote(r.Context(), userID, r.URL.Query().Get("tz"))
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(p)
+}
+
+func (h *TemplateHandler) getDailySettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	settings, err := h.Service.GetDailySettings(r.Context(), userID)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+func (h *TemplateHandler) updateDailySettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	var req struct {
+		FolderID       *int   `json:"folder_id"`
+		TemplatePageID *int   `json:"template_page_id"`
+		Timezone       string `json:"timezone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		defer r.Body.Close()
+		return
+	}
+
+	settings, err := h.Service.UpdateDailySettings(r.Context(), userID, req.FolderID, req.TemplatePageID, req.Timezone)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
@@ -0,0 +1,149 @@
+package notes_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/notes_repository"
+	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/notes_services"
+
+	"github.com/gorilla/mux"
+)
+
+const maxNotesImportBytes = 50 << 20
+
+type TransferHandler struct {
+	Service     *notes_services.TransferService
+	AuthService *auth_services.AuthService
+}
+
+func NewTransferHandler(s *notes_services.TransferService, a *auth_services.AuthService) *TransferHandler {
+	return &TransferHandler{Service: s, AuthService: a}
+}
+
+// TransferRoutes нужно регистрировать раньше PagesRoutes,
+// иначе /api/v1/notes/export перехватит маршрут /api/v1/notes/{id}.
+func (h *TransferHandler) TransferRoutes(r *mux.Router) {
+	// Export whole account, ?format=markdown|html|json - Status: WORK
+	r.Handle("/api/v1/notes/export",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.exportAccount)),
+	).Methods("GET")
+	// Export one note - Status: WORK
+	r.Handle("/api/v1/notes/{id}/export",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.exportPage)),
+	).Methods("GET")
+	// Export folder with subfolders - Status: WORK
+	r.Handle("/api/v1/folder/{id}/export",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.exportFolder)),
+	).Methods("GET")
+	// Import zip with markdown files - Status: WORK
+	r.Handle("/api/v1/notes/import",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.importNotes)),
+	).Methods("POST")
+}
+
+func (h *TransferHandler) exportAccount(w http.ResponseWriter, r *http.Request) {
+	h.export(w, r, notes_services.ExportScopeAccount, 0)
+}
+
+func (h *TransferHandler) exportPage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	h.export(w, r, notes_services.ExportScopePage, id)
+}
+
+func (h *TransferHandler) exportFolder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	h.export(w, r, notes_services.ExportScopeFolder, id)
+}
+
+func (h *TransferHandler) export(w http.ResponseWriter, r *http.Request, scope string, id int) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" || format == "md" {
+		format = notes_services.ExportMarkdown
+	}
+
+	file, err := h.Service.Export(r.Context(), userID, scope, id, format)
+	if err != nil {
+		switch {
+		case errors.Is(err, notes_repository.ErrPageNotFound),
+			errors.Is(err, notes_repository.ErrFolderNotFound):
+			http.Error(w, "not found", http.StatusNotFound)
+		case errors.Is(err, notes_services.ErrUnknownExportFormat):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	w.Write(file.Data)
+}
+
+// importNotes принимает zip либо в поле file multipart-формы, либо телом запроса.
+func (h *TransferHandler) importNotes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxNotesImportBytes)
+	defer r.Body.Close()
+
+	var src io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		src = file
+	}
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		http.Error(w, "import archive is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	report, err := h.Service.ImportMarkdownZip(r.Context(), userID, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, notes_services.ErrInvalidImportZip):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, notes_services.ErrImportTooLarge):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(report)
+}
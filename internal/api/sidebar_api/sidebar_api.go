@@ -0,0 +1,181 @@
+package sidebar_api
+
+import (
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/sidebar_repository"
+	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/sidebar_services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type SidebarHandler struct {
+	Service     *sidebar_services.SidebarService
+	AuthService *auth_services.AuthService
+}
+
+func NewSidebarHandler(s *sidebar_services.SidebarService, a *auth_services.AuthService) *SidebarHandler {
+	return &SidebarHandler{Service: s, AuthService: a}
+}
+
+func (h *SidebarHandler) SidebarRoutes(r *mux.Router) {
+	// Favorites and recent items across notes and trello - Status: WORK
+	r.Handle("/api/v1/sidebar",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getSidebar)),
+	).Methods("GET")
+	// Favorites - Status: WORK
+	r.Handle("/api/v1/favorites",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getFavorites)),
+	).Methods("GET")
+	r.Handle("/api/v1/favorites",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.addFavorite)),
+	).Methods("POST")
+	// Pin/unpin and reorder favorite - Status: WORK
+	r.Handle("/api/v1/favorites/{favoriteID}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.updateFavorite)),
+	).Methods("PUT")
+	r.Handle("/api/v1/favorites/{favoriteID}",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.deleteFavorite)),
+	).Methods("DELETE")
+}
+
+func handleSidebarError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	message := "Internal server error"
+
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.Is(err, sidebar_repository.ErrFavoriteNotFound):
+		status, message = http.StatusNotFound, "Favorite not found"
+	case errors.Is(err, sidebar_repository.ErrItemNotFound):
+		status, message = http.StatusNotFound, "Page, folder or board not found"
+	case errors.Is(err, sidebar_services.ErrInvalidItem):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.As(err, &syntaxErr):
+		status, message = http.StatusBadRequest, "Invalid request payload"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func (h *SidebarHandler) getSidebar(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	sidebar, err := h.Service.GetSidebar(r.Context(), userID)
+	if err != nil {
+		handleSidebarError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sidebar)
+}
+
+func (h *SidebarHandler) getFavorites(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	favorites, err := h.Service.GetFavorites(r.Context(), userID)
+	if err != nil {
+		handleSidebarError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(favorites)
+}
+
+func (h *SidebarHandler) addFavorite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	var req struct {
+		ItemType string `json:"item_type"`
+		ItemID   string `json:"item_id"`
+		Pinned   bool   `json:"pinned"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleSidebarError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	f, err := h.Service.AddFavorite(r.Context(), userID, req.ItemType, req.ItemID, req.Pinned)
+	if err != nil {
+		handleSidebarError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(f)
+}
+
+func (h *SidebarHandler) updateFavorite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["favoriteID"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Pinned   *bool `json:"pinned"`
+		Position *int  `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleSidebarError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	f, err := h.Service.UpdateFavorite(r.Context(), userID, id, req.Pinned, req.Position)
+	if err != nil {
+		handleSidebarError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(f)
+}
+
+func (h *SidebarHandler) deleteFavorite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["favoriteID"])
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteFavorite(r.Context(), userID, id); err != nil {
+		handleSidebarError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Favorite removed"})
+}
@@ -0,0 +1,80 @@
+package trello_api
+
+import (
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/trello_repository"
+	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/trello_services"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type ActivityHandler struct {
+	Service     *trello_services.ActivityService
+	AuthService *auth_services.AuthService
+	BoardRepo   middlewares.BoardRepoInterface
+}
+
+func NewActivityHandler(s *trello_services.ActivityService, a *auth_services.AuthService, br middlewares.BoardRepoInterface) *ActivityHandler {
+	return &ActivityHandler{Service: s, AuthService: a, BoardRepo: br}
+}
+
+func (h *ActivityHandler) ActivityRoutes(r *mux.Router) {
+	r.Handle("/api/v1/trello/board/{boardID}/activity",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(h.BoardRepo, http.HandlerFunc(h.getBoardActivity))),
+	).Methods("GET")
+
+	r.Handle("/api/v1/trello/column/{columnID}/card/{cardID}/activity",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_ColumnPath(h.BoardRepo, http.HandlerFunc(h.getCardActivity))),
+	).Methods("GET")
+}
+
+// parsePagination читает ?limit=&offset= из запроса; некорректные значения заменяются нулём,
+// а нормализация границ остаётся за репозиторием.
+func parsePagination(r *http.Request) (int, int) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	return limit, offset
+}
+
+func (h *ActivityHandler) getBoardActivity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+	limit, offset := parsePagination(r)
+
+	page, err := h.Service.GetBoardActivity(r.Context(), boardID, limit, offset)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (h *ActivityHandler) getCardActivity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	columnID := vars["columnID"]
+	cardID := vars["cardID"]
+	limit, offset := parsePagination(r)
+
+	page, err := h.Service.GetCardActivity(r.Context(), columnID, cardID, limit, offset)
+	if err != nil {
+		if errors.Is(err, trello_repository.ErrCardNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Card not found"})
+			return
+		}
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
@@ -5,10 +5,14 @@ import (
 	"anemone_notes/internal/model/trello_model"
 	"anemone_notes/internal/repository/trello_repository"
 	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/sidebar_services"
 	"anemone_notes/internal/services/trello_services"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"regexp"
 
 	"github.com/gorilla/mux"
 )
@@ -22,6 +26,18 @@ func handleError(w http.ResponseWriter, err error) {
 		return
 	}
 
+	if errors.Is(err, trello_repository.ErrBoardArchived) || errors.Is(err, trello_repository.ErrColumnArchived) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Restore it from the archive first: " + err.Error()})
+		return
+	}
+
+	if errors.Is(err, trello_repository.ErrWipLimitReached) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+		return
+	}
+
 	var reqErr *json.SyntaxError
 	if errors.As(err, &reqErr) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -35,6 +51,7 @@ func handleError(w http.ResponseWriter, err error) {
 
 type BoardHandler struct {
 	Service     *trello_services.BoardService
+	Sidebar     *sidebar_services.SidebarService
 	AuthService *auth_services.AuthService
 }
 
@@ -42,8 +59,8 @@ func (h *BoardHandler) getBoardRepoInterface() middlewares.BoardRepoInterface {
 	return h.Service.Repo
 }
 
-func NewBoardHandler(s *trello_services.BoardService, a *auth_services.AuthService) *BoardHandler {
-	return &BoardHandler{Service: s, AuthService: a}
+func NewBoardHandler(s *trello_services.BoardService, sb *sidebar_services.SidebarService, a *auth_services.AuthService) *BoardHandler {
+	return &BoardHandler{Service: s, Sidebar: sb, AuthService: a}
 }
 
 func (h *BoardHandler) BoardRoutes(r *mux.Router) {
@@ -56,6 +73,12 @@ func (h *BoardHandler) BoardRoutes(r *mux.Router) {
 	r.Handle("/api/v1/trello/get_all_user_boards",
 		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getAllUserBoards)),
 	).Methods("GET")
+	r.Handle("/api/v1/trello/import",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.importBoard)),
+	).Methods("POST")
+	r.Handle("/api/v1/trello/get_all_archived_boards",
+		middlewares.AuthMiddleware(h.AuthService, http.HandlerFunc(h.getAllArchivedBoards)),
+	).Methods("GET")
 
 	boardRouter := r.PathPrefix("/api/v1/trello/board/{boardID}").Subrouter()
 	// Work
@@ -78,6 +101,27 @@ func (h *BoardHandler) BoardRoutes(r *mux.Router) {
 		middlewares.AuthMiddleware(h.AuthService,
 			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.updateBoard))),
 	).Methods("POST")
+
+	boardRouter.Handle("/archive",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.archiveBoard))),
+	).Methods("PUT")
+	boardRouter.Handle("/unarchive",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.unarchiveBoard))),
+	).Methods("PUT")
+	boardRouter.Handle("/copy",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.copyBoard))),
+	).Methods("POST")
+	boardRouter.Handle("/export",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.exportBoard))),
+	).Methods("GET")
+	boardRouter.Handle("/archived",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.getArchivedItems))),
+	).Methods("GET")
 }
 
 func (h *BoardHandler) createBoard(w http.ResponseWriter, r *http.Request) {
@@ -117,6 +161,13 @@ func (h *BoardHandler) getOneUserBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Недавние доски для сайдбара; ошибка записи не мешает ответу
+	if userID, ok := middlewares.GetUserIDFromContext(r.Context()); ok && h.Sidebar != nil {
+		if err := h.Sidebar.RecordBoardView(r.Context(), userID, boardID); err != nil {
+			log.Printf("WARN: failed to record board view %s: %v", boardID, err)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(oneUserBoard)
 }
@@ -154,7 +205,7 @@ func (h *BoardHandler) deleteBoard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Board deleted successfully"})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Board moved to archive"})
 }
 
 func (h *BoardHandler) renameBoard(w http.ResponseWriter, r *http.Request) {
@@ -220,3 +271,175 @@ func (h *BoardHandler) updateBoard(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]any{"message": "Board updated successfully", "success": true})
 }
+
+func (h *BoardHandler) getAllArchivedBoards(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	boards, err := h.Service.GetAllArchivedBoards(r.Context(), userID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(boards)
+}
+
+func (h *BoardHandler) archiveBoard(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+
+	board, err := h.Service.ArchiveBoard(r.Context(), boardID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+func (h *BoardHandler) unarchiveBoard(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+
+	board, err := h.Service.UnarchiveBoard(r.Context(), boardID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+func (h *BoardHandler) getArchivedItems(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+
+	items, err := h.Service.GetArchivedItems(r.Context(), boardID)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+func (h *BoardHandler) copyBoard(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Title        string `json:"title"`
+		IncludeCards bool   `json:"include_cards"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	board, err := h.Service.CopyBoard(r.Context(), boardID, userID, req.Title, req.IncludeCards)
+	if err != nil {
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(board)
+}
+
+const maxImportBytes = 10 << 20
+
+var unsafeFilenameChars = regexp.MustCompile(`[^\w\-. ]+`)
+
+func attachmentFilename(title, ext string) string {
+	name := unsafeFilenameChars.ReplaceAllString(title, "_")
+	if name == "" {
+		name = "board"
+	}
+	return fmt.Sprintf("attachment; filename=%q", name+"."+ext)
+}
+
+func (h *BoardHandler) exportBoard(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "trello", "json":
+		export, err := h.Service.ExportTrello(r.Context(), boardID)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", attachmentFilename(export.Name, "json"))
+		json.NewEncoder(w).Encode(export)
+	case "csv":
+		title, data, err := h.Service.ExportCSV(r.Context(), boardID)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", attachmentFilename(title, "csv"))
+		w.Write(data)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Unsupported export format, use trello or csv"})
+	}
+}
+
+func (h *BoardHandler) importBoard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	var export trello_model.TrelloBoardExport
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportBytes)).Decode(&export); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Invalid Trello export: " + err.Error()})
+		return
+	}
+	defer r.Body.Close()
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	report, err := h.Service.ImportTrello(r.Context(), userID, &export, dryRun)
+	if err != nil {
+		if errors.Is(err, trello_services.ErrImportEmpty) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Import contains no lists"})
+			return
+		}
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !dryRun {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -41,9 +41,35 @@ func (h *CardHandler) CardRoutes(r *mux.Router) {
     middlewares.AuthMiddleware(h.AuthService,
       middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.renameCard))),
   ).Methods("PUT")
+
+  cardRouter.Handle("/move",
+    middlewares.AuthMiddleware(h.AuthService,
+      middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.moveCard))),
+  ).Methods("PUT")
+
+  cardRouter.Handle("/copy",
+    middlewares.AuthMiddleware(h.AuthService,
+      middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.copyCard))),
+  ).Methods("POST")
+
+  cardRouter.Handle("/archive",
+    middlewares.AuthMiddleware(h.AuthService,
+      middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.archiveCard))),
+  ).Methods("PUT")
+
+  cardRouter.Handle("/unarchive",
+    middlewares.AuthMiddleware(h.AuthService,
+      middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.unarchiveCard))),
+  ).Methods("PUT")
 }
 
 func (h *CardHandler) createCard(w http.ResponseWriter, r *http.Request) {
+  userID, ok := middlewares.GetUserIDFromContext(r.Context())
+  if !ok {
+    http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+    return
+  }
+
   vars := mux.Vars(r)
   columnID := vars["columnID"]
 
@@ -57,7 +83,7 @@ func (h *CardHandler) createCard(w http.ResponseWriter, r *http.Request) {
   }
   defer r.Body.Close()
 
-  cardData, err := h.Service.CreateCard(r.Context(), columnID, req.CardTitle)
+  cardData, err := h.Service.CreateCard(r.Context(), userID, columnID, req.CardTitle)
   if err != nil {
     handleError(w, err)
     return
@@ -68,11 +94,17 @@ func (h *CardHandler) createCard(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *CardHandler) deleteCard(w http.ResponseWriter, r *http.Request) {
+  userID, ok := middlewares.GetUserIDFromContext(r.Context())
+  if !ok {
+    http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+    return
+  }
+
   vars := mux.Vars(r)
   columnID := vars["columnID"]
   cardID := vars["cardID"]
   
-  err := h.Service.DeleteCard(r.Context(), columnID, cardID)
+  err := h.Service.DeleteCard(r.Context(), userID, columnID, cardID)
   if err != nil {
     if errors.Is(err, trello_repository.ErrCardNotFound) {
       w.WriteHeader(http.StatusNotFound)
@@ -84,10 +116,16 @@ func (h *CardHandler) deleteCard(w http.ResponseWriter, r *http.Request) {
   }
 
   w.Header().Set("Content-Type", "application/json")
-  json.NewEncoder(w).Encode(map[string]string{"message": "Card deleted successfully"})
+  json.NewEncoder(w).Encode(map[string]string{"message": "Card moved to archive"})
 }
 
 func (h *CardHandler) renameCard(w http.ResponseWriter, r *http.Request) {
+  userID, ok := middlewares.GetUserIDFromContext(r.Context())
+  if !ok {
+    http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+    return
+  }
+
   vars := mux.Vars(r)
   columnID := vars["columnID"]
   cardID := vars["cardID"]
@@ -102,7 +140,7 @@ func (h *CardHandler) renameCard(w http.ResponseWriter, r *http.Request) {
   }
   defer r.Body.Close()
 
-  card, err := h.Service.RenameCard(r.Context(), columnID, cardID, req.NewName)
+  card, err := h.Service.RenameCard(r.Context(), userID, columnID, cardID, req.NewName)
   if err != nil {
     if errors.Is(err, trello_repository.ErrCardNotFound) {
       w.WriteHeader(http.StatusNotFound)
@@ -115,4 +153,141 @@ func (h *CardHandler) renameCard(w http.ResponseWriter, r *http.Request) {
 
   w.Header().Set("Content-Type", "application/json")
   json.NewEncoder(w).Encode(map[string]any{"message": "Card success renamed", "card": card})
+}
+
+func (h *CardHandler) moveCard(w http.ResponseWriter, r *http.Request) {
+  userID, ok := middlewares.GetUserIDFromContext(r.Context())
+  if !ok {
+    http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+    return
+  }
+
+  vars := mux.Vars(r)
+  columnID := vars["columnID"]
+  cardID := vars["cardID"]
+
+  var req struct {
+    ToColumnID string `json:"to_column_id"`
+    Position   int    `json:"position"`
+  }
+
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+    handleError(w, err)
+    return
+  }
+  defer r.Body.Close()
+
+  card, err := h.Service.MoveCard(r.Context(), userID, columnID, cardID, req.ToColumnID, req.Position)
+  if err != nil {
+    if errors.Is(err, trello_repository.ErrCardNotFound) {
+      w.WriteHeader(http.StatusNotFound)
+      json.NewEncoder(w).Encode(map[string]string{"message": "Card not found"})
+      return
+    }
+    if errors.Is(err, trello_repository.ErrColumnNotFoundForCard) {
+      w.WriteHeader(http.StatusNotFound)
+      json.NewEncoder(w).Encode(map[string]string{"message": "Target column not found on this board"})
+      return
+    }
+    handleError(w, err)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  json.NewEncoder(w).Encode(card)
+}
+
+func (h *CardHandler) archiveCard(w http.ResponseWriter, r *http.Request) {
+  userID, ok := middlewares.GetUserIDFromContext(r.Context())
+  if !ok {
+    http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+    return
+  }
+
+  vars := mux.Vars(r)
+  columnID := vars["columnID"]
+  cardID := vars["cardID"]
+
+  card, err := h.Service.ArchiveCard(r.Context(), userID, columnID, cardID)
+  if err != nil {
+    if errors.Is(err, trello_repository.ErrCardNotFound) {
+      w.WriteHeader(http.StatusNotFound)
+      json.NewEncoder(w).Encode(map[string]string{"message": "Card not found"})
+      return
+    }
+    handleError(w, err)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  json.NewEncoder(w).Encode(card)
+}
+
+func (h *CardHandler) unarchiveCard(w http.ResponseWriter, r *http.Request) {
+  userID, ok := middlewares.GetUserIDFromContext(r.Context())
+  if !ok {
+    http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+    return
+  }
+
+  vars := mux.Vars(r)
+  columnID := vars["columnID"]
+  cardID := vars["cardID"]
+
+  card, err := h.Service.UnarchiveCard(r.Context(), userID, columnID, cardID)
+  if err != nil {
+    if errors.Is(err, trello_repository.ErrCardNotFound) {
+      w.WriteHeader(http.StatusNotFound)
+      json.NewEncoder(w).Encode(map[string]string{"message": "Archived card not found"})
+      return
+    }
+    handleError(w, err)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  json.NewEncoder(w).Encode(card)
+}
+
+func (h *CardHandler) copyCard(w http.ResponseWriter, r *http.Request) {
+  userID, ok := middlewares.GetUserIDFromContext(r.Context())
+  if !ok {
+    http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+    return
+  }
+
+  vars := mux.Vars(r)
+  columnID := vars["columnID"]
+  cardID := vars["cardID"]
+
+  var req struct {
+    ToColumnID string `json:"to_column_id"`
+    Position   int    `json:"position"`
+  }
+
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+    handleError(w, err)
+    return
+  }
+  defer r.Body.Close()
+
+  card, err := h.Service.CopyCard(r.Context(), userID, columnID, cardID, req.ToColumnID, req.Position)
+  if err != nil {
+    if errors.Is(err, trello_repository.ErrCardNotFound) {
+      w.WriteHeader(http.StatusNotFound)
+      json.NewEncoder(w).Encode(map[string]string{"message": "Card not found"})
+      return
+    }
+    if errors.Is(err, trello_repository.ErrColumnNotFoundForCard) {
+      w.WriteHeader(http.StatusNotFound)
+      json.NewEncoder(w).Encode(map[string]string{"message": "Target column not found"})
+      return
+    }
+    handleError(w, err)
+    return
+  }
+
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(http.StatusCreated)
+  json.NewEncoder(w).Encode(card)
 }
\ No newline at end of file
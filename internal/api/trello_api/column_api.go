@@ -46,9 +46,34 @@ func (h *ColumnHandler) ColumnRoutes(r *mux.Router) {
 		middlewares.AuthMiddleware(h.AuthService,
 			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.renameColumn))),
 	).Methods("PUT")
+
+	columnRouter.Handle("/wip",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.setWipLimit))),
+	).Methods("PUT")
+
+	columnRouter.Handle("/copy",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.copyColumn))),
+	).Methods("POST")
+
+	columnRouter.Handle("/archive",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.archiveColumn))),
+	).Methods("PUT")
+	columnRouter.Handle("/unarchive",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_Path(boardRepo, http.HandlerFunc(h.unarchiveColumn))),
+	).Methods("PUT")
 }
 
 func (h *ColumnHandler) createColumn(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
 	vars := mux.Vars(r)
 	boardID := vars["boardID"]
 
@@ -62,7 +87,7 @@ func (h *ColumnHandler) createColumn(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	columnData, err := h.Service.CreateColumn(r.Context(), boardID, req.ColumnTitle)
+	columnData, err := h.Service.CreateColumn(r.Context(), userID, boardID, req.ColumnTitle)
 	if err != nil {
 		handleError(w, err)
 		return
@@ -73,11 +98,17 @@ func (h *ColumnHandler) createColumn(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ColumnHandler) deleteColumn(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
 	vars := mux.Vars(r)
 	boardID := vars["boardID"]
 	columnID := vars["columnID"]
 
-	err := h.Service.DeleteColumn(r.Context(), boardID, columnID)
+	err := h.Service.DeleteColumn(r.Context(), userID, boardID, columnID)
 	if err != nil {
 		if errors.Is(err, trello_repository.ErrColumnNotFound) {
 			w.WriteHeader(http.StatusNotFound)
@@ -89,10 +120,16 @@ func (h *ColumnHandler) deleteColumn(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Column deleted successfully"})
+	json.NewEncoder(w).Encode(map[string]string{"message": "Column moved to archive"})
 }
 
 func (h *ColumnHandler) renameColumn(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
 	vars := mux.Vars(r)
 	boardID := vars["boardID"]
 	columnID := vars["columnID"]
@@ -107,13 +144,144 @@ func (h *ColumnHandler) renameColumn(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	column, err := h.Service.RenameColumn(r.Context(), boardID, columnID, req.NewName)
+	column, err := h.Service.RenameColumn(r.Context(), userID, boardID, columnID, req.NewName)
+	if err != nil {
+		if errors.Is(err, trello_repository.ErrColumnNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Column not found"})
+			return
+		}
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(column)
+}
+
+func (h *ColumnHandler) archiveColumn(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+	columnID := vars["columnID"]
+
+	column, err := h.Service.ArchiveColumn(r.Context(), userID, boardID, columnID)
+	if err != nil {
+		if errors.Is(err, trello_repository.ErrColumnNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Column not found"})
+			return
+		}
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(column)
+}
+
+func (h *ColumnHandler) unarchiveColumn(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+	columnID := vars["columnID"]
+
+	column, err := h.Service.UnarchiveColumn(r.Context(), userID, boardID, columnID)
+	if err != nil {
+		if errors.Is(err, trello_repository.ErrColumnNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Archived column not found"})
+			return
+		}
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(column)
+}
+
+func (h *ColumnHandler) copyColumn(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+	columnID := vars["columnID"]
+
+	var req struct {
+		ToBoardID string `json:"to_board_id"`
+		Title     string `json:"title"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	column, err := h.Service.CopyColumn(r.Context(), userID, boardID, columnID, req.ToBoardID, req.Title)
 	if err != nil {
 		if errors.Is(err, trello_repository.ErrColumnNotFound) {
 			w.WriteHeader(http.StatusNotFound)
 			json.NewEncoder(w).Encode(map[string]string{"message": "Column not found"})
 			return
 		}
+		if errors.Is(err, trello_repository.ErrBoardAccessDenied) {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Target board not found or access denied"})
+			return
+		}
+		handleError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(column)
+}
+
+func (h *ColumnHandler) setWipLimit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	boardID := vars["boardID"]
+	columnID := vars["columnID"]
+
+	var req struct {
+		WipLimit  *int   `json:"wip_limit"`
+		WipPolicy string `json:"wip_policy"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	column, err := h.Service.SetWipLimit(r.Context(), boardID, columnID, req.WipLimit, req.WipPolicy)
+	if err != nil {
+		if errors.Is(err, trello_repository.ErrColumnNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"message": "Column not found"})
+			return
+		}
+		if errors.Is(err, trello_repository.ErrInvalidWipLimit) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"message": "WIP limit must be positive and policy must be warn or block"})
+			return
+		}
 		handleError(w, err)
 		return
 	}
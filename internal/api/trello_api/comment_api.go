@@ -0,0 +1,170 @@
+package trello_api
+
+import (
+	"anemone_notes/internal/api/middlewares"
+	"anemone_notes/internal/repository/trello_repository"
+	"anemone_notes/internal/services/auth_services"
+	"anemone_notes/internal/services/trello_services"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type CommentHandler struct {
+	Service     *trello_services.CommentService
+	AuthService *auth_services.AuthService
+	BoardRepo   middlewares.BoardRepoInterface
+}
+
+func NewCommentHandler(s *trello_services.CommentService, a *auth_services.AuthService, br middlewares.BoardRepoInterface) *CommentHandler {
+	return &CommentHandler{Service: s, AuthService: a, BoardRepo: br}
+}
+
+func (h *CommentHandler) CommentRoutes(r *mux.Router) {
+	boardRepo := h.BoardRepo
+
+	commentsRouter := r.PathPrefix("/api/v1/trello/column/{columnID}/card/{cardID}/comments").Subrouter()
+
+	commentsRouter.Handle("",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.getComments))),
+	).Methods("GET")
+
+	commentsRouter.Handle("",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.createComment))),
+	).Methods("POST")
+
+	commentsRouter.Handle("/{commentID}",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.updateComment))),
+	).Methods("PUT")
+
+	commentsRouter.Handle("/{commentID}",
+		middlewares.AuthMiddleware(h.AuthService,
+			middlewares.IsBoardOwner_ColumnPath(boardRepo, http.HandlerFunc(h.deleteComment))),
+	).Methods("DELETE")
+}
+
+func handleCommentError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case errors.Is(err, trello_repository.ErrCardNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Card not found"})
+	case errors.Is(err, trello_repository.ErrCommentNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Comment not found"})
+	case errors.Is(err, trello_repository.ErrCommentForbidden):
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"message": "Only the author can change this comment"})
+	case errors.Is(err, trello_services.ErrCommentEmpty), errors.Is(err, trello_services.ErrCommentTooLong):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"message": err.Error()})
+	default:
+		handleError(w, err)
+	}
+}
+
+func (h *CommentHandler) getComments(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	columnID := vars["columnID"]
+	cardID := vars["cardID"]
+
+	comments, err := h.Service.GetCardComments(r.Context(), columnID, cardID)
+	if err != nil {
+		handleCommentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments)
+}
+
+func (h *CommentHandler) createComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	columnID := vars["columnID"]
+	cardID := vars["cardID"]
+
+	var req struct {
+		Body string `json:"body"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	comment, err := h.Service.CreateComment(r.Context(), userID, columnID, cardID, req.Body)
+	if err != nil {
+		handleCommentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (h *CommentHandler) updateComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	columnID := vars["columnID"]
+	cardID := vars["cardID"]
+	commentID := vars["commentID"]
+
+	var req struct {
+		Body string `json:"body"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(w, err)
+		return
+	}
+	defer r.Body.Close()
+
+	comment, err := h.Service.UpdateComment(r.Context(), userID, columnID, cardID, commentID, req.Body)
+	if err != nil {
+		handleCommentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (h *CommentHandler) deleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middlewares.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User authentication data missing", http.StatusInternalServerError)
+		return
+	}
+
+	vars := mux.Vars(r)
+	columnID := vars["columnID"]
+	cardID := vars["cardID"]
+	commentID := vars["commentID"]
+
+	if err := h.Service.DeleteComment(r.Context(), userID, columnID, cardID, commentID); err != nil {
+		handleCommentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Comment deleted successfully"})
+}
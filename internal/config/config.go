@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -17,6 +19,58 @@ type Config struct {
 	CorsProd 	 	  string
 	AccessSecret  string
 	RefreshSecret string
+
+	AccessTokenTTLMinutes int
+	RefreshTokenTTLDays   int
+	TOTPIssuer            string
+
+	OIDCProviders []OIDCProvider
+
+	PasswordMinLength         int
+	PasswordMinClasses        int
+	BreachedPasswordsDir      string
+	BreachedPasswordsMinCount int
+
+	LoginAttemptsStore  string
+	LoginMaxFailures    int
+	LoginLockoutMinutes int
+	LoginIPMaxFailures  int
+	TrustProxyHeaders   bool
+
+	AppBaseURL string
+
+	Mailer            string
+	MailFrom          string
+	MailDir           string
+	SMTPRelayHost     string
+	SMTPRelayPort     int
+	SMTPRelayUser     string
+	SMTPRelayPassword string
+
+	EmailVerifyTTLHours     int
+	PasswordResetTTLMinutes int
+	UnverifiedPolicy        string
+	UnverifiedGraceHours    int
+
+	TrelloArchiveRetentionDays int
+	NotesTrashRetentionDays    int
+
+	RecentItemsLimit int
+
+	AccountDeletionGraceDays int
+	DataExportTTLHours       int
+
+	AttachmentsStorage       string
+	AttachmentsDir           string
+	AttachmentsMaxBytes      int
+	AttachmentsURLTTLMinutes int
+	AttachmentsSigningKey    string
+	S3Endpoint               string
+	S3Region                 string
+	S3Bucket                 string
+	S3AccessKey              string
+	S3SecretKey              string
+	S3PathStyle              bool
 }
 
 func Load() *Config {
@@ -34,6 +88,65 @@ func Load() *Config {
 		CorsProd:		   getEnv("CORS_PROD", ""),
 		AccessSecret:  getEnv("ACCESS_SECRET", ""),
 		RefreshSecret: getEnv("REFRESH_SECRET", ""),
+
+		AccessTokenTTLMinutes: getEnvInt("ACCESS_TOKEN_TTL_MINUTES", 15),
+		RefreshTokenTTLDays:   getEnvInt("REFRESH_TOKEN_TTL_DAYS", 7),
+		TOTPIssuer:            getEnv("TOTP_ISSUER", "Anemone"),
+
+		OIDCProviders: loadOIDCProviders(getEnv("HTTP_PORT", "8080")),
+
+		// BREACHED_PASSWORDS_DIR — каталог с файлами хэш-префиксов SHA-1 в формате
+		// Have I Been Pwned; пустой — проверка по утечкам выключена
+		PasswordMinLength:         getEnvInt("PASSWORD_MIN_LENGTH", 10),
+		PasswordMinClasses:        getEnvInt("PASSWORD_MIN_CLASSES", 0),
+		BreachedPasswordsDir:      getEnv("BREACHED_PASSWORDS_DIR", ""),
+		BreachedPasswordsMinCount: getEnvInt("BREACHED_PASSWORDS_MIN_COUNT", 1),
+
+		// LOGIN_ATTEMPTS_STORE: postgres (общий для всех экземпляров) или memory.
+		// TRUST_PROXY_HEADERS включает X-Forwarded-For для лимитов по IP —
+		// только за своим reverse proxy, иначе адрес подделывается клиентом.
+		LoginAttemptsStore:  getEnv("LOGIN_ATTEMPTS_STORE", "postgres"),
+		LoginMaxFailures:    getEnvInt("LOGIN_MAX_FAILURES", 10),
+		LoginLockoutMinutes: getEnvInt("LOGIN_LOCKOUT_MINUTES", 30),
+		LoginIPMaxFailures:  getEnvInt("LOGIN_IP_MAX_FAILURES", 30),
+		TrustProxyHeaders:   getEnv("TRUST_PROXY_HEADERS", "false") == "true",
+
+		AppBaseURL: getEnv("APP_BASE_URL", "http://localhost:3000"),
+
+		Mailer:            getEnv("MAILER", "log"),
+		MailFrom:          getEnv("MAIL_FROM", "no-reply@localhost"),
+		MailDir:           getEnv("MAIL_DIR", "./data/mail"),
+		SMTPRelayHost:     getEnv("SMTP_RELAY_HOST", ""),
+		SMTPRelayPort:     getEnvInt("SMTP_RELAY_PORT", 587),
+		SMTPRelayUser:     getEnv("SMTP_RELAY_USER", ""),
+		SMTPRelayPassword: getEnv("SMTP_RELAY_PASSWORD", ""),
+
+		// UNVERIFIED_POLICY: allow, read_only или block — что можно аккаунту
+		// с неподтверждённой почтой после UNVERIFIED_GRACE_HOURS с регистрации
+		EmailVerifyTTLHours:     getEnvInt("EMAIL_VERIFY_TTL_HOURS", 48),
+		PasswordResetTTLMinutes: getEnvInt("PASSWORD_RESET_TTL_MINUTES", 60),
+		UnverifiedPolicy:        getEnv("UNVERIFIED_POLICY", "read_only"),
+		UnverifiedGraceHours:    getEnvInt("UNVERIFIED_GRACE_HOURS", 24),
+
+		TrelloArchiveRetentionDays: getEnvInt("TRELLO_ARCHIVE_RETENTION_DAYS", 30),
+		NotesTrashRetentionDays:    getEnvInt("NOTES_TRASH_RETENTION_DAYS", 30),
+
+		RecentItemsLimit: getEnvInt("RECENT_ITEMS_LIMIT", 20),
+
+		AccountDeletionGraceDays: getEnvInt("ACCOUNT_DELETION_GRACE_DAYS", 30),
+		DataExportTTLHours:       getEnvInt("DATA_EXPORT_TTL_HOURS", 72),
+
+		AttachmentsStorage:       getEnv("ATTACHMENTS_STORAGE", "local"),
+		AttachmentsDir:           getEnv("ATTACHMENTS_DIR", "./data/attachments"),
+		AttachmentsMaxBytes:      getEnvInt("ATTACHMENTS_MAX_BYTES", 10<<20),
+		AttachmentsURLTTLMinutes: getEnvInt("ATTACHMENTS_URL_TTL_MINUTES", 15),
+		AttachmentsSigningKey:    getEnv("ATTACHMENTS_SIGNING_KEY", ""),
+		S3Endpoint:               getEnv("S3_ENDPOINT", ""),
+		S3Region:                 getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:                 getEnv("S3_BUCKET", ""),
+		S3AccessKey:              getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:              getEnv("S3_SECRET_KEY", ""),
+		S3PathStyle:              getEnv("S3_PATH_STYLE", "true") == "true",
 	}
 }
 
@@ -43,3 +156,55 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+
+func getEnvInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("WARN: invalid integer in %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// OIDCProvider — настройки входа через OpenID Connect (корпоративный SSO).
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// loadOIDCProviders читает OIDC_PROVIDERS=okta,google и для каждого имени
+// переменные OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _SCOPES, _REDIRECT_URL.
+func loadOIDCProviders(httpPort string) []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		p := OIDCProvider{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", "http://localhost:"+httpPort+"/api/v1/auth/oidc/"+name+"/callback"),
+			Scopes:       strings.Fields(strings.ReplaceAll(getEnv(prefix+"SCOPES", "openid email profile"), ",", " ")),
+		}
+		if p.Issuer == "" || p.ClientID == "" {
+			log.Printf("WARN: OIDC provider %q skipped: %sISSUER and %sCLIENT_ID are required", name, prefix, prefix)
+			continue
+		}
+		providers = append(providers, p)
+	}
+	return providers
+}
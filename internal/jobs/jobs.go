@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every запускает fn сразу и затем с интервалом interval, пока не отменён ctx.
+// Ошибки только логируются: следующий запуск всё равно состоится.
+func Every(ctx context.Context, name string, interval time.Duration, fn func(ctx context.Context) error) {
+	run := func() {
+		if err := fn(ctx); err != nil {
+			log.Printf("ERROR: job %q failed: %v", name, err)
+		}
+	}
+
+	log.Printf("INFO: Starting background job %q every %s", name, interval)
+	run()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run()
+		}
+	}
+}
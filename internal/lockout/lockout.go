@@ -0,0 +1,105 @@
+// Package lockout считает неудачные попытки входа и решает, сколько ждать
+// до следующей: экспоненциальная задержка, а после порога — блокировка.
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// State — счётчик неудач по одному ключу (аккаунт, IP).
+type State struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Store хранит счётчики. Реализации: MemoryStore для одного процесса и
+// PostgresStore, общий для всех экземпляров сервера.
+type Store interface {
+	Get(ctx context.Context, key string) (State, error)
+	// Fail добавляет неудачу. Если прошлая неудача была раньше since,
+	// счёт начинается заново.
+	Fail(ctx context.Context, key string, now, since time.Time) (State, error)
+	// Lock блокирует ключ до until и обнуляет счётчик неудач.
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+	// Cleanup удаляет ключи без неудач после before и без действующей блокировки.
+	Cleanup(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Policy задаёт правила для одного вида ключей.
+type Policy struct {
+	// FreeFailures неудач проходят без задержки.
+	FreeFailures int
+	// BaseDelay удваивается с каждой следующей неудачей, но не больше MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxFailures неудач подряд блокируют ключ на LockDuration; 0 — не блокировать.
+	MaxFailures  int
+	LockDuration time.Duration
+	// Window — через столько после последней неудачи счётчик забывается.
+	Window time.Duration
+}
+
+type Guard struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewGuard(store Store) *Guard {
+	return &Guard{store: store, now: time.Now}
+}
+
+// Wait возвращает, сколько ещё ждать до следующей попытки; 0 — можно сейчас.
+func (g *Guard) Wait(ctx context.Context, key string, p Policy) (time.Duration, error) {
+	st, err := g.store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	now := g.now()
+	if st.LockedUntil.After(now) {
+		return st.LockedUntil.Sub(now), nil
+	}
+	if st.Failures <= p.FreeFailures || now.Sub(st.LastFailure) > p.Window {
+		return 0, nil
+	}
+	next := st.LastFailure.Add(p.delay(st.Failures - p.FreeFailures))
+	if next.After(now) {
+		return next.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// Fail учитывает неудачу. true — именно эта неудача заблокировала ключ.
+func (g *Guard) Fail(ctx context.Context, key string, p Policy) (bool, error) {
+	now := g.now()
+	st, err := g.store.Fail(ctx, key, now, now.Add(-p.Window))
+	if err != nil {
+		return false, err
+	}
+	if p.MaxFailures > 0 && st.Failures >= p.MaxFailures {
+		return true, g.store.Lock(ctx, key, now.Add(p.LockDuration))
+	}
+	return false, nil
+}
+
+func (g *Guard) Reset(ctx context.Context, key string) error {
+	return g.store.Reset(ctx, key)
+}
+
+// Cleanup удаляет счётчики, которые уже ни на что не влияют.
+func (g *Guard) Cleanup(ctx context.Context, window time.Duration) (int64, error) {
+	return g.store.Cleanup(ctx, g.now().Add(-window))
+}
+
+func (p Policy) delay(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
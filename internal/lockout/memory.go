@@ -0,0 +1,71 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore держит счётчики в памяти процесса. Подходит для одного
+// экземпляра сервера и для разработки; при перезапуске счётчики теряются.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]State
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: map[string]State{}}
+}
+
+func (m *MemoryStore) Get(ctx context.Context, key string) (State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.states[key], nil
+}
+
+func (m *MemoryStore) Fail(ctx context.Context, key string, now, since time.Time) (State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := m.states[key]
+	if st.LastFailure.Before(since) {
+		st.Failures = 0
+	}
+	st.Failures++
+	st.LastFailure = now
+	m.states[key] = st
+	return st, nil
+}
+
+func (m *MemoryStore) Lock(ctx context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := m.states[key]
+	st.Failures = 0
+	st.LockedUntil = until
+	m.states[key] = st
+	return nil
+}
+
+func (m *MemoryStore) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.states, key)
+	return nil
+}
+
+func (m *MemoryStore) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var removed int64
+	for key, st := range m.states {
+		if st.LastFailure.Before(before) && !st.LockedUntil.After(now) {
+			delete(m.states, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
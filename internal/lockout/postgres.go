@@ -0,0 +1,69 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresStore хранит счётчики в таблице login_attempts, поэтому они общие
+// для всех экземпляров сервера и переживают перезапуск.
+type PostgresStore struct {
+	DB *sqlx.DB
+}
+
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{DB: db}
+}
+
+func scanState(row *sql.Row) (State, error) {
+	var st State
+	var lockedUntil sql.NullTime
+	if err := row.Scan(&st.Failures, &st.LastFailure, &lockedUntil); err != nil {
+		return State{}, err
+	}
+	st.LockedUntil = lockedUntil.Time
+	return st, nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (State, error) {
+	q := `SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE key=$1`
+	st, err := scanState(s.DB.QueryRowContext(ctx, q, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return State{}, nil
+	}
+	return st, err
+}
+
+func (s *PostgresStore) Fail(ctx context.Context, key string, now, since time.Time) (State, error) {
+	q := `
+		INSERT INTO login_attempts (key, failures, last_failure_at) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN login_attempts.last_failure_at < $3 THEN 1 ELSE login_attempts.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures, last_failure_at, locked_until`
+	return scanState(s.DB.QueryRowContext(ctx, q, key, now, since))
+}
+
+func (s *PostgresStore) Lock(ctx context.Context, key string, until time.Time) error {
+	q := `UPDATE login_attempts SET failures=0, locked_until=$2 WHERE key=$1`
+	_, err := s.DB.ExecContext(ctx, q, key, until)
+	return err
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	_, err := s.DB.ExecContext(ctx, `DELETE FROM login_attempts WHERE key=$1`, key)
+	return err
+}
+
+func (s *PostgresStore) Cleanup(ctx context.Context, before time.Time) (int64, error) {
+	q := `DELETE FROM login_attempts WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < NOW())`
+	result, err := s.DB.ExecContext(ctx, q, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
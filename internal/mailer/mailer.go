@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"anemone_notes/internal/config"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+)
+
+// Message — простое текстовое письмо одному получателю.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+// Mailer отправляет служебные письма: подтверждение почты, сброс пароля и т.п.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New выбирает отправку по MAILER: smtp, file или log (по умолчанию, для разработки).
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mailer {
+	case "", "log":
+		return NewLogMailer(cfg.MailFrom), nil
+	case "file":
+		return NewFileMailer(cfg.MailFrom, cfg.MailDir)
+	case "smtp":
+		return NewSMTPMailer(SMTPConfig{
+			Host:     cfg.SMTPRelayHost,
+			Port:     cfg.SMTPRelayPort,
+			Username: cfg.SMTPRelayUser,
+			Password: cfg.SMTPRelayPassword,
+			From:     cfg.MailFrom,
+		})
+	}
+	return nil, fmt.Errorf("unknown mailer %q", cfg.Mailer)
+}
+
+// buildMessage собирает RFC 5322 письмо в UTF-8 с телом в quoted-printable.
+func buildMessage(from string, msg Message, now time.Time) ([]byte, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if _, d, ok := strings.Cut(from, "@"); ok {
+		domain = strings.Trim(d, "> ")
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&b)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(msg.Text, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// validHeader не даёт подставить в заголовки перевод строки.
+func validHeader(values ...string) error {
+	for _, v := range values {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("invalid header value %q", v)
+		}
+	}
+	return nil
+}
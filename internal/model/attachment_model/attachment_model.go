@@ -0,0 +1,31 @@
+package attachment_model
+
+import "time"
+
+// Blob — содержимое файла, общее для всех вложений с тем же sha256.
+type Blob struct {
+	SHA256     string    `db:"sha256" json:"sha256"`
+	Size       int64     `db:"size" json:"size"`
+	MimeType   string    `db:"mime_type" json:"mime_type"`
+	StorageKey string    `db:"storage_key" json:"-"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+type Attachment struct {
+	ID         string    `db:"id" json:"id"`
+	UserID     int       `db:"user_id" json:"-"`
+	BlobSHA256 string    `db:"blob_sha256" json:"sha256"`
+	FileName   string    `db:"file_name" json:"file_name"`
+	PageID     *int      `db:"page_id" json:"page_id,omitempty"`
+	CardID     *string   `db:"card_id" json:"card_id,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	Size       int64     `db:"size" json:"size"`
+	MimeType   string    `db:"mime_type" json:"mime_type"`
+	StorageKey string    `db:"storage_key" json:"-"`
+}
+
+// SignedURL — временная ссылка на скачивание, не требующая авторизации.
+type SignedURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
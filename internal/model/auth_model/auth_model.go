@@ -1,10 +1,34 @@
 package auth_model
 
-import "time"
+import (
+	"database/sql"
+	"time"
+)
 
 type User struct {
 	ID       int
 	Email    string
-	Password string
+	// хэш не должен попасть в ответ API: клиенту отдаётся Profile
+	Password string `json:"-"`
 	CreatedAt time.Time
+	EmailVerifiedAt sql.NullTime
+	// DeletionRequestedAt — аккаунт будет удалён по истечении льготного периода
+	DeletionRequestedAt sql.NullTime
+}
+
+// Назначения одноразовых токенов из писем
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+	TokenSSOLogin      = "sso_login"
+	TokenUnlockAccount = "unlock_account"
+	TokenChangeEmail   = "change_email"
+)
+
+// TOTP — секрет второго фактора. До подтверждения первым кодом не действует.
+type TOTP struct {
+	UserID       int
+	Secret       string
+	ConfirmedAt  sql.NullTime
+	LastUsedStep sql.NullInt64
 }
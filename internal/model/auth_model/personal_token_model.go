@@ -0,0 +1,22 @@
+package auth_model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PersonalToken — токен доступа для скриптов. Сам токен показывается только
+// при создании, дальше виден лишь его префикс.
+type PersonalToken struct {
+	ID         int            `db:"id" json:"id"`
+	UserID     int            `db:"user_id" json:"-"`
+	Name       string         `db:"name" json:"name"`
+	Prefix     string         `db:"token_prefix" json:"prefix"`
+	Scopes     pq.StringArray `db:"scopes" json:"scopes"`
+	ExpiresAt  time.Time      `db:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time     `db:"last_used_at" json:"last_used_at"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	// Unverified — владелец ограничен политикой UNVERIFIED_POLICY
+	Unverified bool `db:"-" json:"-"`
+}
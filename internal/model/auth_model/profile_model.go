@@ -0,0 +1,38 @@
+package auth_model
+
+import "time"
+
+// Темы интерфейса
+const (
+	ThemeSystem = "system"
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+)
+
+// Preferences — настройки интерфейса: тема и что открывать по умолчанию.
+type Preferences struct {
+	Theme           string  `json:"theme"`
+	DefaultBoardID  *string `json:"default_board_id"`
+	DefaultFolderID *int    `json:"default_folder_id"`
+}
+
+// Profile — то, что клиент видит о пользователе. В отличие от User, не
+// содержит хэша пароля и безопасен для ответа API.
+type Profile struct {
+	ID            int       `json:"id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	PendingEmail  *string   `json:"pending_email,omitempty"`
+	HasPassword   bool      `json:"has_password"`
+	DisplayName   string    `json:"display_name"`
+	AvatarURL     *string   `json:"avatar_url"`
+	Timezone      string    `json:"timezone"` // по нему считаются ежедневные заметки и {{date}} в шаблонах
+	Locale        string    `json:"locale"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	Preferences Preferences `json:"preferences"`
+
+	DeletionRequestedAt *time.Time `json:"-"`
+	// DeletionScheduledAt — когда аккаунт будет удалён, если удаление запрошено
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
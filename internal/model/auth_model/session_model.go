@@ -0,0 +1,22 @@
+package auth_model
+
+import "time"
+
+// Session — семейство refresh-токенов, то есть одно устройство, на котором выполнен вход.
+type Session struct {
+	ID         string    `db:"family_id" json:"id"`
+	UserAgent  string    `db:"user_agent" json:"user_agent"`
+	IPAddress  string    `db:"ip_address" json:"ip_address"`
+	CreatedAt  time.Time `db:"session_created_at" json:"created_at"`
+	LastUsedAt time.Time `db:"last_used_at" json:"last_used_at"`
+	Current    bool      `db:"-" json:"current"`
+}
+
+// ClientInfo — данные устройства из запроса на вход или обновление токена.
+// IPAddress учитывает X-Forwarded-For и нужен только для показа,
+// RemoteIP — адрес TCP-соединения.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+	RemoteIP  string
+}
@@ -0,0 +1,26 @@
+package export_model
+
+import "time"
+
+// Статусы выгрузки данных
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+)
+
+// Export — архив со всеми данными пользователя. Собирается фоновой задачей
+// и хранится до ExpiresAt.
+type Export struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     *int       `db:"user_id" json:"-"`
+	Status     string     `db:"status" json:"status"`
+	StorageKey *string    `db:"storage_key" json:"-"`
+	Size       *int64     `db:"size" json:"size,omitempty"`
+	Error      *string    `db:"error" json:"error,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	StartedAt  *time.Time `db:"started_at" json:"-"`
+	FinishedAt *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+}
@@ -0,0 +1,47 @@
+package notes_model
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Типы блоков страницы
+const (
+	BlockParagraph = "paragraph"
+	BlockHeading   = "heading"
+	BlockTodo      = "todo"
+	BlockCode      = "code"
+	BlockQuote     = "quote"
+	BlockImage     = "image"
+	BlockDivider   = "divider"
+	BlockEmbed     = "embed"
+)
+
+// Block — один блок содержимого страницы. Props хранит параметры типа:
+// level у заголовка, checked у задачи, language у кода, url у картинки и встраивания.
+type Block struct {
+	ID        int
+	PageID    int
+	Type      string
+	Content   string
+	Props     types.JSONText
+	Position  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PageWithBlocks — страница вместе с блоками. Content остаётся текстовым
+// представлением блоков для старых клиентов.
+type PageWithBlocks struct {
+	*Page
+	Blocks []*Block
+}
+
+func IsValidBlockType(t string) bool {
+	switch t {
+	case BlockParagraph, BlockHeading, BlockTodo, BlockCode, BlockQuote, BlockImage, BlockDivider, BlockEmbed:
+		return true
+	}
+	return false
+}
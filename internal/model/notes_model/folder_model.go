@@ -1,6 +1,9 @@
 package notes_model;
 
-import "time";
+import (
+	"database/sql"
+	"time"
+)
 
 type Folder struct {
 	ID int
@@ -8,4 +11,24 @@ type Folder struct {
 	Title string
 	UpdatedAt time.Time
 	CreatedAt time.Time
-}
\ No newline at end of file
+	ParentFolderID sql.NullInt64
+	Position int
+}
+
+// FolderNode — узел дерева папок. PageCount считает страницы только в самой папке,
+// TotalPageCount — вместе со всеми вложенными папками.
+type FolderNode struct {
+	ID             int
+	Title          string
+	ParentFolderID sql.NullInt64
+	Position       int
+	PageCount      int
+	TotalPageCount int
+	Children       []*FolderNode
+}
+
+// Стратегии удаления папки
+const (
+	FolderDeleteMove  = "move"
+	FolderDeleteTrash = "trash"
+)
@@ -0,0 +1,27 @@
+package notes_model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PageLink — исходящая ссылка [[...]] со страницы. TargetPageID пуст, если
+// страницы с таким названием нет или она в корзине.
+type PageLink struct {
+	TargetTitle  string
+	TargetPageID sql.NullInt64
+	Broken       bool
+}
+
+// Backlink — страница, которая ссылается на текущую.
+type Backlink struct {
+	PageID    int
+	Title     string
+	UpdatedAt time.Time
+}
+
+type BrokenLink struct {
+	SourcePageID int
+	SourceTitle  string
+	TargetTitle  string
+}
@@ -6,12 +6,42 @@ import (
 )
 
 type Page struct {
-	ID        int
-	UserID    int
-	Title     string
-	Content   string
-	IsDeleted bool
-	FolderID  sql.NullInt64
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID           int
+	UserID       int
+	Title        string
+	Content      string
+	IsDeleted    bool
+	FolderID     sql.NullInt64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	ParentPageID sql.NullInt64
+	Position     int
+	DeletedAt    sql.NullTime
+	IsTemplate   bool
+}
+
+// TrashItem — страница в корзине. Вложенные страницы удаляются вместе
+// с родителем, поэтому ParentPageID может указывать на страницу из корзины.
+type TrashItem struct {
+	ID           int
+	Title        string
+	FolderID     sql.NullInt64
+	ParentPageID sql.NullInt64
+	DeletedAt    time.Time
+}
+
+// PageNode — узел дерева страниц без содержимого.
+type PageNode struct {
+	ID           int
+	Title        string
+	FolderID     sql.NullInt64
+	ParentPageID sql.NullInt64
+	Position     int
+	Depth        int
+	Children     []*PageNode
+}
+
+type Breadcrumb struct {
+	ID    int
+	Title string
 }
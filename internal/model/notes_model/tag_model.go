@@ -0,0 +1,17 @@
+package notes_model
+
+import "time"
+
+type Tag struct {
+	ID        int
+	UserID    int
+	Name      string
+	CreatedAt time.Time
+}
+
+// TagCount — тег с количеством страниц (без удалённых), для облака тегов.
+type TagCount struct {
+	ID    int
+	Name  string
+	Count int
+}
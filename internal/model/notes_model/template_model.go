@@ -0,0 +1,16 @@
+package notes_model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DailySettings — куда и из какого шаблона создаются ежедневные заметки.
+// Если папка не задана, при первой заметке создаётся папка «Daily».
+type DailySettings struct {
+	UserID         int
+	FolderID       sql.NullInt64
+	TemplatePageID sql.NullInt64
+	Timezone       string
+	UpdatedAt      time.Time
+}
@@ -0,0 +1,44 @@
+package notes_model
+
+import "time"
+
+// NotesExport — JSON-экспорт заметок: папки и страницы с блоками.
+type NotesExport struct {
+	ExportedAt time.Time
+	Folders    []*Folder
+	Pages      []*PageWithBlocks
+}
+
+// ImportFolder и ImportPage ссылаются друг на друга по ключам (путям внутри архива),
+// родители всегда идут в списке раньше детей.
+type ImportFolder struct {
+	Key       string
+	ParentKey string
+	Title     string
+}
+
+type ImportPage struct {
+	Key       string
+	FolderKey string
+	ParentKey string
+	Title     string
+	Content   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type NotesImport struct {
+	Folders []*ImportFolder
+	Pages   []*ImportPage
+}
+
+type SkippedFile struct {
+	Path   string
+	Reason string
+}
+
+type NotesImportReport struct {
+	Folders int
+	Pages   int
+	Skipped []*SkippedFile
+}
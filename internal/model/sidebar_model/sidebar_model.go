@@ -0,0 +1,33 @@
+package sidebar_model
+
+import "time"
+
+const (
+	ItemPage   = "page"
+	ItemFolder = "folder"
+	ItemBoard  = "board"
+)
+
+// Favorite — элемент избранного. ItemID — id страницы или папки строкой либо UUID доски.
+type Favorite struct {
+	ID        int       `db:"id" json:"id"`
+	ItemType  string    `db:"item_type" json:"item_type"`
+	ItemID    string    `db:"item_id" json:"item_id"`
+	Title     string    `db:"title" json:"title"`
+	Pinned    bool      `db:"pinned" json:"pinned"`
+	Position  int       `db:"position" json:"position"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type RecentItem struct {
+	ItemType string    `db:"item_type" json:"item_type"`
+	ItemID   string    `db:"item_id" json:"item_id"`
+	Title    string    `db:"title" json:"title"`
+	ViewedAt time.Time `db:"viewed_at" json:"viewed_at"`
+}
+
+// Sidebar — всё, что нужно боковой панели за один запрос.
+type Sidebar struct {
+	Favorites []*Favorite   `json:"favorites"`
+	Recent    []*RecentItem `json:"recent"`
+}
@@ -0,0 +1,64 @@
+package trello_model
+
+// Подмножество формата экспорта доски из Trello, которое мы умеем читать и писать.
+// Неизвестные поля при импорте игнорируются.
+
+type TrelloBoardExport struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Lists      []*TrelloList      `json:"lists"`
+	Cards      []*TrelloCard      `json:"cards"`
+	Labels     []*TrelloLabel     `json:"labels"`
+	Checklists []*TrelloChecklist `json:"checklists"`
+}
+
+type TrelloList struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+type TrelloCard struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Desc         string   `json:"desc"`
+	IDList       string   `json:"idList"`
+	Closed       bool     `json:"closed"`
+	Pos          float64  `json:"pos"`
+	IDLabels     []string `json:"idLabels"`
+	IDChecklists []string `json:"idChecklists"`
+}
+
+type TrelloLabel struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type TrelloChecklist struct {
+	ID         string             `json:"id"`
+	IDCard     string             `json:"idCard"`
+	Name       string             `json:"name"`
+	CheckItems []*TrelloCheckItem `json:"checkItems"`
+}
+
+type TrelloCheckItem struct {
+	Name  string  `json:"name"`
+	State string  `json:"state"`
+	Pos   float64 `json:"pos"`
+}
+
+// ImportReport описывает, что было (или при dry run — было бы) создано при импорте.
+type ImportReport struct {
+	DryRun          bool     `json:"dry_run"`
+	BoardID         string   `json:"board_id,omitempty"`
+	BoardTitle      string   `json:"board_title"`
+	Columns         int      `json:"columns"`
+	Cards           int      `json:"cards"`
+	ArchivedColumns int      `json:"archived_columns"`
+	ArchivedCards   int      `json:"archived_cards"`
+	Labels          int      `json:"labels"`
+	ChecklistItems  int      `json:"checklist_items"`
+	Warnings        []string `json:"warnings"`
+}
@@ -2,29 +2,46 @@ package trello_model
 
 import (
 	"time"
+
+	"github.com/jmoiron/sqlx/types"
 )
 
 type Card struct {
-	ID       string `db:"id" json:"id"`
-	Content  string `db:"content" json:"content"`
-	ColumnID string `db:"column_id" json:"column_id"`
-	Position int    `db:"position" json:"position"`
+	ID         string     `db:"id" json:"id"`
+	Content    string     `db:"content" json:"content"`
+	ColumnID   string     `db:"column_id" json:"column_id"`
+	Position   int        `db:"position" json:"position"`
+	IsArchived bool       `db:"is_archived" json:"is_archived,omitempty"`
+	ArchivedAt *time.Time `db:"archived_at" json:"archived_at,omitempty"`
 }
 
 type Column struct {
-	ID       string  `db:"id" json:"id"`
-	Title    string  `db:"column_title" json:"title"`
-	BoardID  string  `db:"board_id" json:"-"`
-	Position int     `db:"position" json:"position"`
-	Cards    []*Card `db:"-" json:"cards"`
+	ID         string     `db:"id" json:"id"`
+	Title      string     `db:"column_title" json:"title"`
+	BoardID    string     `db:"board_id" json:"-"`
+	Position   int        `db:"position" json:"position"`
+	IsArchived bool       `db:"is_archived" json:"is_archived,omitempty"`
+	ArchivedAt *time.Time `db:"archived_at" json:"archived_at,omitempty"`
+	WipLimit   *int       `db:"wip_limit" json:"wip_limit"`
+	WipPolicy  string     `db:"wip_policy" json:"wip_policy"`
+	CardCount  int        `db:"-" json:"card_count"`
+	OverLimit  bool       `db:"-" json:"over_wip_limit"`
+	Cards      []*Card    `db:"-" json:"cards"`
 }
 
+const (
+	WipPolicyWarn  = "warn"
+	WipPolicyBlock = "block"
+)
+
 type Board struct {
-	ID        string     `db:"id" json:"id"`
-	Title     string     `db:"title" json:"title"`
-	UserID    int     `db:"user_id" json:"user_id"`
-	CreatedAt time.Time  `db:"created_at" json:"created_at"`
-	UpdatedAt *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+	ID         string     `db:"id" json:"id"`
+	Title      string     `db:"title" json:"title"`
+	UserID     int        `db:"user_id" json:"user_id"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+	IsArchived bool       `db:"is_archived" json:"is_archived"`
+	ArchivedAt *time.Time `db:"archived_at" json:"archived_at,omitempty"`
 }
 
 type BoardWithColumns struct {
@@ -33,6 +50,55 @@ type BoardWithColumns struct {
 	Columns []*Column `json:"columns"`
 }
 
+// ArchivedBoardItems — содержимое архива одной доски: архивные колонки
+// вместе с их карточками и отдельно архивные карточки из активных колонок.
+type ArchivedBoardItems struct {
+	BoardID string    `json:"board_id"`
+	Columns []*Column `json:"columns"`
+	Cards   []*Card   `json:"cards"`
+}
+
+type Comment struct {
+	ID        string     `db:"id" json:"id"`
+	CardID    string     `db:"card_id" json:"card_id"`
+	UserID    int        `db:"user_id" json:"user_id"`
+	Body      string     `db:"body" json:"body"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+}
+
+// Действия, которые попадают в ленту активности доски
+const (
+	ActivityCardCreated    = "card_created"
+	ActivityCardRenamed    = "card_renamed"
+	ActivityCardMoved      = "card_moved"
+	ActivityCardArchived   = "card_archived"
+	ActivityCardRestored   = "card_restored"
+	ActivityColumnCreated  = "column_created"
+	ActivityColumnRenamed  = "column_renamed"
+	ActivityColumnArchived = "column_archived"
+	ActivityColumnRestored = "column_restored"
+	ActivityCommentAdded   = "comment_added"
+)
+
+type Activity struct {
+	ID        int64          `db:"id" json:"id"`
+	BoardID   string         `db:"board_id" json:"board_id"`
+	ColumnID  *string        `db:"column_id" json:"column_id,omitempty"`
+	CardID    *string        `db:"card_id" json:"card_id,omitempty"`
+	UserID    *int           `db:"user_id" json:"user_id,omitempty"`
+	Action    string         `db:"action" json:"action"`
+	Data      types.JSONText `db:"data" json:"data"`
+	CreatedAt time.Time      `db:"created_at" json:"created_at"`
+}
+
+type ActivityPage struct {
+	Items   []*Activity `json:"items"`
+	Limit   int         `json:"limit"`
+	Offset  int         `json:"offset"`
+	HasMore bool        `json:"has_more"`
+}
+
 type DefaultColumnData struct {
 	Title string
 	Cards []string
@@ -0,0 +1,227 @@
+package oidc
+
+import (
+	"anemone_notes/internal/config"
+	"anemone_notes/internal/oidc/oidctest"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testClientID = "anemone-test"
+
+func newTestProvider(t *testing.T) (*oidctest.Provider, *Client) {
+	t.Helper()
+	p, err := oidctest.New(testClientID)
+	if err != nil {
+		t.Fatalf("start mock provider: %v", err)
+	}
+	t.Cleanup(p.Close)
+
+	c := NewClient(config.OIDCProvider{
+		Name:        "mock",
+		Issuer:      p.Issuer(),
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost/api/v1/auth/oidc/mock/callback",
+		Scopes:      []string{"openid", "email"},
+	}, &http.Client{Timeout: 5 * time.Second})
+	return p, c
+}
+
+// login проходит весь поток: адрес входа, код от провайдера, обмен кода.
+func login(t *testing.T, p *oidctest.Provider, c *Client, claims map[string]any) (*Identity, error) {
+	t.Helper()
+	return loginWith(t, p, c, claims, "", "")
+}
+
+// loginWith позволяет подменить verifier и nonce, которые клиент передаёт при обмене.
+func loginWith(t *testing.T, p *oidctest.Provider, c *Client, claims map[string]any, verifier, nonce string) (*Identity, error) {
+	t.Helper()
+	ctx := context.Background()
+	state, _ := NewState()
+	realNonce, _ := NewState()
+	realVerifier, _ := NewVerifier()
+
+	authURL, err := c.AuthURL(ctx, state, realNonce, Challenge(realVerifier))
+	if err != nil {
+		t.Fatalf("AuthURL: %v", err)
+	}
+	code, err := p.Authorize(authURL, claims)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if verifier == "" {
+		verifier = realVerifier
+	}
+	if nonce == "" {
+		nonce = realNonce
+	}
+	return c.Exchange(ctx, code, verifier, nonce)
+}
+
+func TestExchangeGoodLogin(t *testing.T) {
+	p, c := newTestProvider(t)
+
+	id, err := login(t, p, c, map[string]any{
+		"sub":            "user-1",
+		"email":          " Alice@Example.com ",
+		"email_verified": true,
+		"name":           "Alice",
+	})
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	want := Identity{Provider: "mock", Subject: "user-1", Email: "Alice@Example.com", EmailVerified: true, Name: "Alice"}
+	if *id != want {
+		t.Fatalf("identity = %+v, want %+v", *id, want)
+	}
+}
+
+func TestExchangeForwardsPKCEVerifier(t *testing.T) {
+	p, c := newTestProvider(t)
+
+	verifier, _ := NewVerifier()
+	ctx := context.Background()
+	authURL, err := c.AuthURL(ctx, "state", "nonce", Challenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthURL: %v", err)
+	}
+	code, err := p.Authorize(authURL, map[string]any{"sub": "user-1"})
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if _, err := c.Exchange(ctx, code, verifier, "nonce"); err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if got := p.LastVerifier(); got != verifier {
+		t.Fatalf("token endpoint got verifier %q, want %q", got, verifier)
+	}
+
+	// провайдер отклоняет код, если verifier не соответствует challenge
+	other, _ := NewVerifier()
+	_, err = loginWith(t, p, c, map[string]any{"sub": "user-1"}, other, "")
+	if err == nil {
+		t.Fatal("Exchange with a wrong verifier succeeded")
+	}
+	if errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("wrong verifier must fail at the token endpoint, got %v", err)
+	}
+}
+
+func TestExchangeEmailVerifiedAsString(t *testing.T) {
+	p, c := newTestProvider(t)
+
+	for value, want := range map[any]bool{"true": true, "false": false, true: true, nil: false} {
+		claims := map[string]any{"sub": "user-1", "email": "a@example.com"}
+		if value != nil {
+			claims["email_verified"] = value
+		}
+		id, err := login(t, p, c, claims)
+		if err != nil {
+			t.Fatalf("email_verified=%v: %v", value, err)
+		}
+		if id.EmailVerified != want {
+			t.Errorf("email_verified=%v: got %v, want %v", value, id.EmailVerified, want)
+		}
+	}
+}
+
+func TestExchangeRejectsInvalidIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]any
+		setup  func(t *testing.T, p *oidctest.Provider)
+		nonce  string
+	}{
+		{
+			name: "bad signature",
+			setup: func(t *testing.T, p *oidctest.Provider) {
+				key, err := rsa.GenerateKey(rand.Reader, 2048)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p.SignWith(key)
+			},
+		},
+		{name: "wrong issuer", claims: map[string]any{"iss": "https://evil.example.com"}},
+		{name: "wrong audience", claims: map[string]any{"aud": "someone-else"}},
+		{name: "audience among others without azp", claims: map[string]any{"aud": []string{testClientID, "someone-else"}}},
+		{name: "expired", claims: map[string]any{"exp": time.Now().Add(-10 * time.Minute).Unix()}},
+		{name: "no expiry", claims: map[string]any{"exp": nil}},
+		{name: "nonce mismatch", nonce: "another-nonce"},
+		{name: "missing sub", claims: map[string]any{"sub": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, c := newTestProvider(t)
+			if tt.setup != nil {
+				tt.setup(t, p)
+			}
+			claims := map[string]any{"sub": "user-1"}
+			for k, v := range tt.claims {
+				claims[k] = v
+			}
+
+			_, err := loginWith(t, p, c, claims, "", tt.nonce)
+			if !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestUnknownKidRefetchesKeys(t *testing.T) {
+	p, c := newTestProvider(t)
+	claims := map[string]any{"sub": "user-1"}
+
+	if _, err := login(t, p, c, claims); err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if n := p.JWKSRequests.Load(); n != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", n)
+	}
+
+	// Сразу после загрузки незнакомый kid не заставляет перекачивать ключи
+	if err := p.RotateKey(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := login(t, p, c, claims); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("login right after rotation: err = %v, want ErrInvalidIDToken", err)
+	}
+	if n := p.JWKSRequests.Load(); n != 1 {
+		t.Fatalf("JWKS fetched %d times within refresh interval, want 1", n)
+	}
+
+	// По истечении интервала ключи перечитываются и новый kid принимается
+	c.keys.mu.Lock()
+	c.keys.fetchedAt = time.Now().Add(-jwksRefreshInterval)
+	c.keys.mu.Unlock()
+	id, err := login(t, p, c, claims)
+	if err != nil {
+		t.Fatalf("login after refetch: %v", err)
+	}
+	if id.Subject != "user-1" {
+		t.Fatalf("subject = %q", id.Subject)
+	}
+	if n := p.JWKSRequests.Load(); n != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", n)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	p, err := oidctest.New(testClientID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	c := NewClient(config.OIDCProvider{Name: "mock", Issuer: p.Issuer() + "/", ClientID: testClientID}, http.DefaultClient)
+	if _, err := c.AuthURL(context.Background(), "s", "n", "c"); err == nil {
+		t.Fatal("AuthURL succeeded with a mismatching issuer")
+	}
+}
@@ -0,0 +1,227 @@
+// Package oidctest — провайдер OpenID Connect в памяти процесса для тестов:
+// discovery, JWKS и token endpoint на httptest.Server с ключом RSA.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Provider выдаёт коды авторизации и подписывает ID-токены.
+type Provider struct {
+	Server   *httptest.Server
+	ClientID string
+
+	// JWKSRequests — сколько раз клиент скачивал ключи.
+	JWKSRequests atomic.Int32
+
+	mu      sync.Mutex
+	key     *rsa.PrivateKey
+	kid     string
+	signKey *rsa.PrivateKey
+	grants  map[string]grant
+	lastVer string
+}
+
+type grant struct {
+	nonce     string
+	challenge string
+	claims    map[string]any
+}
+
+// New запускает провайдера; сервер закрывается через Close.
+func New(clientID string) (*Provider, error) {
+	p := &Provider{ClientID: clientID, grants: map[string]grant{}}
+	if err := p.RotateKey(); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "use Provider.Authorize", http.StatusNotImplemented)
+	})
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	return p, nil
+}
+
+func (p *Provider) Close() {
+	p.Server.Close()
+}
+
+// Issuer — адрес провайдера для config.OIDCProvider.Issuer.
+func (p *Provider) Issuer() string {
+	return p.Server.URL
+}
+
+// RotateKey меняет ключ подписи и его kid, как при ротации ключей у провайдера.
+func (p *Provider) RotateKey() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	kid := make([]byte, 8)
+	if _, err := rand.Read(kid); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.key = key
+	p.kid = base64.RawURLEncoding.EncodeToString(kid)
+	p.signKey = nil
+	return nil
+}
+
+// SignWith подписывает следующие токены чужим ключом с тем же kid.
+func (p *Provider) SignWith(key *rsa.PrivateKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.signKey = key
+}
+
+// LastVerifier — code_verifier из последнего запроса к token endpoint.
+func (p *Provider) LastVerifier() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastVer
+}
+
+// Authorize заменяет страницу входа провайдера: принимает адрес, который
+// вернул клиент, и выдаёт код авторизации. claims добавляются к ID-токену
+// поверх стандартных (iss, aud, exp, iat, nonce) и могут их переопределить.
+func (p *Provider) Authorize(authURL string, claims map[string]any) (string, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	switch {
+	case q.Get("response_type") != "code":
+		return "", errors.New("oidctest: response_type must be code")
+	case q.Get("client_id") != p.ClientID:
+		return "", errors.New("oidctest: unknown client_id")
+	case q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "":
+		return "", errors.New("oidctest: S256 code challenge required")
+	case q.Get("state") == "":
+		return "", errors.New("oidctest: state required")
+	}
+
+	code := make([]byte, 16)
+	if _, err := rand.Read(code); err != nil {
+		return "", err
+	}
+	c := base64.RawURLEncoding.EncodeToString(code)
+	p.mu.Lock()
+	p.grants[c] = grant{nonce: q.Get("nonce"), challenge: q.Get("code_challenge"), claims: claims}
+	p.mu.Unlock()
+	return c, nil
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.Issuer(),
+		"authorization_endpoint": p.Issuer() + "/authorize",
+		"token_endpoint":         p.Issuer() + "/token",
+		"jwks_uri":               p.Issuer() + "/jwks",
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	p.JWKSRequests.Add(1)
+	p.mu.Lock()
+	pub := p.key.PublicKey
+	kid := p.kid
+	p.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request", err.Error())
+		return
+	}
+	code, verifier := r.PostForm.Get("code"), r.PostForm.Get("code_verifier")
+
+	p.mu.Lock()
+	g, ok := p.grants[code]
+	delete(p.grants, code)
+	p.lastVer = verifier
+	key, kid := p.key, p.kid
+	if p.signKey != nil {
+		key = p.signKey
+	}
+	p.mu.Unlock()
+
+	if r.PostForm.Get("grant_type") != "authorization_code" || !ok {
+		tokenError(w, "invalid_grant", "unknown authorization code")
+		return
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		tokenError(w, "invalid_grant", "PKCE verification failed")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": p.Issuer(),
+		"aud": p.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+	for k, v := range g.claims {
+		claims[k] = v
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = kid
+	idToken, err := t.SignedString(key)
+	if err != nil {
+		tokenError(w, "server_error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "at-" + code,
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+		"expires_in":   300,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
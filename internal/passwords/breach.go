@@ -0,0 +1,88 @@
+package passwords
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BreachChecker сообщает, встречался ли пароль в утечках.
+type BreachChecker interface {
+	IsBreached(ctx context.Context, password string) (bool, error)
+}
+
+const prefixLength = 5
+
+// PrefixDir — офлайн-список утёкших паролей в формате k-anonymity API Have I
+// Been Pwned: в каталоге по файлу на каждые первые 5 символов SHA-1 (ABCDE или
+// ABCDE.txt), в файле строки "ОСТАТОК_ХЭША:ЧИСЛО_УТЕЧЕК". Такой каталог
+// выкачивает официальный PwnedPasswordsDownloader. При проверке читается
+// только один небольшой файл, весь список в память не грузится.
+type PrefixDir struct {
+	Dir string
+	// MinCount — со скольких утечек пароль считается скомпрометированным.
+	MinCount int
+}
+
+func NewPrefixDir(dir string, minCount int) (*PrefixDir, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("breached passwords dir: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breached passwords dir: %s is not a directory", dir)
+	}
+	if minCount < 1 {
+		minCount = 1
+	}
+	return &PrefixDir{Dir: dir, MinCount: minCount}, nil
+}
+
+func (d *PrefixDir) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	f, err := d.open(prefix)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		line := strings.TrimSpace(sc.Text())
+		lineSuffix, count, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(lineSuffix, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil {
+			// строка без числа — пароль всё равно в списке
+			return true, nil
+		}
+		return n >= d.MinCount, nil
+	}
+	return false, sc.Err()
+}
+
+func (d *PrefixDir) open(prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(d.Dir, prefix))
+	if errors.Is(err, os.ErrNotExist) {
+		return os.Open(filepath.Join(d.Dir, prefix+".txt"))
+	}
+	return f, err
+}
@@ -0,0 +1,110 @@
+// Package passwords проверяет новые пароли: длина, классы символов, сходство
+// с почтой и наличие в списке утёкших паролей.
+package passwords
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// bcrypt учитывает только первые 72 байта, а более длинный пароль отвергает
+const bcryptMaxBytes = 72
+
+// Коды нарушений, по ним клиент может показать свой текст
+const (
+	CodeRequired      = "required"
+	CodeTooShort      = "too_short"
+	CodeTooLong       = "too_long"
+	CodeTooFewClasses = "too_few_classes"
+	CodeMatchesEmail  = "matches_email"
+	CodeBreached      = "breached"
+)
+
+// Violation — одно нарушение политики.
+type Violation struct {
+	Code    string
+	Message string
+}
+
+type Policy struct {
+	MinLength int
+	// MinClasses — сколько классов из четырёх (строчные, прописные, цифры,
+	// прочие символы) должно встретиться в пароле; 0 — не проверять.
+	MinClasses int
+	// Breached — список утёкших паролей; nil — не проверять.
+	Breached BreachChecker
+}
+
+// Check возвращает все нарушения политики; пустой список — пароль подходит.
+// email нужен, чтобы запретить пароль, совпадающий с адресом или его частью до @.
+func (p *Policy) Check(ctx context.Context, password, email string) []Violation {
+	if password == "" {
+		return []Violation{{CodeRequired, "password is required"}}
+	}
+
+	var violations []Violation
+	if n := utf8.RuneCountInString(password); n < p.MinLength {
+		violations = append(violations, Violation{CodeTooShort, fmt.Sprintf("password must be at least %d characters", p.MinLength)})
+	}
+	if len(password) > bcryptMaxBytes {
+		violations = append(violations, Violation{CodeTooLong, fmt.Sprintf("password must be at most %d bytes", bcryptMaxBytes)})
+	}
+	if p.MinClasses > 0 && classes(password) < p.MinClasses {
+		violations = append(violations, Violation{CodeTooFewClasses,
+			fmt.Sprintf("password must mix at least %d of: lowercase letters, uppercase letters, digits, symbols", p.MinClasses)})
+	}
+	if matchesEmail(password, email) {
+		violations = append(violations, Violation{CodeMatchesEmail, "password must not match your email"})
+	}
+	if len(violations) > 0 || p.Breached == nil {
+		return violations
+	}
+
+	breached, err := p.Breached.IsBreached(ctx, password)
+	if err != nil {
+		// недоступный список не должен мешать регистрации: остальные проверки уже пройдены
+		log.Printf("ERROR checking breached passwords: %v", err)
+		return nil
+	}
+	if breached {
+		violations = append(violations, Violation{CodeBreached, "this password appeared in a data breach, choose another one"})
+	}
+	return violations
+}
+
+func classes(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	n := 0
+	for _, ok := range []bool{lower, upper, digit, other} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+func matchesEmail(password, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	password = strings.ToLower(password)
+	local, _, _ := strings.Cut(email, "@")
+	return password == email || password == local
+}
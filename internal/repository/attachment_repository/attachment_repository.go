@@ -0,0 +1,204 @@
+package attachment_repository
+
+import (
+	"anemone_notes/internal/model/attachment_model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrParentNotFound     = errors.New("page or card not found")
+)
+
+type AttachmentRepo struct {
+	DB *sqlx.DB
+}
+
+func NewAttachmentRepo(db *sqlx.DB) *AttachmentRepo {
+	return &AttachmentRepo{DB: db}
+}
+
+const attachmentSelect = `
+	SELECT a.id, a.user_id, a.blob_sha256, a.file_name, a.page_id, a.card_id, a.created_at,
+	       b.size, b.mime_type, b.storage_key
+	FROM attachments a
+	JOIN attachment_blobs b ON b.sha256 = a.blob_sha256`
+
+// PageOwned проверяет, что страница принадлежит пользователю и не лежит в корзине.
+func (r *AttachmentRepo) PageOwned(ctx context.Context, userID, pageID int) (bool, error) {
+	var exists bool
+	q := `SELECT EXISTS(SELECT 1 FROM pages WHERE id = $1 AND user_id = $2 AND is_deleted IS NOT TRUE)`
+	err := r.DB.GetContext(ctx, &exists, q, pageID, userID)
+	return exists, err
+}
+
+// CardInColumn проверяет, что карточка лежит в колонке из пути запроса
+// (владение доской уже проверено middleware).
+func (r *AttachmentRepo) CardInColumn(ctx context.Context, columnID, cardID string) (bool, error) {
+	var exists bool
+	q := `SELECT EXISTS(SELECT 1 FROM cards WHERE id = $1 AND column_id = $2)`
+	err := r.DB.GetContext(ctx, &exists, q, cardID, columnID)
+	return exists, err
+}
+
+// CreateAttachment сохраняет вложение. На время транзакции берётся advisory-блокировка
+// по хэшу содержимого (строки blob может ещё не быть, и FOR UPDATE ничего бы не
+// заблокировал): если такого содержимого нет, вызывается upload и blob создаётся,
+// иначе файл переиспользуется. Возвращает true, если содержимое уже было в хранилище.
+func (r *AttachmentRepo) CreateAttachment(ctx context.Context, a *attachment_model.Attachment, blob *attachment_model.Blob, upload func() error) (bool, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Параллельные загрузки одного и того же файла выстраиваются в очередь
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1));`, blob.SHA256); err != nil {
+		return false, fmt.Errorf("failed to lock blob: %w", err)
+	}
+
+	var existing attachment_model.Blob
+	qBlob := `SELECT * FROM attachment_blobs WHERE sha256 = $1 FOR UPDATE;`
+	err = tx.GetContext(ctx, &existing, qBlob, blob.SHA256)
+	deduped := err == nil
+
+	switch {
+	case deduped:
+		*blob = existing
+	case errors.Is(err, sql.ErrNoRows):
+		if err := upload(); err != nil {
+			return false, fmt.Errorf("failed to store file: %w", err)
+		}
+		qInsert := `INSERT INTO attachment_blobs (sha256, size, mime_type, storage_key) VALUES ($1, $2, $3, $4) RETURNING *;`
+		if err := tx.QueryRowxContext(ctx, qInsert, blob.SHA256, blob.Size, blob.MimeType, blob.StorageKey).StructScan(blob); err != nil {
+			return false, fmt.Errorf("failed to insert blob: %w", err)
+		}
+	default:
+		return false, err
+	}
+
+	qAttachment := `
+		INSERT INTO attachments (id, user_id, blob_sha256, file_name, page_id, card_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at;`
+	err = tx.GetContext(ctx, &a.CreatedAt, qAttachment, a.ID, a.UserID, blob.SHA256, a.FileName, a.PageID, a.CardID)
+	if err != nil {
+		return deduped, fmt.Errorf("failed to insert attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return deduped, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	a.BlobSHA256 = blob.SHA256
+	a.Size = blob.Size
+	a.MimeType = blob.MimeType
+	a.StorageKey = blob.StorageKey
+	return deduped, nil
+}
+
+func (r *AttachmentRepo) GetPageAttachments(ctx context.Context, pageID int) ([]*attachment_model.Attachment, error) {
+	items := []*attachment_model.Attachment{}
+	q := attachmentSelect + ` WHERE a.page_id = $1 ORDER BY a.created_at;`
+	if err := r.DB.SelectContext(ctx, &items, q, pageID); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *AttachmentRepo) GetCardAttachments(ctx context.Context, cardID string) ([]*attachment_model.Attachment, error) {
+	items := []*attachment_model.Attachment{}
+	q := attachmentSelect + ` WHERE a.card_id = $1 ORDER BY a.created_at;`
+	if err := r.DB.SelectContext(ctx, &items, q, cardID); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *AttachmentRepo) GetAttachment(ctx context.Context, id string) (*attachment_model.Attachment, error) {
+	var a attachment_model.Attachment
+	q := attachmentSelect + ` WHERE a.id = $1;`
+	if err := r.DB.GetContext(ctx, &a, q, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetUserAttachment возвращает вложение, если пользователь загрузил его сам
+// или владеет страницей либо доской, к которой оно прикреплено.
+func (r *AttachmentRepo) GetUserAttachment(ctx context.Context, userID int, id string) (*attachment_model.Attachment, error) {
+	var a attachment_model.Attachment
+	q := attachmentSelect + `
+		LEFT JOIN pages p ON p.id = a.page_id
+		LEFT JOIN cards ca ON ca.id = a.card_id
+		LEFT JOIN columns c ON c.id = ca.column_id
+		LEFT JOIN boards bo ON bo.id = c.board_id
+		WHERE a.id = $1 AND (a.user_id = $2 OR p.user_id = $2 OR bo.user_id = $2);`
+	if err := r.DB.GetContext(ctx, &a, q, id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteAttachment удаляет только запись; файл уберёт сборщик сирот,
+// когда на него не останется ссылок.
+func (r *AttachmentRepo) DeleteAttachment(ctx context.Context, userID int, id string) error {
+	q := `
+		DELETE FROM attachments a
+		USING attachments x
+		LEFT JOIN pages p ON p.id = x.page_id
+		LEFT JOIN cards ca ON ca.id = x.card_id
+		LEFT JOIN columns c ON c.id = ca.column_id
+		LEFT JOIN boards bo ON bo.id = c.board_id
+		WHERE a.id = x.id AND a.id = $1 AND (x.user_id = $2 OR p.user_id = $2 OR bo.user_id = $2);`
+	result, err := r.DB.ExecContext(ctx, q, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
+
+// GetOrphanBlobs возвращает содержимое, на которое больше не ссылается ни одно
+// вложение: записи удаляются каскадно при очистке корзины или удалении доски.
+// Свежие blob'ы не трогаем, чтобы не мешать идущим загрузкам.
+func (r *AttachmentRepo) GetOrphanBlobs(ctx context.Context, olderThan time.Duration, limit int) ([]*attachment_model.Blob, error) {
+	blobs := []*attachment_model.Blob{}
+	q := `
+		SELECT b.* FROM attachment_blobs b
+		WHERE b.created_at < $1
+		  AND NOT EXISTS (SELECT 1 FROM attachments a WHERE a.blob_sha256 = b.sha256)
+		LIMIT $2;`
+	if err := r.DB.SelectContext(ctx, &blobs, q, time.Now().Add(-olderThan), limit); err != nil {
+		return nil, err
+	}
+	return blobs, nil
+}
+
+// DeleteOrphanBlob удаляет запись blob, если на неё всё ещё нет ссылок.
+// Возвращает false, если blob успели переиспользовать.
+func (r *AttachmentRepo) DeleteOrphanBlob(ctx context.Context, sha string) (bool, error) {
+	q := `
+		DELETE FROM attachment_blobs b
+		WHERE b.sha256 = $1 AND NOT EXISTS (SELECT 1 FROM attachments a WHERE a.blob_sha256 = b.sha256);`
+	result, err := r.DB.ExecContext(ctx, q, sha)
+	if err != nil {
+		return false, err
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
@@ -0,0 +1,170 @@
+package auth_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrIdentityNotFound  = errors.New("identity not found")
+	ErrLoginStateExpired = errors.New("login attempt expired, try again")
+)
+
+// IdentityRepo хранит привязки к внешним провайдерам входа и состояние
+// незавершённых входов через них.
+type IdentityRepo struct {
+	DB *sqlx.DB
+}
+
+func NewIdentityRepo(db *sqlx.DB) *IdentityRepo {
+	return &IdentityRepo{DB: db}
+}
+
+// Touch находит пользователя по привязке и отмечает время входа.
+func (r *IdentityRepo) Touch(ctx context.Context, provider, subject, email string) (int, error) {
+	q := `
+		UPDATE user_identities SET last_login_at=NOW(), email=$3
+		WHERE provider=$1 AND subject=$2
+		RETURNING user_id`
+	var userID int
+	if err := r.DB.GetContext(ctx, &userID, q, provider, subject, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrIdentityNotFound
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+// FindUserByEmail ищет пользователя без учёта регистра: провайдеры не
+// обязаны сохранять регистр адреса таким, каким его ввели при регистрации.
+func (r *IdentityRepo) FindUserByEmail(ctx context.Context, email string) (int, error) {
+	var userID int
+	q := `SELECT id FROM users WHERE lower(email)=lower($1) ORDER BY id LIMIT 1`
+	if err := r.DB.GetContext(ctx, &userID, q, email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrIdentityNotFound
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+// Link привязывает существующего пользователя и подтверждает его почту:
+// привязка по почте допускается, только если провайдер её подтвердил.
+// Если сам аккаунт почту так и не подтвердил, его мог заранее зарегистрировать
+// кто угодно. Поэтому пароль, незавершённая смена почты, 2FA, сессии и
+// токены такого аккаунта сбрасываются, и дальше им владеет только хозяин почты.
+func (r *IdentityRepo) Link(ctx context.Context, userID int, provider, subject, email string) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var verified bool
+	qUser := `SELECT email_verified_at IS NOT NULL FROM users WHERE id=$1 FOR UPDATE`
+	if err := tx.GetContext(ctx, &verified, qUser, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrIdentityNotFound
+		}
+		return err
+	}
+	if !verified {
+		if err := resetUnverifiedAccount(ctx, tx, userID); err != nil {
+			return err
+		}
+	}
+
+	q := `INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, q, userID, provider, subject, email); err != nil {
+		return err
+	}
+	qVerify := `UPDATE users SET email_verified_at=COALESCE(email_verified_at, NOW()) WHERE id=$1`
+	if _, err := tx.ExecContext(ctx, qVerify, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func resetUnverifiedAccount(ctx context.Context, tx *sqlx.Tx, userID int) error {
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET password=NULL, pending_email=NULL WHERE id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := revokeTokens(ctx, tx, `user_id=$1`, userID); err != nil {
+		return err
+	}
+	queries := []string{
+		`DELETE FROM personal_access_tokens WHERE user_id=$1`,
+		`DELETE FROM user_tokens WHERE user_id=$1 AND used_at IS NULL`,
+		`DELETE FROM user_recovery_codes WHERE user_id=$1`,
+		`DELETE FROM user_totp WHERE user_id=$1`,
+	}
+	for _, q := range queries {
+		if _, err := tx.ExecContext(ctx, q, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateUser создаёт пользователя без пароля вместе с привязкой.
+func (r *IdentityRepo) CreateUser(ctx context.Context, provider, subject, email string, emailVerified bool) (int, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var verifiedAt sql.NullTime
+	if emailVerified {
+		verifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+	var userID int
+	q := `INSERT INTO users (email, password, email_verified_at) VALUES ($1, NULL, $2) RETURNING id`
+	if err := tx.GetContext(ctx, &userID, q, email, verifiedAt); err != nil {
+		return 0, err
+	}
+	qLink := `INSERT INTO user_identities (user_id, provider, subject, email) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, qLink, userID, provider, subject, email); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+func (r *IdentityRepo) SaveState(ctx context.Context, stateHash, provider, verifier, nonce string, expiresAt time.Time) error {
+	q := `INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.DB.ExecContext(ctx, q, stateHash, provider, verifier, nonce, expiresAt)
+	return err
+}
+
+// ConsumeState забирает состояние входа; повторно его использовать нельзя.
+func (r *IdentityRepo) ConsumeState(ctx context.Context, stateHash, provider string) (string, string, error) {
+	var st struct {
+		Verifier string `db:"code_verifier"`
+		Nonce    string `db:"nonce"`
+	}
+	q := `
+		DELETE FROM oidc_login_states
+		WHERE state_hash=$1 AND provider=$2 AND expires_at > NOW()
+		RETURNING code_verifier, nonce`
+	if err := r.DB.GetContext(ctx, &st, q, stateHash, provider); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", ErrLoginStateExpired
+		}
+		return "", "", err
+	}
+	return st.Verifier, st.Nonce, nil
+}
+
+func (r *IdentityRepo) DeleteExpiredStates(ctx context.Context) (int64, error) {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,98 @@
+package auth_repository
+
+import (
+	"anemone_notes/internal/model/auth_model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var ErrPersonalTokenNotFound = errors.New("personal access token not found")
+
+type PersonalTokenRepo struct {
+	DB *sqlx.DB
+}
+
+func NewPersonalTokenRepo(db *sqlx.DB) *PersonalTokenRepo {
+	return &PersonalTokenRepo{DB: db}
+}
+
+const personalTokenColumns = `id, user_id, name, token_prefix, scopes, expires_at, last_used_at, created_at`
+
+func (r *PersonalTokenRepo) Create(ctx context.Context, t *auth_model.PersonalToken, tokenHash string) error {
+	q := `
+		INSERT INTO personal_access_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + personalTokenColumns
+	return r.DB.GetContext(ctx, t, q, t.UserID, t.Name, tokenHash, t.Prefix, pq.Array(t.Scopes), t.ExpiresAt)
+}
+
+func (r *PersonalTokenRepo) CountForUser(ctx context.Context, userID int) (int, error) {
+	var count int
+	q := `SELECT COUNT(*) FROM personal_access_tokens WHERE user_id=$1 AND expires_at > NOW()`
+	err := r.DB.GetContext(ctx, &count, q, userID)
+	return count, err
+}
+
+func (r *PersonalTokenRepo) GetForUser(ctx context.Context, userID int) ([]*auth_model.PersonalToken, error) {
+	q := `SELECT ` + personalTokenColumns + ` FROM personal_access_tokens WHERE user_id=$1 ORDER BY created_at DESC`
+	tokens := []*auth_model.PersonalToken{}
+	if err := r.DB.SelectContext(ctx, &tokens, q, userID); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Use находит действующий токен по хэшу и отмечает время использования.
+// Чтобы не писать в базу на каждый запрос, отметка обновляется раз в минуту.
+func (r *PersonalTokenRepo) Use(ctx context.Context, tokenHash string) (*auth_model.PersonalToken, error) {
+	var t auth_model.PersonalToken
+	q := `SELECT ` + personalTokenColumns + ` FROM personal_access_tokens WHERE token_hash=$1 AND expires_at > NOW()`
+	if err := r.DB.GetContext(ctx, &t, q, tokenHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPersonalTokenNotFound
+		}
+		return nil, err
+	}
+
+	qTouch := `
+		UPDATE personal_access_tokens SET last_used_at=NOW()
+		WHERE id=$1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	if _, err := r.DB.ExecContext(ctx, qTouch, t.ID); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *PersonalTokenRepo) Delete(ctx context.Context, userID, tokenID int) error {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM personal_access_tokens WHERE id=$1 AND user_id=$2`, tokenID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrPersonalTokenNotFound
+	}
+	return nil
+}
+
+func (r *PersonalTokenRepo) DeleteAllForUser(ctx context.Context, userID int) error {
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM personal_access_tokens WHERE user_id=$1`, userID)
+	return err
+}
+
+// DeleteExpired удаляет токены, истёкшие больше 30 дней назад: до этого они
+// остаются в списке, чтобы было видно, какой токен перестал работать.
+func (r *PersonalTokenRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM personal_access_tokens WHERE expires_at < NOW() - INTERVAL '30 days'`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
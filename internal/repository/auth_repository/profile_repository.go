@@ -0,0 +1,129 @@
+package auth_repository
+
+import (
+	"anemone_notes/internal/model/auth_model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrProfileNotFound = errors.New("user not found")
+	ErrEmailTaken      = errors.New("email is already in use")
+)
+
+type ProfileRepo struct {
+	DB *sqlx.DB
+}
+
+func NewProfileRepo(db *sqlx.DB) *ProfileRepo {
+	return &ProfileRepo{DB: db}
+}
+
+// ProfileUpdate — частичное обновление профиля, nil-поля не меняются.
+// Пустая строка в AvatarURL и DefaultBoardID и 0 в DefaultFolderID сбрасывают значение.
+type ProfileUpdate struct {
+	DisplayName     *string
+	AvatarURL       *string
+	Timezone        *string
+	Locale          *string
+	Theme           *string
+	DefaultBoardID  *string
+	DefaultFolderID *int
+}
+
+func (r *ProfileRepo) Get(ctx context.Context, userID int) (*auth_model.Profile, error) {
+	q := `
+		SELECT u.id, u.email, u.email_verified_at IS NOT NULL, u.pending_email, u.password IS NOT NULL,
+		       u.created_at, u.deletion_requested_at,
+		       COALESCE(p.display_name, ''), p.avatar_url, COALESCE(p.timezone, 'UTC'), COALESCE(p.locale, 'en'),
+		       COALESCE(p.theme, 'system'), p.default_board_id, p.default_folder_id
+		FROM users u
+		LEFT JOIN user_profiles p ON p.user_id = u.id
+		WHERE u.id=$1;`
+	var p auth_model.Profile
+	err := r.DB.QueryRowContext(ctx, q, userID).Scan(&p.ID, &p.Email, &p.EmailVerified, &p.PendingEmail, &p.HasPassword,
+		&p.CreatedAt, &p.DeletionRequestedAt,
+		&p.DisplayName, &p.AvatarURL, &p.Timezone, &p.Locale,
+		&p.Preferences.Theme, &p.Preferences.DefaultBoardID, &p.Preferences.DefaultFolderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProfileNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Update создаёт строку профиля при первом изменении.
+func (r *ProfileRepo) Update(ctx context.Context, userID int, upd ProfileUpdate) error {
+	q := `
+		INSERT INTO user_profiles (user_id, display_name, avatar_url, timezone, locale, theme, default_board_id, default_folder_id)
+		VALUES ($1, COALESCE($2, ''), NULLIF($3, ''), COALESCE($4, 'UTC'), COALESCE($5, 'en'), COALESCE($6, 'system'),
+		        NULLIF($7, '')::uuid, NULLIF($8::int, 0))
+		ON CONFLICT (user_id) DO UPDATE SET
+			display_name = COALESCE($2, user_profiles.display_name),
+			avatar_url = CASE WHEN $3::text IS NULL THEN user_profiles.avatar_url ELSE NULLIF($3, '') END,
+			timezone = COALESCE($4, user_profiles.timezone),
+			locale = COALESCE($5, user_profiles.locale),
+			theme = COALESCE($6, user_profiles.theme),
+			default_board_id = CASE WHEN $7::text IS NULL THEN user_profiles.default_board_id ELSE NULLIF($7, '')::uuid END,
+			default_folder_id = CASE WHEN $8::int IS NULL THEN user_profiles.default_folder_id ELSE NULLIF($8::int, 0) END,
+			updated_at = NOW();`
+	_, err := r.DB.ExecContext(ctx, q, userID, upd.DisplayName, upd.AvatarURL, upd.Timezone, upd.Locale, upd.Theme,
+		upd.DefaultBoardID, upd.DefaultFolderID)
+	return err
+}
+
+func (r *ProfileRepo) BoardOwned(ctx context.Context, userID int, boardID string) (bool, error) {
+	var ok bool
+	q := `SELECT EXISTS(SELECT 1 FROM boards WHERE id=$1 AND user_id=$2);`
+	err := r.DB.GetContext(ctx, &ok, q, boardID, userID)
+	return ok, err
+}
+
+func (r *ProfileRepo) FolderOwned(ctx context.Context, userID, folderID int) (bool, error) {
+	var ok bool
+	q := `SELECT EXISTS(SELECT 1 FROM notes_folder WHERE id=$1 AND user_id=$2);`
+	err := r.DB.GetContext(ctx, &ok, q, folderID, userID)
+	return ok, err
+}
+
+// EmailInUse проверяет, занят ли адрес другим аккаунтом.
+func (r *ProfileRepo) EmailInUse(ctx context.Context, email string) (bool, error) {
+	var taken bool
+	q := `SELECT EXISTS(SELECT 1 FROM users WHERE lower(email) = lower($1));`
+	err := r.DB.GetContext(ctx, &taken, q, email)
+	return taken, err
+}
+
+func (r *ProfileRepo) SetPendingEmail(ctx context.Context, userID int, email string) error {
+	_, err := r.DB.ExecContext(ctx, `UPDATE users SET pending_email=$1 WHERE id=$2;`, email, userID)
+	return err
+}
+
+// ConfirmEmailChange переносит pending_email в email и возвращает старый
+// и новый адреса. Адрес, занятый за время ожидания, даёт ErrEmailTaken.
+func (r *ProfileRepo) ConfirmEmailChange(ctx context.Context, userID int) (string, string, error) {
+	var oldEmail, newEmail string
+	q := `
+		UPDATE users u SET email=u.pending_email, pending_email=NULL, email_verified_at=NOW()
+		FROM (SELECT id, email FROM users WHERE id=$1 FOR UPDATE) old
+		WHERE u.id = old.id AND u.pending_email IS NOT NULL
+		RETURNING old.email, u.email;`
+	err := r.DB.QueryRowContext(ctx, q, userID).Scan(&oldEmail, &newEmail)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return "", "", ErrEmailTaken
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", ErrInvalidToken
+		}
+		return "", "", err
+	}
+	return oldEmail, newEmail, nil
+}
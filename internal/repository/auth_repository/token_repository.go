@@ -1,12 +1,23 @@
 package auth_repository
 
 import (
+	"anemone_notes/internal/model/auth_model"
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+var (
+	ErrRefreshNotFound = errors.New("refresh token not found or expired")
+	// ErrRefreshReused — предъявлен уже обменянный токен: его, скорее всего,
+	// украли, поэтому всё семейство отозвано.
+	ErrRefreshReused   = errors.New("refresh token reuse detected")
+	ErrSessionNotFound = errors.New("session not found")
+)
+
 type RefreshRepo struct {
 	DB *sqlx.DB
 }
@@ -15,21 +26,205 @@ func NewRefreshRepo(db *sqlx.DB) *RefreshRepo {
 	return &RefreshRepo{DB: db}
 }
 
-func (r *RefreshRepo) Store(ctx context.Context, userID int, token string, exp time.Time) error {
-	q := `INSERT INTO refresh_tokens (user_id, token, expires_at) VALUES ($1,$2,$3)`
-	_, err := r.DB.ExecContext(ctx, q, userID, token, exp)
+// RefreshIssue — новый refresh-токен вместе с access-токеном, выданным в паре с ним.
+type RefreshIssue struct {
+	UserID          int
+	FamilyID        string
+	TokenHash       string
+	ExpiresAt       time.Time
+	AccessJTI       string
+	AccessExpiresAt time.Time
+	Client          auth_model.ClientInfo
+}
+
+// Store сохраняет первый токен нового семейства (сессии).
+func (r *RefreshRepo) Store(ctx context.Context, t RefreshIssue) error {
+	q := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, access_jti, access_expires_at, user_agent, ip_address)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := r.DB.ExecContext(ctx, q, t.UserID, t.FamilyID, t.TokenHash, t.ExpiresAt,
+		t.AccessJTI, t.AccessExpiresAt, t.Client.UserAgent, t.Client.IPAddress)
 	return err
 }
 
-func (r *RefreshRepo) Check(ctx context.Context, userID int, token string, exp time.Time) (bool, error) {
-	var count int
-	q := `SELECT COUNT(*) FROM refresh_tokens WHERE user_id=$1 AND token=$2 AND expires_at>$3`
-	err := r.DB.QueryRowContext(ctx, q, userID, token, time.Now()).Scan(&count)
-	return count > 0, err
+// Rotate обменивает токен на новый того же семейства и возвращает id семейства;
+// UserID и FamilyID в next берутся из старого токена. Повторное предъявление
+// обменянного токена отзывает всё семейство и возвращает ErrRefreshReused.
+func (r *RefreshRepo) Rotate(ctx context.Context, oldHash string, next RefreshIssue) (string, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var cur struct {
+		UserID           int          `db:"user_id"`
+		FamilyID         string       `db:"family_id"`
+		ExpiresAt        time.Time    `db:"expires_at"`
+		RotatedAt        sql.NullTime `db:"rotated_at"`
+		SessionCreatedAt time.Time    `db:"session_created_at"`
+	}
+	q := `
+		SELECT user_id, family_id, expires_at, rotated_at, session_created_at
+		FROM refresh_tokens WHERE token_hash=$1 FOR UPDATE;`
+	if err := tx.GetContext(ctx, &cur, q, oldHash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrRefreshNotFound
+		}
+		return "", err
+	}
+
+	if cur.RotatedAt.Valid {
+		if _, err := revokeTokens(ctx, tx, `family_id=$1`, cur.FamilyID); err != nil {
+			return "", err
+		}
+		if err := tx.Commit(); err != nil {
+			return "", err
+		}
+		return cur.FamilyID, ErrRefreshReused
+	}
+	if !cur.ExpiresAt.After(time.Now()) {
+		return "", ErrRefreshNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET rotated_at=NOW() WHERE token_hash=$1;`, oldHash); err != nil {
+		return "", err
+	}
+	qNew := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, access_jti, access_expires_at,
+			user_agent, ip_address, session_created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW());`
+	_, err = tx.ExecContext(ctx, qNew, cur.UserID, cur.FamilyID, next.TokenHash, next.ExpiresAt,
+		next.AccessJTI, next.AccessExpiresAt, next.Client.UserAgent, next.Client.IPAddress, cur.SessionCreatedAt)
+	if err != nil {
+		return "", err
+	}
+	return cur.FamilyID, tx.Commit()
 }
 
-func (r *RefreshRepo) Delete(ctx context.Context, userID int, token string) error {
-	q := `DELETE FROM refresh_tokens WHERE user_id=$1 AND token=$2`
-	_, err := r.DB.ExecContext(ctx, q, userID, token)
+// revokeTokens удаляет refresh-токены по условию cond и заносит в denylist
+// ещё действующие access-токены, выданные вместе с ними. Возвращает число
+// затронутых сессий.
+func revokeTokens(ctx context.Context, tx *sqlx.Tx, cond string, args ...any) (int64, error) {
+	qDeny := `
+		INSERT INTO access_denylist (jti, expires_at)
+		SELECT access_jti, access_expires_at FROM refresh_tokens
+		WHERE access_jti IS NOT NULL AND access_expires_at > NOW() AND ` + cond + `
+		ON CONFLICT (jti) DO NOTHING;`
+	if _, err := tx.ExecContext(ctx, qDeny, args...); err != nil {
+		return 0, err
+	}
+
+	var families int64
+	qDelete := `
+		WITH deleted AS (DELETE FROM refresh_tokens WHERE ` + cond + ` RETURNING family_id)
+		SELECT COUNT(DISTINCT family_id) FROM deleted;`
+	if err := tx.GetContext(ctx, &families, qDelete, args...); err != nil {
+		return 0, err
+	}
+	return families, nil
+}
+
+func (r *RefreshRepo) revoke(ctx context.Context, cond string, args ...any) (int64, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	n, err := revokeTokens(ctx, tx, cond, args...)
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+// DeleteFamily завершает сессию, к которой относится токен.
+func (r *RefreshRepo) DeleteFamily(ctx context.Context, tokenHash string) error {
+	_, err := r.revoke(ctx, `family_id = (SELECT family_id FROM refresh_tokens WHERE token_hash=$1)`, tokenHash)
 	return err
-}
\ No newline at end of file
+}
+
+// DeleteAllForUser отзывает все refresh-токены пользователя, например после смены пароля.
+func (r *RefreshRepo) DeleteAllForUser(ctx context.Context, userID int) error {
+	_, err := r.revoke(ctx, `user_id=$1`, userID)
+	return err
+}
+
+// GetSessions возвращает активные сессии пользователя: у каждой ровно один
+// необменянный и неистёкший токен.
+func (r *RefreshRepo) GetSessions(ctx context.Context, userID int) ([]*auth_model.Session, error) {
+	q := `
+		SELECT family_id, user_agent, ip_address, session_created_at, last_used_at
+		FROM refresh_tokens
+		WHERE user_id=$1 AND rotated_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC;`
+	sessions := []*auth_model.Session{}
+	if err := r.DB.SelectContext(ctx, &sessions, q, userID); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *RefreshRepo) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	n, err := r.revoke(ctx, `user_id=$1 AND family_id=$2`, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeOtherSessions завершает все сессии пользователя, кроме keepSessionID.
+func (r *RefreshRepo) RevokeOtherSessions(ctx context.Context, userID int, keepSessionID string) (int64, error) {
+	return r.revoke(ctx, `user_id=$1 AND family_id <> $2`, userID, keepSessionID)
+}
+
+// SessionCreatedAt возвращает время входа, которым открыта сессия.
+func (r *RefreshRepo) SessionCreatedAt(ctx context.Context, userID int, sessionID string) (time.Time, error) {
+	var createdAt time.Time
+	q := `SELECT MIN(session_created_at) FROM refresh_tokens WHERE user_id=$1 AND family_id=$2 HAVING COUNT(*) > 0;`
+	if err := r.DB.GetContext(ctx, &createdAt, q, userID, sessionID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, ErrSessionNotFound
+		}
+		return time.Time{}, err
+	}
+	return createdAt, nil
+}
+
+// DenyAccess отзывает отдельный токен до истечения его срока.
+func (r *RefreshRepo) DenyAccess(ctx context.Context, jti string, expiresAt time.Time) error {
+	q := `INSERT INTO access_denylist (jti, expires_at) VALUES ($1, $2) ON CONFLICT (jti) DO NOTHING;`
+	_, err := r.DB.ExecContext(ctx, q, jti, expiresAt)
+	return err
+}
+
+func (r *RefreshRepo) IsAccessRevoked(ctx context.Context, jti string) (bool, error) {
+	var revoked bool
+	q := `SELECT EXISTS(SELECT 1 FROM access_denylist WHERE jti=$1);`
+	err := r.DB.GetContext(ctx, &revoked, q, jti)
+	return revoked, err
+}
+
+// DeleteExpired удаляет истёкшие токены и записи denylist. Обменянные токены
+// живут до своего срока: пока они не истекли, по ним ловится повторное использование.
+func (r *RefreshRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM refresh_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	result, err = r.DB.ExecContext(ctx, `DELETE FROM access_denylist WHERE expires_at < NOW()`)
+	if err != nil {
+		return removed, err
+	}
+	denied, err := result.RowsAffected()
+	return removed + denied, err
+}
@@ -0,0 +1,144 @@
+package auth_repository
+
+import (
+	"anemone_notes/internal/model/auth_model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrTOTPNotFound       = errors.New("two-factor authentication is not set up")
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+)
+
+type TOTPRepo struct {
+	DB *sqlx.DB
+}
+
+func NewTOTPRepo(db *sqlx.DB) *TOTPRepo {
+	return &TOTPRepo{DB: db}
+}
+
+func (r *TOTPRepo) Get(ctx context.Context, userID int) (*auth_model.TOTP, error) {
+	var t auth_model.TOTP
+	q := `SELECT user_id, secret, confirmed_at, last_used_step FROM user_totp WHERE user_id=$1`
+	err := r.DB.QueryRowContext(ctx, q, userID).Scan(&t.UserID, &t.Secret, &t.ConfirmedAt, &t.LastUsedStep)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTOTPNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TOTPRepo) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	var enabled bool
+	q := `SELECT EXISTS(SELECT 1 FROM user_totp WHERE user_id=$1 AND confirmed_at IS NOT NULL)`
+	err := r.DB.GetContext(ctx, &enabled, q, userID)
+	return enabled, err
+}
+
+// SavePending сохраняет новый неподтверждённый секрет, заменяя прежний
+// неподтверждённый. Включённый второй фактор не трогает.
+func (r *TOTPRepo) SavePending(ctx context.Context, userID int, secret string) error {
+	q := `
+		INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret=EXCLUDED.secret, last_used_step=NULL, created_at=NOW()
+		WHERE user_totp.confirmed_at IS NULL`
+	result, err := r.DB.ExecContext(ctx, q, userID, secret)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+	return nil
+}
+
+// Confirm включает второй фактор и заменяет коды восстановления.
+func (r *TOTPRepo) Confirm(ctx context.Context, userID int, step int64, recoveryHashes []string) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := `UPDATE user_totp SET confirmed_at=NOW(), last_used_step=$2 WHERE user_id=$1 AND confirmed_at IS NULL`
+	result, err := tx.ExecContext(ctx, q, userID, step)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrTOTPAlreadyEnabled
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID int, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		q := `INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.ExecContext(ctx, q, userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseStep атомарно запоминает шаг принятого кода. false — код с этого или
+// более раннего шага уже использовался.
+func (r *TOTPRepo) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	q := `
+		UPDATE user_totp SET last_used_step=$2
+		WHERE user_id=$1 AND confirmed_at IS NOT NULL AND (last_used_step IS NULL OR last_used_step < $2)`
+	result, err := r.DB.ExecContext(ctx, q, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UseRecoveryCode гасит код восстановления; false — такого неиспользованного кода нет.
+func (r *TOTPRepo) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	q := `UPDATE user_recovery_codes SET used_at=NOW() WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL`
+	result, err := r.DB.ExecContext(ctx, q, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// Delete выключает второй фактор вместе с кодами восстановления.
+func (r *TOTPRepo) Delete(ctx context.Context, userID int) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id=$1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
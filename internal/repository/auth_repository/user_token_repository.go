@@ -0,0 +1,80 @@
+package auth_repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+type UserTokenRepo struct {
+	DB *sqlx.DB
+}
+
+func NewUserTokenRepo(db *sqlx.DB) *UserTokenRepo {
+	return &UserTokenRepo{DB: db}
+}
+
+// Create сохраняет хэш нового токена. Прежние неиспользованные токены того же
+// назначения гасятся: действует только ссылка из последнего письма.
+func (r *UserTokenRepo) Create(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qRevoke := `UPDATE user_tokens SET used_at=NOW() WHERE user_id=$1 AND purpose=$2 AND used_at IS NULL;`
+	if _, err := tx.ExecContext(ctx, qRevoke, userID, purpose); err != nil {
+		return err
+	}
+	q := `INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4);`
+	if _, err := tx.ExecContext(ctx, q, userID, purpose, tokenHash, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Consume атомарно помечает токен использованным и возвращает его владельца.
+func (r *UserTokenRepo) Consume(ctx context.Context, purpose, tokenHash string) (int, error) {
+	q := `
+		UPDATE user_tokens SET used_at=NOW()
+		WHERE token_hash=$1 AND purpose=$2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id;`
+	var userID int
+	if err := r.DB.GetContext(ctx, &userID, q, tokenHash, purpose); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidToken
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+// Peek возвращает владельца действующего токена, не расходуя его: так запрос
+// с ошибкой в других полях не сжигает ссылку из письма.
+func (r *UserTokenRepo) Peek(ctx context.Context, purpose, tokenHash string) (int, error) {
+	q := `SELECT user_id FROM user_tokens WHERE token_hash=$1 AND purpose=$2 AND used_at IS NULL AND expires_at > NOW();`
+	var userID int
+	if err := r.DB.GetContext(ctx, &userID, q, tokenHash, purpose); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidToken
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+// DeleteStale удаляет использованные и истёкшие токены старше суток.
+func (r *UserTokenRepo) DeleteStale(ctx context.Context) (int64, error) {
+	q := `DELETE FROM user_tokens WHERE (used_at IS NOT NULL OR expires_at < NOW()) AND created_at < NOW() - INTERVAL '1 day';`
+	result, err := r.DB.ExecContext(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
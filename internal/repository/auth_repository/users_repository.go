@@ -3,8 +3,11 @@ package auth_repository
 import (
 	"anemone_notes/internal/model/auth_model"
 	"context"
+	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type UserRepo struct {
@@ -17,13 +20,19 @@ func NewUserRepo(db *sqlx.DB) *UserRepo {
 
 func (r *UserRepo) Create(ctx context.Context, u *auth_model.User) error {
 	q := `INSERT INTO users (email, password) VALUES ($1, $2) RETURNING id, created_at`
-	return r.DB.QueryRowContext(ctx, q, u.Email, u.Password).Scan(&u.ID, &u.CreatedAt)
+	err := r.DB.QueryRowContext(ctx, q, u.Email, u.Password).Scan(&u.ID, &u.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrEmailTaken
+	}
+	return err
 }
 
 func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*auth_model.User, error) {
 	var u auth_model.User
-	q := `SELECT id, email, password, created_at FROM users WHERE email=$1`
-	err := r.DB.QueryRowContext(ctx, q, email).Scan(&u.ID, &u.Email, &u.Password, &u.CreatedAt)
+	// у пользователей из SSO пароля нет: пустой хэш не совпадёт ни с одним паролем
+	q := `SELECT id, email, COALESCE(password, ''), created_at, email_verified_at, deletion_requested_at FROM users WHERE lower(email)=lower($1) ORDER BY id LIMIT 1`
+	err := r.DB.QueryRowContext(ctx, q, email).Scan(&u.ID, &u.Email, &u.Password, &u.CreatedAt, &u.EmailVerifiedAt, &u.DeletionRequestedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -32,8 +41,8 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*auth_model.Us
 
 func(r *UserRepo) GetByID(ctx context.Context, id float64) (*auth_model.User, error) {
 	var u auth_model.User
-	q := `SELECT id, email, created_at FROM users WHERE id=$1`
-	err := r.DB.QueryRowContext(ctx, q, id).Scan(&u.ID, &u.Email, &u.CreatedAt)
+	q := `SELECT id, email, created_at, email_verified_at, deletion_requested_at FROM users WHERE id=$1`
+	err := r.DB.QueryRowContext(ctx, q, id).Scan(&u.ID, &u.Email, &u.CreatedAt, &u.EmailVerifiedAt, &u.DeletionRequestedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -44,4 +53,49 @@ func (r *UserRepo) UpdatePassword(ctx context.Context, userID int, newHash strin
 	q := `UPDATE users SET password=$1 WHERE id=$2`
 	_, err := r.DB.ExecContext(ctx, q, newHash, userID)
 	return err
-}
\ No newline at end of file
+}
+
+func (r *UserRepo) MarkEmailVerified(ctx context.Context, userID int) error {
+	q := `UPDATE users SET email_verified_at=COALESCE(email_verified_at, NOW()) WHERE id=$1`
+	_, err := r.DB.ExecContext(ctx, q, userID)
+	return err
+}
+
+// GetPasswordHash нужен для повторной проверки пароля перед опасными действиями.
+func (r *UserRepo) GetPasswordHash(ctx context.Context, userID int) (string, error) {
+	var hash string
+	err := r.DB.QueryRowContext(ctx, `SELECT COALESCE(password, '') FROM users WHERE id=$1`, userID).Scan(&hash)
+	return hash, err
+}
+
+// ScheduleDeletion помечает аккаунт к удалению; повторный запрос не сдвигает дату.
+func (r *UserRepo) ScheduleDeletion(ctx context.Context, userID int) (time.Time, error) {
+	var requestedAt time.Time
+	q := `UPDATE users SET deletion_requested_at=COALESCE(deletion_requested_at, NOW()) WHERE id=$1 RETURNING deletion_requested_at`
+	err := r.DB.QueryRowContext(ctx, q, userID).Scan(&requestedAt)
+	return requestedAt, err
+}
+
+// CancelDeletion снимает пометку; false — аккаунт и не был помечен.
+func (r *UserRepo) CancelDeletion(ctx context.Context, userID int) (bool, error) {
+	q := `UPDATE users SET deletion_requested_at=NULL WHERE id=$1 AND deletion_requested_at IS NOT NULL`
+	result, err := r.DB.ExecContext(ctx, q, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteScheduled окончательно удаляет аккаунты, помеченные раньше before.
+// Страницы, папки, доски, почтовые адреса с письмами и остальные данные
+// пользователя удаляются каскадно; в ленте активности чужих досок он
+// остаётся без имени (ON DELETE SET NULL).
+func (r *UserRepo) DeleteScheduled(ctx context.Context, before time.Time) (int64, error) {
+	q := `DELETE FROM users WHERE deletion_requested_at IS NOT NULL AND deletion_requested_at < $1`
+	result, err := r.DB.ExecContext(ctx, q, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
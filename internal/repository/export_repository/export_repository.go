@@ -0,0 +1,219 @@
+package export_repository
+
+import (
+	"anemone_notes/internal/model/export_model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrExportNotFound   = errors.New("export not found")
+	ErrExportInProgress = errors.New("an export is already in progress")
+)
+
+// Выгрузку, зависшую в running дольше этого (например, после перезапуска), берём заново
+const staleRunningAfter = time.Hour
+
+type ExportRepo struct {
+	DB *sqlx.DB
+}
+
+func NewExportRepo(db *sqlx.DB) *ExportRepo {
+	return &ExportRepo{DB: db}
+}
+
+// Section — один JSON-файл архива.
+type Section struct {
+	Name string
+	Data json.RawMessage
+}
+
+// Create ставит выгрузку в очередь, если у пользователя нет незавершённой.
+func (r *ExportRepo) Create(ctx context.Context, userID int) (*export_model.Export, error) {
+	var e export_model.Export
+	q := `
+		INSERT INTO data_exports (user_id)
+		SELECT $1
+		WHERE NOT EXISTS (SELECT 1 FROM data_exports WHERE user_id=$1 AND status IN ('pending', 'running'))
+		RETURNING *;`
+	if err := r.DB.GetContext(ctx, &e, q, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrExportInProgress
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *ExportRepo) GetUserExport(ctx context.Context, userID int, id string) (*export_model.Export, error) {
+	var e export_model.Export
+	if err := r.DB.GetContext(ctx, &e, `SELECT * FROM data_exports WHERE id=$1 AND user_id=$2;`, id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ClaimNext забирает самую старую выгрузку из очереди. SKIP LOCKED позволяет
+// нескольким экземплярам сервиса разбирать очередь без двойной работы.
+// Возвращает nil, если очередь пуста.
+func (r *ExportRepo) ClaimNext(ctx context.Context) (*export_model.Export, error) {
+	var e export_model.Export
+	q := `
+		UPDATE data_exports SET status='running', started_at=NOW()
+		WHERE id = (
+			SELECT id FROM data_exports
+			WHERE user_id IS NOT NULL
+			  AND (status='pending' OR (status='running' AND started_at < $1))
+			ORDER BY created_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING *;`
+	if err := r.DB.GetContext(ctx, &e, q, time.Now().Add(-staleRunningAfter)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *ExportRepo) MarkReady(ctx context.Context, id, storageKey string, size int64, expiresAt time.Time) error {
+	q := `
+		UPDATE data_exports
+		SET status='ready', storage_key=$2, size=$3, expires_at=$4, finished_at=NOW(), error=NULL
+		WHERE id=$1;`
+	_, err := r.DB.ExecContext(ctx, q, id, storageKey, size, expiresAt)
+	return err
+}
+
+func (r *ExportRepo) MarkFailed(ctx context.Context, id, reason string, expiresAt time.Time) error {
+	q := `UPDATE data_exports SET status='failed', error=$2, expires_at=$3, finished_at=NOW() WHERE id=$1;`
+	_, err := r.DB.ExecContext(ctx, q, id, reason, expiresAt)
+	return err
+}
+
+// GetExpired возвращает выгрузки, срок хранения которых истёк, и выгрузки
+// удалённых аккаунтов.
+func (r *ExportRepo) GetExpired(ctx context.Context, limit int) ([]*export_model.Export, error) {
+	exports := []*export_model.Export{}
+	q := `
+		SELECT * FROM data_exports
+		WHERE expires_at < NOW() OR (user_id IS NULL AND status <> 'running')
+		LIMIT $1;`
+	if err := r.DB.SelectContext(ctx, &exports, q, limit); err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+func (r *ExportRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.DB.ExecContext(ctx, `DELETE FROM data_exports WHERE id=$1;`, id)
+	return err
+}
+
+// exportSections — содержимое архива. Каждый запрос возвращает одно JSON-значение.
+// Пароль, секреты 2FA и хэши токенов в выгрузку не попадают, у писем —
+// только метаданные без тела.
+var exportSections = []struct {
+	name  string
+	query string
+}{
+	{"profile", `
+		SELECT json_build_object(
+			'id', u.id, 'email', u.email, 'created_at', u.created_at,
+			'email_verified_at', u.email_verified_at, 'deletion_requested_at', u.deletion_requested_at,
+			'display_name', p.display_name, 'avatar_url', p.avatar_url, 'timezone', p.timezone, 'locale', p.locale,
+			'preferences', json_build_object(
+				'theme', p.theme, 'default_board_id', p.default_board_id, 'default_folder_id', p.default_folder_id))
+		FROM users u LEFT JOIN user_profiles p ON p.user_id = u.id
+		WHERE u.id=$1`},
+	{"folders", `
+		SELECT COALESCE(json_agg(json_build_object(
+			'id', f.id, 'title', f.title, 'parent_folder_id', f.parent_folder_id, 'position', f.position,
+			'created_at', f.created_at, 'updated_at', f.updated_at) ORDER BY f.id), '[]')
+		FROM notes_folder f WHERE f.user_id=$1`},
+	{"pages", `
+		SELECT COALESCE(json_agg(json_build_object(
+			'id', p.id, 'title', p.title, 'content', p.content, 'folder_id', p.folder_id,
+			'parent_page_id', p.parent_page_id, 'position', p.position, 'is_template', p.is_template,
+			'is_deleted', COALESCE(p.is_deleted, false), 'deleted_at', p.deleted_at,
+			'created_at', p.created_at, 'updated_at', p.updated_at,
+			'tags', (
+				SELECT COALESCE(json_agg(t.name ORDER BY lower(t.name)), '[]')
+				FROM page_tags pt JOIN tags t ON t.id = pt.tag_id WHERE pt.page_id = p.id),
+			'blocks', (
+				SELECT COALESCE(json_agg(json_build_object(
+					'id', b.id, 'type', b.type, 'content', b.content, 'props', b.props, 'position', b.position
+				) ORDER BY b.position, b.id), '[]')
+				FROM page_blocks b WHERE b.page_id = p.id)
+		) ORDER BY p.id), '[]')
+		FROM pages p WHERE p.user_id=$1`},
+	{"boards", `
+		SELECT COALESCE(json_agg(json_build_object(
+			'id', bo.id, 'title', bo.title, 'is_archived', bo.is_archived, 'archived_at', bo.archived_at,
+			'created_at', bo.created_at, 'updated_at', bo.updated_at,
+			'columns', (
+				SELECT COALESCE(json_agg(json_build_object(
+					'id', c.id, 'title', c.column_title, 'position', c.position, 'is_archived', c.is_archived,
+					'wip_limit', c.wip_limit, 'wip_policy', c.wip_policy,
+					'cards', (
+						SELECT COALESCE(json_agg(json_build_object(
+							'id', ca.id, 'content', ca.content, 'position', ca.position, 'is_archived', ca.is_archived,
+							'comments', (
+								SELECT COALESCE(json_agg(json_build_object(
+									'id', cc.id, 'body', cc.body, 'created_at', cc.created_at, 'updated_at', cc.updated_at
+								) ORDER BY cc.created_at), '[]')
+								FROM card_comments cc WHERE cc.card_id = ca.id)
+						) ORDER BY ca.position), '[]')
+						FROM cards ca WHERE ca.column_id = c.id)
+				) ORDER BY c.position), '[]')
+				FROM columns c WHERE c.board_id = bo.id)
+		) ORDER BY bo.created_at), '[]')
+		FROM boards bo WHERE bo.user_id=$1`},
+	{"mail", `
+		SELECT COALESCE(json_agg(json_build_object(
+			'address', ta.address, 'created_at', ta.created_at, 'expires_at', ta.expires_at,
+			'emails', (
+				SELECT COALESCE(json_agg(json_build_object(
+					'id', e.id, 'sender', e.sender, 'recipients', e.recipients,
+					'subject', e.subject, 'received_at', e.received_at
+				) ORDER BY e.received_at), '[]')
+				FROM emails e WHERE e.address_id = ta.id)
+		) ORDER BY ta.created_at), '[]')
+		FROM temp_addresses ta WHERE ta.user_id=$1`},
+	{"sessions", `
+		SELECT COALESCE(json_agg(json_build_object(
+			'id', family_id, 'user_agent', user_agent, 'ip_address', ip_address,
+			'created_at', session_created_at, 'last_used_at', last_used_at, 'expires_at', expires_at
+		) ORDER BY last_used_at DESC), '[]')
+		FROM refresh_tokens WHERE user_id=$1 AND rotated_at IS NULL AND expires_at > NOW()`},
+}
+
+// CollectUserData собирает все разделы выгрузки из одного снимка базы.
+func (r *ExportRepo) CollectUserData(ctx context.Context, userID int) ([]Section, error) {
+	tx, err := r.DB.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sections := make([]Section, 0, len(exportSections))
+	for _, s := range exportSections {
+		var data []byte
+		if err := tx.QueryRowContext(ctx, s.query, userID).Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to collect %s: %w", s.name, err)
+		}
+		sections = append(sections, Section{Name: s.name, Data: data})
+	}
+	return sections, nil
+}
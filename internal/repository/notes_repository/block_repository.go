@@ -0,0 +1,360 @@
+package notes_repository
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrBlockNotFound = errors.New("block not found")
+	ErrPageHasBlocks = errors.New("page is edited with blocks; plain text would lose its structure")
+)
+
+type BlockRepo struct {
+	DB *sqlx.DB
+}
+
+func NewBlockRepo(db *sqlx.DB) *BlockRepo {
+	return &BlockRepo{DB: db}
+}
+
+// BlockUpdate — частичное обновление блока, nil-поля не меняются.
+type BlockUpdate struct {
+	Type    *string
+	Content *string
+	Props   []byte
+}
+
+const blockColumns = `id, page_id, type, content, props, position, created_at, updated_at`
+
+func scanBlock(row rowScanner, b *notes_model.Block) error {
+	return row.Scan(&b.ID, &b.PageID, &b.Type, &b.Content, &b.Props, &b.Position, &b.CreatedAt, &b.UpdatedAt)
+}
+
+var paragraphSplit = regexp.MustCompile(`\r?\n\s*\r?\n`)
+
+// splitParagraphs режет текст на абзацы по пустым строкам так же, как миграция 007.
+func splitParagraphs(text string) []string {
+	var parts []string
+	for _, part := range paragraphSplit.Split(text, -1) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// hasStructuredBlocks сообщает, есть ли на странице блоки, которые текстом
+// без потерь не передать: не абзацы, абзацы со свойствами или с пустыми
+// строками внутри (при разборе текста они распались бы на несколько).
+func hasStructuredBlocks(ctx context.Context, tx *sqlx.Tx, pageID int) (bool, error) {
+	blocks, err := getPageBlocks(ctx, tx, pageID)
+	if err != nil {
+		return false, err
+	}
+	for _, b := range blocks {
+		if b.Type != notes_model.BlockParagraph {
+			return true, nil
+		}
+		if props := strings.TrimSpace(string(b.Props)); props != "" && props != "{}" && props != "null" {
+			return true, nil
+		}
+		if len(splitParagraphs(b.Content)) > 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// replaceBlocksFromText — режим совместимости: текст страницы целиком
+// заменяет её блоки абзацами. Вызывается только для страниц из одних абзацев.
+func replaceBlocksFromText(ctx context.Context, tx *sqlx.Tx, pageID int, text string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM page_blocks WHERE page_id=$1;`, pageID); err != nil {
+		return err
+	}
+	q := `INSERT INTO page_blocks (page_id, type, content, position) VALUES ($1, $2, $3, $4);`
+	for i, part := range splitParagraphs(text) {
+		if _, err := tx.ExecContext(ctx, q, pageID, notes_model.BlockParagraph, part, i+1); err != nil {
+			return fmt.Errorf("failed to insert block: %w", err)
+		}
+	}
+	return nil
+}
+
+func getPageBlocks(ctx context.Context, q sqlx.QueryerContext, pageID int) ([]*notes_model.Block, error) {
+	rows, err := q.QueryContext(ctx, `SELECT `+blockColumns+` FROM page_blocks WHERE page_id=$1 ORDER BY position, id;`, pageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := []*notes_model.Block{}
+	for rows.Next() {
+		var b notes_model.Block
+		if err := scanBlock(rows, &b); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// syncPageContent пересобирает pages.content из блоков, чтобы старые клиенты
+// и все места, читающие content, видели актуальный текст.
+func syncPageContent(ctx context.Context, tx *sqlx.Tx, pageID int) error {
+	blocks, err := getPageBlocks(ctx, tx, pageID)
+	if err != nil {
+		return err
+	}
+	text := RenderBlocksText(blocks)
+	if _, err := tx.ExecContext(ctx, `UPDATE pages SET content=$1, updated_at=NOW() WHERE id=$2;`, text, pageID); err != nil {
+		return err
+	}
+	return replacePageLinks(ctx, tx, pageID, text)
+}
+
+// RenderBlocksText превращает блоки в markdown-подобный текст.
+func RenderBlocksText(blocks []*notes_model.Block) string {
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		props := map[string]any{}
+		if len(b.Props) > 0 {
+			_ = json.Unmarshal(b.Props, &props)
+		}
+		str := func(key string) string {
+			v, _ := props[key].(string)
+			return v
+		}
+
+		switch b.Type {
+		case notes_model.BlockHeading:
+			level := 1
+			if l, ok := props["level"].(float64); ok && l >= 1 && l <= 6 {
+				level = int(l)
+			}
+			parts = append(parts, strings.Repeat("#", level)+" "+b.Content)
+		case notes_model.BlockTodo:
+			mark := " "
+			if checked, _ := props["checked"].(bool); checked {
+				mark = "x"
+			}
+			parts = append(parts, fmt.Sprintf("- [%s] %s", mark, b.Content))
+		case notes_model.BlockCode:
+			parts = append(parts, "```"+str("language")+"\n"+b.Content+"\n```")
+		case notes_model.BlockQuote:
+			parts = append(parts, "> "+strings.ReplaceAll(b.Content, "\n", "\n> "))
+		case notes_model.BlockImage:
+			parts = append(parts, fmt.Sprintf("![%s](%s)", b.Content, str("url")))
+		case notes_model.BlockDivider:
+			parts = append(parts, "---")
+		case notes_model.BlockEmbed:
+			url := str("url")
+			if url == "" {
+				url = b.Content
+			}
+			parts = append(parts, url)
+		default:
+			parts = append(parts, b.Content)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// lockPage проверяет, что страница принадлежит пользователю и не в корзине,
+// и блокирует её строку, чтобы правки блоков одной страницы шли по очереди.
+func lockPage(ctx context.Context, tx *sqlx.Tx, userID, pageID int) error {
+	var id int
+	q := `SELECT id FROM pages WHERE id=$1 AND user_id=$2 AND is_deleted IS NOT TRUE FOR UPDATE;`
+	if err := tx.GetContext(ctx, &id, q, pageID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPageNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *BlockRepo) GetPageBlocks(ctx context.Context, userID, pageID int) ([]*notes_model.Block, error) {
+	var exists bool
+	q := `SELECT EXISTS(SELECT 1 FROM pages WHERE id=$1 AND user_id=$2);`
+	if err := r.DB.GetContext(ctx, &exists, q, pageID, userID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrPageNotFound
+	}
+	return getPageBlocks(ctx, r.DB, pageID)
+}
+
+// InsertBlock вставляет блок на позицию position; позиция вне диапазона — в конец страницы.
+func (r *BlockRepo) InsertBlock(ctx context.Context, userID, pageID int, b *notes_model.Block) (*notes_model.Block, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockPage(ctx, tx, userID, pageID); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM page_blocks WHERE page_id=$1;`, pageID); err != nil {
+		return nil, err
+	}
+	if b.Position < 1 || b.Position > count+1 {
+		b.Position = count + 1
+	}
+
+	qShift := `UPDATE page_blocks SET position=position+1 WHERE page_id=$1 AND position >= $2;`
+	if _, err := tx.ExecContext(ctx, qShift, pageID, b.Position); err != nil {
+		return nil, err
+	}
+
+	props := []byte(b.Props)
+	if len(props) == 0 {
+		props = []byte("{}")
+	}
+	q := `INSERT INTO page_blocks (page_id, type, content, props, position) VALUES ($1, $2, $3, $4, $5) RETURNING ` + blockColumns
+	var created notes_model.Block
+	if err := scanBlock(tx.QueryRowContext(ctx, q, pageID, b.Type, b.Content, props, b.Position), &created); err != nil {
+		return nil, fmt.Errorf("failed to insert block: %w", err)
+	}
+
+	if err := syncPageContent(ctx, tx, pageID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (r *BlockRepo) UpdateBlock(ctx context.Context, userID, pageID, blockID int, upd BlockUpdate) (*notes_model.Block, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockPage(ctx, tx, userID, pageID); err != nil {
+		return nil, err
+	}
+
+	q := `
+		UPDATE page_blocks
+		SET type=COALESCE($1, type), content=COALESCE($2, content), props=COALESCE($3::jsonb, props), updated_at=NOW()
+		WHERE id=$4 AND page_id=$5
+		RETURNING ` + blockColumns
+	var updated notes_model.Block
+	if err := scanBlock(tx.QueryRowContext(ctx, q, upd.Type, upd.Content, upd.Props, blockID, pageID), &updated); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBlockNotFound
+		}
+		return nil, err
+	}
+
+	if err := syncPageContent(ctx, tx, pageID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (r *BlockRepo) MoveBlock(ctx context.Context, userID, pageID, blockID, position int) (*notes_model.Block, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockPage(ctx, tx, userID, pageID); err != nil {
+		return nil, err
+	}
+
+	var oldPosition int
+	qFind := `SELECT position FROM page_blocks WHERE id=$1 AND page_id=$2;`
+	if err := tx.GetContext(ctx, &oldPosition, qFind, blockID, pageID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBlockNotFound
+		}
+		return nil, err
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM page_blocks WHERE page_id=$1;`, pageID); err != nil {
+		return nil, err
+	}
+	if position < 1 || position > count {
+		position = count
+	}
+
+	if position != oldPosition {
+		qShift := `
+			UPDATE page_blocks
+			SET position = CASE WHEN $3 > $2 THEN position-1 ELSE position+1 END
+			WHERE page_id=$1 AND id <> $4
+			  AND position BETWEEN LEAST($2::int, $3::int) AND GREATEST($2::int, $3::int);`
+		if _, err := tx.ExecContext(ctx, qShift, pageID, oldPosition, position, blockID); err != nil {
+			return nil, err
+		}
+	}
+
+	var moved notes_model.Block
+	qMove := `UPDATE page_blocks SET position=$1, updated_at=NOW() WHERE id=$2 RETURNING ` + blockColumns
+	if err := scanBlock(tx.QueryRowContext(ctx, qMove, position, blockID), &moved); err != nil {
+		return nil, err
+	}
+
+	if err := syncPageContent(ctx, tx, pageID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &moved, nil
+}
+
+func (r *BlockRepo) DeleteBlock(ctx context.Context, userID, pageID, blockID int) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockPage(ctx, tx, userID, pageID); err != nil {
+		return err
+	}
+
+	var position int
+	q := `DELETE FROM page_blocks WHERE id=$1 AND page_id=$2 RETURNING position;`
+	if err := tx.GetContext(ctx, &position, q, blockID, pageID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrBlockNotFound
+		}
+		return err
+	}
+
+	qCloseGap := `UPDATE page_blocks SET position=position-1 WHERE page_id=$1 AND position > $2;`
+	if _, err := tx.ExecContext(ctx, qCloseGap, pageID, position); err != nil {
+		return err
+	}
+
+	if err := syncPageContent(ctx, tx, pageID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
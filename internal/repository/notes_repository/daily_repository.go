@@ -0,0 +1,155 @@
+package notes_repository
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type DailyRepo struct {
+	DB *sqlx.DB
+}
+
+func NewDailyRepo(db *sqlx.DB) *DailyRepo {
+	return &DailyRepo{DB: db}
+}
+
+// GetSettings возвращает настройки ежедневных заметок; без сохранённых настроек — значения по умолчанию.
+// Часовой пояс берётся из профиля пользователя.
+func (r *DailyRepo) GetSettings(ctx context.Context, userID int) (*notes_model.DailySettings, error) {
+	q := `SELECT user_id, folder_id, template_page_id, updated_at FROM daily_note_settings WHERE user_id=$1;`
+	s := notes_model.DailySettings{UserID: userID}
+	err := r.DB.QueryRowContext(ctx, q, userID).Scan(&s.UserID, &s.FolderID, &s.TemplatePageID, &s.UpdatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if s.Timezone, err = r.GetTimezone(ctx, userID); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// GetTimezone — часовой пояс из профиля пользователя, по умолчанию UTC.
+func (r *DailyRepo) GetTimezone(ctx context.Context, userID int) (string, error) {
+	var tz string
+	q := `SELECT COALESCE((SELECT timezone FROM user_profiles WHERE user_id=$1), 'UTC');`
+	err := r.DB.GetContext(ctx, &tz, q, userID)
+	return tz, err
+}
+
+// SaveSettings проверяет, что папка и шаблон принадлежат пользователю, и сохраняет настройки.
+// Непустой Timezone записывается в профиль пользователя, пустой оставляет пояс как есть.
+func (r *DailyRepo) SaveSettings(ctx context.Context, s *notes_model.DailySettings) (*notes_model.DailySettings, error) {
+	if s.FolderID.Valid {
+		var exists bool
+		q := `SELECT EXISTS(SELECT 1 FROM notes_folder WHERE id=$1 AND user_id=$2);`
+		if err := r.DB.GetContext(ctx, &exists, q, s.FolderID, s.UserID); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrFolderNotFound
+		}
+	}
+	if s.TemplatePageID.Valid {
+		var exists bool
+		q := `SELECT EXISTS(SELECT 1 FROM pages WHERE id=$1 AND user_id=$2 AND is_template AND is_deleted IS NOT TRUE);`
+		if err := r.DB.GetContext(ctx, &exists, q, s.TemplatePageID, s.UserID); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrTemplateNotFound
+		}
+	}
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := `
+		INSERT INTO daily_note_settings (user_id, folder_id, template_page_id, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE
+		SET folder_id = EXCLUDED.folder_id, template_page_id = EXCLUDED.template_page_id, updated_at = NOW()
+		RETURNING updated_at;`
+	if err := tx.GetContext(ctx, &s.UpdatedAt, q, s.UserID, s.FolderID, s.TemplatePageID); err != nil {
+		return nil, err
+	}
+
+	if s.Timezone != "" {
+		qTZ := `
+			INSERT INTO user_profiles (user_id, timezone) VALUES ($1, $2)
+			ON CONFLICT (user_id) DO UPDATE SET timezone = EXCLUDED.timezone, updated_at = NOW();`
+		if _, err := tx.ExecContext(ctx, qTZ, s.UserID, s.Timezone); err != nil {
+			return nil, err
+		}
+	} else {
+		qTZ := `SELECT COALESCE((SELECT timezone FROM user_profiles WHERE user_id=$1), 'UTC');`
+		if err := tx.GetContext(ctx, &s.Timezone, qTZ, s.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetDefaultFolder запоминает папку для ежедневных заметок, если её ещё нет.
+// Возвращает false, если папку успел задать параллельный запрос.
+func (r *DailyRepo) SetDefaultFolder(ctx context.Context, userID, folderID int) (bool, error) {
+	q := `
+		INSERT INTO daily_note_settings (user_id, folder_id) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET folder_id = EXCLUDED.folder_id, updated_at = NOW()
+		WHERE daily_note_settings.folder_id IS NULL;`
+	result, err := r.DB.ExecContext(ctx, q, userID, folderID)
+	if err != nil {
+		return false, err
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// GetDailyPageID ищет страницу ежедневной заметки за дату; удалённая в корзину не считается.
+func (r *DailyRepo) GetDailyPageID(ctx context.Context, userID int, date time.Time) (int, bool, error) {
+	q := `
+		SELECT d.page_id FROM daily_notes d JOIN pages p ON p.id = d.page_id
+		WHERE d.user_id=$1 AND d.note_date=$2 AND p.is_deleted IS NOT TRUE;`
+	var id int
+	err := r.DB.GetContext(ctx, &id, q, userID, date.Format("2006-01-02"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+// ClaimDailyPage привязывает страницу к дате. Если за дату уже есть живая
+// страница (её создал параллельный запрос), возвращает её id.
+func (r *DailyRepo) ClaimDailyPage(ctx context.Context, userID int, date time.Time, pageID int) (int, error) {
+	day := date.Format("2006-01-02")
+	q := `
+		INSERT INTO daily_notes (user_id, note_date, page_id) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, note_date) DO UPDATE SET page_id = EXCLUDED.page_id, created_at = NOW()
+		WHERE NOT EXISTS (
+			SELECT 1 FROM pages p WHERE p.id = daily_notes.page_id AND p.is_deleted IS NOT TRUE
+		)
+		RETURNING page_id;`
+	var claimed int
+	err := r.DB.GetContext(ctx, &claimed, q, userID, day, pageID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = r.DB.GetContext(ctx, &claimed, `SELECT page_id FROM daily_notes WHERE user_id=$1 AND note_date=$2;`, userID, day)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return claimed, nil
+}
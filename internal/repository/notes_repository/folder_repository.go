@@ -3,28 +3,80 @@ package notes_repository
 import (
 	"anemone_notes/internal/model/notes_model"
 	"context"
+	"database/sql"
+	"errors"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrFolderNotFound        = errors.New("folder not found")
+	ErrInvalidParentFolder   = errors.New("parent folder not found or would create a cycle")
+	ErrInvalidDeleteStrategy = errors.New("unknown delete strategy, expected move or trash")
 )
 
 type FolderRepo struct {
 	DB *sqlx.DB
 }
 
+// folderColumns перечисляет колонки notes_folder в порядке, который ожидает scanFolder.
+const folderColumns = `id, user_id, title, updated_at, created_at, parent_folder_id, position`
+
+func scanFolder(row rowScanner, p *notes_model.Folder) error {
+	return row.Scan(&p.ID, &p.UserID, &p.Title, &p.UpdatedAt, &p.CreatedAt, &p.ParentFolderID, &p.Position)
+}
+
+// folderSubtreeCTE выбирает папку $1 вместе со всеми вложенными папками.
+const folderSubtreeCTE = `
+	WITH RECURSIVE folder_subtree AS (
+		SELECT id FROM notes_folder WHERE id = $1
+		UNION
+		SELECT f.id FROM notes_folder f JOIN folder_subtree s ON f.parent_folder_id = s.id
+	)`
+
 func NewFolderRepo(db *sqlx.DB) *FolderRepo {
 	return &FolderRepo{DB: db}
 }
 
+func (r *FolderRepo) checkParentFolder(ctx context.Context, q sqlx.QueryerContext, userID, parentID int) error {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM notes_folder WHERE id=$1 AND user_id=$2);`
+	if err := sqlx.GetContext(ctx, q, &exists, query, parentID, userID); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrInvalidParentFolder
+	}
+	return nil
+}
+
 func (r *FolderRepo) CreateFolder(ctx context.Context, p *notes_model.Folder) (*notes_model.Folder, error) {
-	q := `INSERT INTO notes_folder (user_id, title) VALUES ($1, $2) RETURNING *;`
-	err := r.DB.QueryRowContext(ctx, q, p.UserID, p.Title).Scan(&p.ID, &p.UserID, &p.Title, &p.UpdatedAt, &p.CreatedAt)
-	if err != nil {
+	if p.ParentFolderID.Valid {
+		if err := r.checkParentFolder(ctx, r.DB, p.UserID, int(p.ParentFolderID.Int64)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := insertFolder(ctx, r.DB, p); err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
+func insertFolder(ctx context.Context, q sqlx.QueryerContext, p *notes_model.Folder) error {
+	// Новая папка встаёт последней среди соседей
+	query := `
+		INSERT INTO notes_folder (user_id, title, parent_folder_id, position)
+		VALUES ($1, $2, $3, (
+			SELECT COALESCE(MAX(position), 0) + 1 FROM notes_folder
+			WHERE user_id=$1 AND parent_folder_id IS NOT DISTINCT FROM $3
+		))
+		RETURNING ` + folderColumns
+	return scanFolder(q.QueryRowxContext(ctx, query, p.UserID, p.Title, p.ParentFolderID), p)
+}
+
 func (r *FolderRepo) GetAllFolders(ctx context.Context, id int) ([]*notes_model.Folder, error) {
-	q := `SELECT * FROM notes_folder WHERE user_id=$1`
+	q := `SELECT ` + folderColumns + ` FROM notes_folder WHERE user_id=$1 ORDER BY parent_folder_id NULLS FIRST, position, id`
 	rows, err := r.DB.QueryContext(ctx, q, id)
 	if err != nil {
 		return nil, err
@@ -34,7 +86,7 @@ func (r *FolderRepo) GetAllFolders(ctx context.Context, id int) ([]*notes_model.
 	var folders []*notes_model.Folder
 	for rows.Next() {
 		var p notes_model.Folder
-		if err := rows.Scan(&p.ID, &p.UserID, &p.Title, &p.UpdatedAt, &p.CreatedAt); err != nil {
+		if err := scanFolder(rows, &p); err != nil {
 			return nil, err
 		}
 		folders = append(folders, &p)
@@ -45,10 +97,62 @@ func (r *FolderRepo) GetAllFolders(ctx context.Context, id int) ([]*notes_model.
 	return folders, nil
 }
 
+// GetFolderTree возвращает все папки пользователя деревом вместе с количеством
+// страниц (без удалённых) в каждой папке.
+func (r *FolderRepo) GetFolderTree(ctx context.Context, userID int) ([]*notes_model.FolderNode, error) {
+	q := `
+		SELECT f.id, f.title, f.parent_folder_id, f.position,
+		       (SELECT COUNT(*) FROM pages p WHERE p.folder_id = f.id AND p.is_deleted IS NOT TRUE)
+		FROM notes_folder f
+		WHERE f.user_id=$1
+		ORDER BY f.position, f.id;`
+	rows, err := r.DB.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var all []*notes_model.FolderNode
+	nodes := make(map[int]*notes_model.FolderNode)
+	for rows.Next() {
+		n := &notes_model.FolderNode{Children: []*notes_model.FolderNode{}}
+		if err := rows.Scan(&n.ID, &n.Title, &n.ParentFolderID, &n.Position, &n.PageCount); err != nil {
+			return nil, err
+		}
+		all = append(all, n)
+		nodes[n.ID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Строки отсортированы по позиции, поэтому дети добавляются уже в нужном порядке
+	roots := []*notes_model.FolderNode{}
+	for _, n := range all {
+		if parent, ok := nodes[int(n.ParentFolderID.Int64)]; ok && n.ParentFolderID.Valid {
+			parent.Children = append(parent.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	for _, n := range roots {
+		sumFolderPages(n)
+	}
+	return roots, nil
+}
+
+func sumFolderPages(n *notes_model.FolderNode) int {
+	n.TotalPageCount = n.PageCount
+	for _, c := range n.Children {
+		n.TotalPageCount += sumFolderPages(c)
+	}
+	return n.TotalPageCount
+}
+
 func (r *FolderRepo) UpdateTitleFolder(ctx context.Context, id int, new_title string) (*notes_model.Folder, error) {
-	q := `UPDATE notes_folder SET title=$1, updated_at=NOW() WHERE id=$2 RETURNING *;`
+	q := `UPDATE notes_folder SET title=$1, updated_at=NOW() WHERE id=$2 RETURNING ` + folderColumns
 	var updatedFolder notes_model.Folder
-	err := r.DB.QueryRowContext(ctx, q, new_title, id).Scan(&updatedFolder.ID, &updatedFolder.UserID, &updatedFolder.Title, &updatedFolder.UpdatedAt, &updatedFolder.CreatedAt)
+	err := scanFolder(r.DB.QueryRowContext(ctx, q, new_title, id), &updatedFolder)
 
 	if err != nil {
 		return nil, err
@@ -57,11 +161,159 @@ func (r *FolderRepo) UpdateTitleFolder(ctx context.Context, id int, new_title st
 	return &updatedFolder, nil
 }
 
-func (r *FolderRepo) DeleteFolderByID(ctx context.Context, id int) error {
-	q := `DELETE FROM notes_folder WHERE id=$1`
-	_, err := r.DB.ExecContext(ctx, q, id)
+// MoveFolder переносит папку вместе с вложенными папками и страницами под нового
+// родителя (NULL — на верхний уровень) на позицию position среди соседей.
+func (r *FolderRepo) MoveFolder(ctx context.Context, userID, folderID int, newParentID sql.NullInt64, position int) (*notes_model.Folder, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var oldParentID sql.NullInt64
+	var oldPosition int
+	qFind := `SELECT parent_folder_id, position FROM notes_folder WHERE id=$1 AND user_id=$2 FOR UPDATE;`
+	if err := tx.QueryRowContext(ctx, qFind, folderID, userID).Scan(&oldParentID, &oldPosition); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFolderNotFound
+		}
+		return nil, err
+	}
+
+	if newParentID.Valid {
+		if err := r.checkParentFolder(ctx, tx, userID, int(newParentID.Int64)); err != nil {
+			return nil, err
+		}
+
+		// Нельзя перенести папку внутрь самой себя
+		var inSubtree bool
+		qCycle := folderSubtreeCTE + `
+		SELECT EXISTS(SELECT 1 FROM folder_subtree WHERE id=$2);`
+		if err := tx.GetContext(ctx, &inSubtree, qCycle, folderID, newParentID.Int64); err != nil {
+			return nil, err
+		}
+		if inSubtree {
+			return nil, ErrInvalidParentFolder
+		}
+	}
+
+	qCloseGap := `
+		UPDATE notes_folder SET position=position-1
+		WHERE user_id=$1 AND parent_folder_id IS NOT DISTINCT FROM $2 AND position > $3 AND id <> $4;`
+	if _, err := tx.ExecContext(ctx, qCloseGap, userID, oldParentID, oldPosition, folderID); err != nil {
+		return nil, err
+	}
+
+	var siblings int
+	qCount := `SELECT COUNT(*) FROM notes_folder WHERE user_id=$1 AND parent_folder_id IS NOT DISTINCT FROM $2 AND id <> $3;`
+	if err := tx.GetContext(ctx, &siblings, qCount, userID, newParentID, folderID); err != nil {
+		return nil, err
+	}
+	if position < 1 || position > siblings+1 {
+		position = siblings + 1
+	}
+
+	qOpenGap := `
+		UPDATE notes_folder SET position=position+1
+		WHERE user_id=$1 AND parent_folder_id IS NOT DISTINCT FROM $2 AND position >= $3 AND id <> $4;`
+	if _, err := tx.ExecContext(ctx, qOpenGap, userID, newParentID, position, folderID); err != nil {
+		return nil, err
+	}
+
+	var moved notes_model.Folder
+	qMove := `UPDATE notes_folder SET parent_folder_id=$1, position=$2, updated_at=NOW() WHERE id=$3 RETURNING ` + folderColumns
+	if err := scanFolder(tx.QueryRowContext(ctx, qMove, newParentID, position, folderID), &moved); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &moved, nil
+}
+
+// DeleteFolderByID удаляет папку по выбранной стратегии:
+// move — страницы и вложенные папки переезжают в родительскую папку на место удалённой;
+// trash — страницы всего поддерева уходят в корзину, вложенные папки удаляются.
+func (r *FolderRepo) DeleteFolderByID(ctx context.Context, userID, id int, strategy string) error {
+	if strategy != notes_model.FolderDeleteMove && strategy != notes_model.FolderDeleteTrash {
+		return ErrInvalidDeleteStrategy
+	}
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	return nil
-}
\ No newline at end of file
+	defer tx.Rollback()
+
+	var parentID sql.NullInt64
+	var position int
+	qFind := `SELECT parent_folder_id, position FROM notes_folder WHERE id=$1 AND user_id=$2 FOR UPDATE;`
+	if err := tx.QueryRowContext(ctx, qFind, id, userID).Scan(&parentID, &position); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrFolderNotFound
+		}
+		return err
+	}
+
+	switch strategy {
+	case notes_model.FolderDeleteMove:
+		qPages := `UPDATE pages SET folder_id=$1, updated_at=NOW() WHERE folder_id=$2;`
+		if _, err := tx.ExecContext(ctx, qPages, parentID, id); err != nil {
+			return err
+		}
+
+		var children int
+		if err := tx.GetContext(ctx, &children, `SELECT COUNT(*) FROM notes_folder WHERE parent_folder_id=$1;`, id); err != nil {
+			return err
+		}
+
+		// Освобождаем место под детей среди соседей удалённой папки
+		qShift := `
+			UPDATE notes_folder SET position=position+$1
+			WHERE user_id=$2 AND parent_folder_id IS NOT DISTINCT FROM $3 AND position > $4 AND id <> $5;`
+		if _, err := tx.ExecContext(ctx, qShift, children-1, userID, parentID, position, id); err != nil {
+			return err
+		}
+
+		qChildren := `
+			UPDATE notes_folder f
+			SET parent_folder_id=$1, position=$2 + o.rn - 1, updated_at=NOW()
+			FROM (
+				SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) AS rn
+				FROM notes_folder WHERE parent_folder_id=$3
+			) o
+			WHERE f.id = o.id;`
+		if _, err := tx.ExecContext(ctx, qChildren, parentID, position, id); err != nil {
+			return err
+		}
+
+	case notes_model.FolderDeleteTrash:
+		var pageIDs []int
+		qPages := folderSubtreeCTE + `
+		SELECT id FROM pages WHERE folder_id IN (SELECT id FROM folder_subtree);`
+		if err := tx.SelectContext(ctx, &pageIDs, qPages, id); err != nil {
+			return err
+		}
+
+		qTrash := subtreeCTE + `
+		UPDATE pages SET ` + trashSet + ` WHERE id IN (SELECT id FROM subtree);`
+		if _, err := tx.ExecContext(ctx, qTrash, pq.Array(pageIDs)); err != nil {
+			return err
+		}
+
+		qCloseGap := `
+			UPDATE notes_folder SET position=position-1
+			WHERE user_id=$1 AND parent_folder_id IS NOT DISTINCT FROM $2 AND position > $3;`
+		if _, err := tx.ExecContext(ctx, qCloseGap, userID, parentID, position); err != nil {
+			return err
+		}
+	}
+
+	// Вложенные папки удаляются каскадно, у страниц в корзине folder_id обнуляется
+	if _, err := tx.ExecContext(ctx, `DELETE FROM notes_folder WHERE id=$1;`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
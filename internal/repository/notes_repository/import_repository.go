@@ -0,0 +1,57 @@
+package notes_repository
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ImportNotes создаёт папки и страницы импорта одной транзакцией:
+// либо переносится весь архив, либо ничего.
+func (r *PageRepo) ImportNotes(ctx context.Context, userID int, imp *notes_model.NotesImport) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	folderIDs := make(map[string]int, len(imp.Folders))
+	for _, f := range imp.Folders {
+		folder := &notes_model.Folder{UserID: userID, Title: f.Title, ParentFolderID: keyID(folderIDs, f.ParentKey)}
+		if err := insertFolder(ctx, tx, folder); err != nil {
+			return fmt.Errorf("failed to import folder %s: %w", f.Key, err)
+		}
+		folderIDs[f.Key] = folder.ID
+	}
+
+	pageIDs := make(map[string]int, len(imp.Pages))
+	for _, p := range imp.Pages {
+		page := &notes_model.Page{
+			UserID:       userID,
+			Title:        p.Title,
+			Content:      p.Content,
+			FolderID:     keyID(folderIDs, p.FolderKey),
+			ParentPageID: keyID(pageIDs, p.ParentKey),
+			CreatedAt:    p.CreatedAt,
+			UpdatedAt:    p.UpdatedAt,
+		}
+		if err := insertPage(ctx, tx, page); err != nil {
+			return fmt.Errorf("failed to import page %s: %w", p.Key, err)
+		}
+		pageIDs[p.Key] = page.ID
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("transaction commit failed: %w", err)
+	}
+	return nil
+}
+
+func keyID(ids map[string]int, key string) sql.NullInt64 {
+	id, ok := ids[key]
+	if key == "" || !ok {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(id), Valid: true}
+}
@@ -0,0 +1,228 @@
+package notes_repository
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// [[Название]] или [[Название|подпись]]
+var wikiLink = regexp.MustCompile(`\[\[([^\[\]|\n]+)(\|[^\[\]\n]*)?\]\]`)
+
+// ParseWikiLinks возвращает названия страниц из [[...]] без повторов (без учёта регистра).
+func ParseWikiLinks(text string) []string {
+	var titles []string
+	seen := make(map[string]bool)
+	for _, m := range wikiLink.FindAllStringSubmatch(text, -1) {
+		title := strings.TrimSpace(m[1])
+		key := strings.ToLower(title)
+		if title == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		titles = append(titles, title)
+	}
+	return titles
+}
+
+// rewriteWikiLinks заменяет ссылки на oldTitle ссылками на newTitle, сохраняя подписи.
+func rewriteWikiLinks(text, oldTitle, newTitle string) (string, bool) {
+	changed := false
+	out := wikiLink.ReplaceAllStringFunc(text, func(link string) string {
+		m := wikiLink.FindStringSubmatch(link)
+		if !strings.EqualFold(strings.TrimSpace(m[1]), oldTitle) {
+			return link
+		}
+		changed = true
+		return "[[" + newTitle + m[2] + "]]"
+	})
+	return out, changed
+}
+
+// replacePageLinks пересобирает ссылки страницы по её тексту.
+func replacePageLinks(ctx context.Context, tx *sqlx.Tx, pageID int, text string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM page_links WHERE source_page_id=$1;`, pageID); err != nil {
+		return err
+	}
+	q := `INSERT INTO page_links (source_page_id, target_title) VALUES ($1, $2);`
+	for _, title := range ParseWikiLinks(text) {
+		if _, err := tx.ExecContext(ctx, q, pageID, title); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pageExists(ctx context.Context, q sqlx.QueryerContext, userID, pageID int) error {
+	var exists bool
+	if err := sqlx.GetContext(ctx, q, &exists, `SELECT EXISTS(SELECT 1 FROM pages WHERE id=$1 AND user_id=$2);`, pageID, userID); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrPageNotFound
+	}
+	return nil
+}
+
+// rewriteLinksOnRename переписывает [[oldTitle]] на [[newTitle]] во всех страницах
+// пользователя. Если у пользователя есть другая страница с прежним названием,
+// ссылки ведут и на неё, поэтому их не трогаем.
+func rewriteLinksOnRename(ctx context.Context, tx *sqlx.Tx, userID, pageID int, oldTitle, newTitle string) error {
+	oldTitle, newTitle = strings.TrimSpace(oldTitle), strings.TrimSpace(newTitle)
+	if oldTitle == "" || newTitle == "" || strings.EqualFold(oldTitle, newTitle) {
+		return nil
+	}
+
+	var namesakes bool
+	qNamesakes := `
+		SELECT EXISTS(
+			SELECT 1 FROM pages
+			WHERE user_id=$1 AND id<>$2 AND is_deleted IS NOT TRUE AND lower(btrim(title))=lower($3)
+		);`
+	if err := tx.GetContext(ctx, &namesakes, qNamesakes, userID, pageID, oldTitle); err != nil {
+		return err
+	}
+	if namesakes {
+		return nil
+	}
+
+	var sources []int
+	qSources := `
+		SELECT s.id FROM page_links l JOIN pages s ON s.id = l.source_page_id
+		WHERE s.user_id=$1 AND lower(l.target_title)=lower($2)
+		ORDER BY s.id
+		FOR UPDATE OF s;`
+	if err := tx.SelectContext(ctx, &sources, qSources, userID, oldTitle); err != nil {
+		return err
+	}
+
+	qBlock := `UPDATE page_blocks SET content=$1, updated_at=NOW() WHERE id=$2;`
+	for _, sourceID := range sources {
+		blocks, err := getPageBlocks(ctx, tx, sourceID)
+		if err != nil {
+			return err
+		}
+		for _, b := range blocks {
+			content, changed := rewriteWikiLinks(b.Content, oldTitle, newTitle)
+			if !changed {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, qBlock, content, b.ID); err != nil {
+				return err
+			}
+		}
+		if err := syncPageContent(ctx, tx, sourceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPageLinks возвращает исходящие ссылки страницы; ссылка на отсутствующую
+// или удалённую страницу помечается как битая.
+func (r *PageRepo) GetPageLinks(ctx context.Context, userID, pageID int) ([]*notes_model.PageLink, error) {
+	if err := pageExists(ctx, r.DB, userID, pageID); err != nil {
+		return nil, err
+	}
+
+	q := `
+		SELECT l.target_title, (
+			SELECT MIN(t.id) FROM pages t
+			WHERE t.user_id=$2 AND t.is_deleted IS NOT TRUE AND lower(btrim(t.title))=lower(l.target_title)
+		)
+		FROM page_links l
+		WHERE l.source_page_id=$1
+		ORDER BY lower(l.target_title);`
+	rows, err := r.DB.QueryContext(ctx, q, pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []*notes_model.PageLink{}
+	for rows.Next() {
+		var l notes_model.PageLink
+		if err := rows.Scan(&l.TargetTitle, &l.TargetPageID); err != nil {
+			return nil, err
+		}
+		l.Broken = !l.TargetPageID.Valid
+		links = append(links, &l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// GetBacklinks возвращает страницы вне корзины, в которых есть [[название этой страницы]].
+func (r *PageRepo) GetBacklinks(ctx context.Context, userID, pageID int) ([]*notes_model.Backlink, error) {
+	var title string
+	err := r.DB.GetContext(ctx, &title, `SELECT title FROM pages WHERE id=$1 AND user_id=$2;`, pageID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPageNotFound
+		}
+		return nil, err
+	}
+
+	q := `
+		SELECT s.id, s.title, s.updated_at
+		FROM page_links l JOIN pages s ON s.id = l.source_page_id
+		WHERE s.user_id=$1 AND s.id<>$2 AND s.is_deleted IS NOT TRUE
+		  AND lower(l.target_title)=lower(btrim($3))
+		ORDER BY s.updated_at DESC, s.id;`
+	rows, err := r.DB.QueryContext(ctx, q, userID, pageID, title)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	backlinks := []*notes_model.Backlink{}
+	for rows.Next() {
+		var b notes_model.Backlink
+		if err := rows.Scan(&b.PageID, &b.Title, &b.UpdatedAt); err != nil {
+			return nil, err
+		}
+		backlinks = append(backlinks, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return backlinks, nil
+}
+
+// GetBrokenLinks находит ссылки на страницы, которых нет или которые лежат в корзине.
+func (r *PageRepo) GetBrokenLinks(ctx context.Context, userID int) ([]*notes_model.BrokenLink, error) {
+	q := `
+		SELECT s.id, s.title, l.target_title
+		FROM page_links l JOIN pages s ON s.id = l.source_page_id
+		WHERE s.user_id=$1 AND s.is_deleted IS NOT TRUE
+		  AND NOT EXISTS (
+			SELECT 1 FROM pages t
+			WHERE t.user_id=s.user_id AND t.is_deleted IS NOT TRUE AND lower(btrim(t.title))=lower(l.target_title)
+		  )
+		ORDER BY s.id, lower(l.target_title);`
+	rows, err := r.DB.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	broken := []*notes_model.BrokenLink{}
+	for rows.Next() {
+		var b notes_model.BrokenLink
+		if err := rows.Scan(&b.SourcePageID, &b.SourceTitle, &b.TargetTitle); err != nil {
+			return nil, err
+		}
+		broken = append(broken, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return broken, nil
+}
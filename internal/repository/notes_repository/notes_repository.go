@@ -5,41 +5,159 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 )
 
+var (
+	ErrPageNotFound  = errors.New("page not found")
+	ErrInvalidParent = errors.New("parent page not found or would create a cycle")
+)
+
 type PageRepo struct {
 	DB *sqlx.DB
 }
 
+// pageColumns перечисляет колонки pages в порядке, который ожидает scanPage.
+const pageColumns = `id, user_id, title, content, is_deleted, folder_id, created_at, updated_at, parent_page_id, position, deleted_at, is_template`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPage(row rowScanner, p *notes_model.Page) error {
+	return row.Scan(&p.ID, &p.UserID, &p.Title, &p.Content, &p.IsDeleted, &p.FolderID, &p.CreatedAt, &p.UpdatedAt, &p.ParentPageID, &p.Position, &p.DeletedAt, &p.IsTemplate)
+}
+
 func NewPageRepo(db *sqlx.DB) *PageRepo {
 	return &PageRepo{DB: db}
 }
 
 func (r *PageRepo) CreateNote(ctx context.Context, p *notes_model.Page) (*notes_model.Page, error) {
-	q := `INSERT INTO pages (user_id, title, content) VALUES ($1, $2, $3) RETURNING *;`
-	err := r.DB.QueryRowContext(ctx, q, p.UserID, p.Title, p.Content).Scan(&p.ID, &p.UserID, &p.Title, &p.Content, &p.IsDeleted, &p.FolderID, &p.UpdatedAt, &p.CreatedAt)
+	if p.ParentPageID.Valid {
+		if err := r.checkParent(ctx, r.DB, p.UserID, int(p.ParentPageID.Int64)); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
+
+	if err := insertPage(ctx, tx, p); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	return p, nil
 }
 
+// insertPage создаёт страницу вместе с блоками из её текста. Нулевые
+// CreatedAt/UpdatedAt заменяются текущим временем.
+func insertPage(ctx context.Context, tx *sqlx.Tx, p *notes_model.Page) error {
+	created := sql.NullTime{Time: p.CreatedAt, Valid: !p.CreatedAt.IsZero()}
+	updated := sql.NullTime{Time: p.UpdatedAt, Valid: !p.UpdatedAt.IsZero()}
+
+	// Новая страница встаёт последней среди соседей
+	q := `
+		INSERT INTO pages (user_id, title, content, folder_id, parent_page_id, position, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, (
+			SELECT COALESCE(MAX(position), 0) + 1 FROM pages
+			WHERE user_id=$1 AND parent_page_id IS NOT DISTINCT FROM $5
+		), COALESCE($6, NOW()), COALESCE($7, NOW()))
+		RETURNING ` + pageColumns
+	err := scanPage(tx.QueryRowContext(ctx, q, p.UserID, p.Title, p.Content, p.FolderID, p.ParentPageID, created, updated), p)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceBlocksFromText(ctx, tx, p.ID, p.Content); err != nil {
+		return err
+	}
+	return replacePageLinks(ctx, tx, p.ID, p.Content)
+}
+
 func (r *PageRepo) GetOneNoteByID(ctx context.Context, id int) (*notes_model.Page, error) {
-	q := `SELECT * FROM pages WHERE id=$1;`
+	q := `SELECT ` + pageColumns + ` FROM pages WHERE id=$1;`
 	var p notes_model.Page
-	err := r.DB.QueryRowContext(ctx, q, id).Scan(&p.ID, &p.UserID, &p.Title, &p.Content, &p.IsDeleted, &p.FolderID, &p.UpdatedAt, &p.CreatedAt)
+	err := scanPage(r.DB.QueryRowContext(ctx, q, id), &p)
 	if err != nil {
 		return nil, err
 	}
 	return &p, nil
 }
 
-func (r *PageRepo) GetAll(ctx context.Context, user_id int) ([]*notes_model.Page, error) {
-	q := `SELECT * FROM pages WHERE user_id=$1;`
-	rows, err := r.DB.QueryContext(ctx, q, user_id)
+// GetPageWithBlocks собирает страницу из её блоков.
+func (r *PageRepo) GetPageWithBlocks(ctx context.Context, id int) (*notes_model.PageWithBlocks, error) {
+	p, err := r.GetOneNoteByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	blocks, err := getPageBlocks(ctx, r.DB, id)
+	if err != nil {
+		return nil, err
+	}
+	return &notes_model.PageWithBlocks{Page: p, Blocks: blocks}, nil
+}
+
+// GetUserPagesWithBlocks возвращает все страницы пользователя вне корзины вместе с блоками.
+func (r *PageRepo) GetUserPagesWithBlocks(ctx context.Context, userID int) ([]*notes_model.PageWithBlocks, error) {
+	q := `SELECT ` + pageColumns + ` FROM pages WHERE user_id=$1 AND is_deleted IS NOT TRUE ORDER BY parent_page_id NULLS FIRST, position, id;`
+	rows, err := r.DB.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := []*notes_model.PageWithBlocks{}
+	byID := make(map[int]*notes_model.PageWithBlocks)
+	for rows.Next() {
+		var p notes_model.Page
+		if err := scanPage(rows, &p); err != nil {
+			return nil, err
+		}
+		pw := &notes_model.PageWithBlocks{Page: &p, Blocks: []*notes_model.Block{}}
+		pages = append(pages, pw)
+		byID[p.ID] = pw
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	qBlocks := `
+		SELECT ` + blockColumns + ` FROM page_blocks
+		WHERE page_id IN (SELECT id FROM pages WHERE user_id=$1 AND is_deleted IS NOT TRUE)
+		ORDER BY page_id, position, id;`
+	blockRows, err := r.DB.QueryContext(ctx, qBlocks, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer blockRows.Close()
+
+	for blockRows.Next() {
+		var b notes_model.Block
+		if err := scanBlock(blockRows, &b); err != nil {
+			return nil, err
+		}
+		if pw, ok := byID[b.PageID]; ok {
+			pw.Blocks = append(pw.Blocks, &b)
+		}
+	}
+	if err := blockRows.Err(); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// GetAll возвращает страницы пользователя; страницы из корзины — только при includeDeleted.
+func (r *PageRepo) GetAll(ctx context.Context, user_id int, includeDeleted bool) ([]*notes_model.Page, error) {
+	q := `SELECT ` + pageColumns + ` FROM pages WHERE user_id=$1 AND ($2 OR is_deleted IS NOT TRUE);`
+	rows, err := r.DB.QueryContext(ctx, q, user_id, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -48,7 +166,7 @@ func (r *PageRepo) GetAll(ctx context.Context, user_id int) ([]*notes_model.Page
 	var pages []*notes_model.Page
 	for rows.Next() {
 		var p notes_model.Page
-		if err := rows.Scan(&p.ID, &p.UserID, &p.Title, &p.Content, &p.IsDeleted, &p.FolderID, &p.UpdatedAt, &p.CreatedAt); err != nil {
+		if err := scanPage(rows, &p); err != nil {
 			return nil, err
 		}
 		pages = append(pages, &p)
@@ -59,25 +177,116 @@ func (r *PageRepo) GetAll(ctx context.Context, user_id int) ([]*notes_model.Page
 	return pages, nil
 }
 
-func (r *PageRepo) UpdateTitleByID(ctx context.Context, id int, new_title string) (*notes_model.Page, error) {
-	q := `UPDATE pages SET title=$1, updated_at=NOW() WHERE id=$2 RETURNING *;`
-	var updatedPage notes_model.Page
-	err := r.DB.QueryRowContext(ctx, q, new_title, id).Scan(&updatedPage.ID, &updatedPage.UserID, &updatedPage.Title, &updatedPage.Content, &updatedPage.IsDeleted, &updatedPage.FolderID, &updatedPage.UpdatedAt, &updatedPage.CreatedAt)
+// GetAllByTags фильтрует страницы пользователя по тегам: matchAll требует
+// все теги сразу, иначе достаточно любого из них.
+func (r *PageRepo) GetAllByTags(ctx context.Context, userID int, tagIDs []int, matchAll, includeDeleted bool) ([]*notes_model.Page, error) {
+	required := 1
+	if matchAll {
+		required = len(tagIDs)
+	}
+	q := `
+		SELECT ` + pageColumns + ` FROM pages
+		WHERE user_id=$1 AND ($4 OR is_deleted IS NOT TRUE) AND id IN (
+			SELECT pt.page_id FROM page_tags pt
+			JOIN tags t ON t.id = pt.tag_id AND t.user_id=$1
+			WHERE pt.tag_id = ANY($2)
+			GROUP BY pt.page_id
+			HAVING COUNT(DISTINCT pt.tag_id) >= $3
+		);`
+	rows, err := r.DB.QueryContext(ctx, q, userID, pq.Array(tagIDs), required, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := []*notes_model.Page{}
+	for rows.Next() {
+		var p notes_model.Page
+		if err := scanPage(rows, &p); err != nil {
+			return nil, err
+		}
+		pages = append(pages, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// UpdateTitleByID переименовывает страницу пользователя; rewriteLinks заодно
+// переписывает [[старое название]] в ссылающихся страницах.
+func (r *PageRepo) UpdateTitleByID(ctx context.Context, userID, id int, new_title string, rewriteLinks bool) (*notes_model.Page, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
+	var oldTitle string
+	err = tx.GetContext(ctx, &oldTitle, `SELECT title FROM pages WHERE id=$1 AND user_id=$2 FOR UPDATE;`, id, userID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("record not found")
+			return nil, ErrPageNotFound
 		}
 		return nil, err
+	}
 
+	q := `UPDATE pages SET title=$1, updated_at=NOW() WHERE id=$2 AND user_id=$3 RETURNING ` + pageColumns
+	var updatedPage notes_model.Page
+	if err := scanPage(tx.QueryRowContext(ctx, q, new_title, id, userID), &updatedPage); err != nil {
+		return nil, err
+	}
+
+	if rewriteLinks {
+		if err := rewriteLinksOnRename(ctx, tx, userID, id, oldTitle, new_title); err != nil {
+			return nil, err
+		}
+		// Страница могла ссылаться сама на себя
+		if err := scanPage(tx.QueryRowContext(ctx, `SELECT `+pageColumns+` FROM pages WHERE id=$1;`, id), &updatedPage); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 	return &updatedPage, nil
 }
 
+// UpdateNoteByID — режим совместимости для клиентов без блоков:
+// переданный текст заменяет все блоки страницы абзацами. Если на странице
+// есть заголовки, задачи, код и другие типизированные блоки, запись
+// отклоняется с ErrPageHasBlocks, кроме сохранения без изменений.
 func (r *PageRepo) UpdateNoteByID(ctx context.Context, id int, new_content string) (*notes_model.Page, error) {
-	q := `UPDATE pages SET content=$1, updated_at=NOW() WHERE id=$2 RETURNING *;`
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var current notes_model.Page
+	err = scanPage(tx.QueryRowContext(ctx, `SELECT `+pageColumns+` FROM pages WHERE id=$1 FOR UPDATE;`, id), &current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("record not found")
+		}
+		return nil, err
+	}
+	// Старые клиенты часто сохраняют текст без правок — это не ошибка
+	if current.Content == new_content {
+		return &current, nil
+	}
+	structured, err := hasStructuredBlocks(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if structured {
+		return nil, ErrPageHasBlocks
+	}
+
+	q := `UPDATE pages SET content=$1, updated_at=NOW() WHERE id=$2 RETURNING ` + pageColumns
 	var updatedPage notes_model.Page
-	err := r.DB.QueryRowContext(ctx, q, new_content, id).Scan(&updatedPage.ID, &updatedPage.UserID, &updatedPage.Title, &updatedPage.Content, &updatedPage.IsDeleted, &updatedPage.FolderID, &updatedPage.UpdatedAt, &updatedPage.CreatedAt)
+	err = scanPage(tx.QueryRowContext(ctx, q, new_content, id), &updatedPage)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -85,11 +294,21 @@ func (r *PageRepo) UpdateNoteByID(ctx context.Context, id int, new_content strin
 		}
 		return nil, err
 	}
+
+	if err := replaceBlocksFromText(ctx, tx, id, new_content); err != nil {
+		return nil, err
+	}
+	if err := replacePageLinks(ctx, tx, id, new_content); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 	return &updatedPage, nil
 }
 
 func (r *PageRepo) GetAllNotesFromFolder(ctx context.Context, id int) ([]*notes_model.Page, error) {
-	q := `SELECT * FROM pages WHERE folder_id=$1;`
+	q := `SELECT ` + pageColumns + ` FROM pages WHERE folder_id=$1;`
 	rows, err := r.DB.QueryContext(ctx, q, id)
 	if err != nil {
 		return nil, err
@@ -99,7 +318,7 @@ func (r *PageRepo) GetAllNotesFromFolder(ctx context.Context, id int) ([]*notes_
 	var notes []*notes_model.Page
 	for rows.Next() {
 		var p notes_model.Page
-		if err := rows.Scan(&p.ID, &p.UserID, &p.Title, &p.Content, &p.IsDeleted, &p.FolderID, &p.UpdatedAt, &p.CreatedAt); err != nil {
+		if err := scanPage(rows, &p); err != nil {
 			return nil, err
 		}
 		notes = append(notes, &p)
@@ -112,10 +331,10 @@ func (r *PageRepo) GetAllNotesFromFolder(ctx context.Context, id int) ([]*notes_
 }
 
 func (r *PageRepo) AddNoteToFolder(ctx context.Context, noteID int, folderID int) (*notes_model.Page, error) {
-	q := `UPDATE pages SET folder_id=$1, updated_at=NOW() WHERE id=$2 RETURNING *;`
+	q := `UPDATE pages SET folder_id=$1, updated_at=NOW() WHERE id=$2 RETURNING ` + pageColumns
 	var updatedPage notes_model.Page
 	// TODO: Возвращать помимо фолдер_айди еще и тайтл фолдера
-	err := r.DB.QueryRowContext(ctx, q, folderID, noteID).Scan(&updatedPage.ID, &updatedPage.UserID, &updatedPage.Title, &updatedPage.Content, &updatedPage.IsDeleted, &updatedPage.FolderID, &updatedPage.UpdatedAt, &updatedPage.CreatedAt)
+	err := scanPage(r.DB.QueryRowContext(ctx, q, folderID, noteID), &updatedPage)
 	if err != nil {
 		return nil, err
 	}
@@ -124,35 +343,39 @@ func (r *PageRepo) AddNoteToFolder(ctx context.Context, noteID int, folderID int
 }
 
 func (r *PageRepo) CencelingNoteFromFolder(ctx context.Context, noteID int) (*notes_model.Page, error) {
-	q := `UPDATE pages SET folder_id=NULL, updated_at=NOW() WHERE id=$1 RETURNING *;`
+	q := `UPDATE pages SET folder_id=NULL, updated_at=NOW() WHERE id=$1 RETURNING ` + pageColumns
 	var updatedPage notes_model.Page
-	err := r.DB.QueryRowContext(ctx, q, noteID).Scan(&updatedPage.ID, &updatedPage.UserID, &updatedPage.Title, &updatedPage.Content, &updatedPage.IsDeleted, &updatedPage.FolderID, &updatedPage.UpdatedAt, &updatedPage.CreatedAt)
+	err := scanPage(r.DB.QueryRowContext(ctx, q, noteID), &updatedPage)
 	if err != nil {
 		return nil, err
 	}
 	return &updatedPage, nil
 }
 
+// subtreeCTE выбирает страницы из $1 вместе со всеми их потомками.
+const subtreeCTE = `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM pages WHERE id = ANY($1)
+		UNION
+		SELECT p.id FROM pages p JOIN subtree s ON p.parent_page_id = s.id
+	)`
+
+// trashSet помечает страницы удалёнными. Время удаления уже лежащих
+// в корзине страниц не сдвигается, иначе повторное удаление продлевало бы им срок хранения.
+const trashSet = `is_deleted=true, deleted_at=COALESCE(deleted_at, NOW()), updated_at=NOW()`
+
+// Удаление в корзину каскадно помечает всё поддерево страницы
 func (r *PageRepo) MarkDeletedNote(ctx context.Context, noteID int) error {
-	q := `UPDATE pages SET is_deleted=true, updated_at=NOW() WHERE id=$1;`
-	_, err := r.DB.ExecContext(ctx, q, noteID)
-	if err != nil {
-		return err
-	}
-	return nil
+	return r.MarkDeletedMoreNotes(ctx, []int{noteID})
 }
 
 func (r *PageRepo) UnmarkDeletedNote(ctx context.Context, noteID int) error {
-	q := `UPDATE pages SET is_deleted=false, updated_at=NOW() WHERE id=$1;`
-	_, err := r.DB.ExecContext(ctx, q, noteID)
-	if err != nil {
-		return err
-	}
-	return nil
+	return r.UnmarkDeletedMoreNotes(ctx, []int{noteID})
 }
 
 func (r *PageRepo) MarkDeletedMoreNotes(ctx context.Context, noteIDs []int) error {
-	q := `UPDATE pages SET is_deleted=true, updated_at=NOW() WHERE id=ANY($1);`
+	q := subtreeCTE + `
+	UPDATE pages SET ` + trashSet + ` WHERE id IN (SELECT id FROM subtree);`
 	_, err := r.DB.ExecContext(ctx, q, pq.Array(noteIDs))
 	if err != nil {
 		return err
@@ -160,17 +383,33 @@ func (r *PageRepo) MarkDeletedMoreNotes(ctx context.Context, noteIDs []int) erro
 	return nil
 }
 
+// Восстановление возвращает поддерево целиком. Если родитель страницы
+// всё ещё в корзине, страница поднимается на верхний уровень.
 func (r *PageRepo) UnmarkDeletedMoreNotes(ctx context.Context, noteIDs []int) error {
-	q := `UPDATE pages SET is_deleted=false, updated_at=NOW() WHERE id=ANY($1);`
-	_, err := r.DB.ExecContext(ctx, q, pq.Array(noteIDs))
+	tx, err := r.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+
+	q := subtreeCTE + `
+	UPDATE pages SET is_deleted=false, deleted_at=NULL, updated_at=NOW() WHERE id IN (SELECT id FROM subtree);`
+	if _, err := tx.ExecContext(ctx, q, pq.Array(noteIDs)); err != nil {
+		return err
+	}
+
+	qDetach := `
+		UPDATE pages SET parent_page_id=NULL, updated_at=NOW()
+		WHERE id = ANY($1) AND parent_page_id IN (SELECT id FROM pages WHERE is_deleted=true);`
+	if _, err := tx.ExecContext(ctx, qDetach, pq.Array(noteIDs)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *PageRepo) MarkDeletedAllNotes(ctx context.Context, userID int) error {
-	q := `UPDATE pages SET is_deleted=true, updated_at=NOW() WHERE user_id=$1;`
+	q := `UPDATE pages SET ` + trashSet + ` WHERE user_id=$1;`
 	_, err := r.DB.ExecContext(ctx, q, userID)
 	if err != nil {
 		return err
@@ -179,7 +418,7 @@ func (r *PageRepo) MarkDeletedAllNotes(ctx context.Context, userID int) error {
 }
 
 func (r *PageRepo) UnmarkDeletedAllNotes(ctx context.Context, userID int) error {
-	q := `UPDATE pages SET is_deleted=false, updated_at=NOW() WHERE user_id=$1;`
+	q := `UPDATE pages SET is_deleted=false, deleted_at=NULL, updated_at=NOW() WHERE user_id=$1;`
 	_, err := r.DB.ExecContext(ctx, q, userID)
 	if err != nil {
 		return err
@@ -195,3 +434,216 @@ func (r *PageRepo) DeleteAllMarkNotes(ctx context.Context, userID int) error {
 	}
 	return nil
 }
+
+// GetTrash возвращает страницы пользователя из корзины, свежие первыми.
+func (r *PageRepo) GetTrash(ctx context.Context, userID int) ([]*notes_model.TrashItem, error) {
+	q := `
+		SELECT id, title, folder_id, parent_page_id, deleted_at FROM pages
+		WHERE user_id=$1 AND is_deleted=true
+		ORDER BY deleted_at DESC, id;`
+	rows, err := r.DB.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*notes_model.TrashItem{}
+	for rows.Next() {
+		var t notes_model.TrashItem
+		if err := rows.Scan(&t.ID, &t.Title, &t.FolderID, &t.ParentPageID, &t.DeletedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// PurgeTrashedNote окончательно удаляет страницу из корзины вместе с поддеревом.
+func (r *PageRepo) PurgeTrashedNote(ctx context.Context, userID, noteID int) error {
+	q := `DELETE FROM pages WHERE id=$1 AND user_id=$2 AND is_deleted=true;`
+	result, err := r.DB.ExecContext(ctx, q, noteID, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrPageNotFound
+	}
+	return nil
+}
+
+// PurgeExpiredTrash удаляет страницы, пролежавшие в корзине дольше retentionDays.
+func (r *PageRepo) PurgeExpiredTrash(ctx context.Context, retentionDays int) (int64, error) {
+	q := `DELETE FROM pages WHERE is_deleted=true AND deleted_at < NOW() - make_interval(days => $1);`
+	result, err := r.DB.ExecContext(ctx, q, retentionDays)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge trash: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *PageRepo) checkParent(ctx context.Context, q sqlx.QueryerContext, userID, parentID int) error {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM pages WHERE id=$1 AND user_id=$2 AND is_deleted IS NOT TRUE);`
+	if err := sqlx.GetContext(ctx, q, &exists, query, parentID, userID); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrInvalidParent
+	}
+	return nil
+}
+
+// GetTree возвращает дерево страниц пользователя без удалённых.
+// Если rootID задан, возвращается поддерево этой страницы, иначе все корневые страницы.
+func (r *PageRepo) GetTree(ctx context.Context, userID int, rootID sql.NullInt64) ([]*notes_model.PageNode, error) {
+	q := `
+		WITH RECURSIVE tree AS (
+			SELECT id, title, folder_id, parent_page_id, position, 0 AS depth
+			FROM pages
+			WHERE user_id=$1 AND is_deleted IS NOT TRUE
+			  AND CASE WHEN $2::int IS NULL THEN parent_page_id IS NULL ELSE id = $2 END
+			UNION ALL
+			SELECT p.id, p.title, p.folder_id, p.parent_page_id, p.position, t.depth + 1
+			FROM pages p JOIN tree t ON p.parent_page_id = t.id
+			WHERE p.is_deleted IS NOT TRUE
+		)
+		SELECT id, title, folder_id, parent_page_id, position, depth FROM tree ORDER BY depth, position, id;`
+	rows, err := r.DB.QueryContext(ctx, q, userID, rootID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roots := []*notes_model.PageNode{}
+	nodes := make(map[int]*notes_model.PageNode)
+	for rows.Next() {
+		n := &notes_model.PageNode{Children: []*notes_model.PageNode{}}
+		if err := rows.Scan(&n.ID, &n.Title, &n.FolderID, &n.ParentPageID, &n.Position, &n.Depth); err != nil {
+			return nil, err
+		}
+		nodes[n.ID] = n
+
+		// Строки идут по возрастанию глубины, поэтому родитель уже в карте
+		if parent, ok := nodes[int(n.ParentPageID.Int64)]; ok && n.Depth > 0 {
+			parent.Children = append(parent.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if rootID.Valid && len(roots) == 0 {
+		return nil, ErrPageNotFound
+	}
+	return roots, nil
+}
+
+func (r *PageRepo) GetBreadcrumbs(ctx context.Context, userID, pageID int) ([]*notes_model.Breadcrumb, error) {
+	q := `
+		WITH RECURSIVE ancestors AS (
+			SELECT id, title, parent_page_id, 0 AS depth FROM pages WHERE id=$1 AND user_id=$2
+			UNION ALL
+			SELECT p.id, p.title, p.parent_page_id, a.depth + 1
+			FROM pages p JOIN ancestors a ON p.id = a.parent_page_id
+			WHERE a.depth < 1000
+		)
+		SELECT id, title FROM ancestors ORDER BY depth DESC;`
+	rows, err := r.DB.QueryContext(ctx, q, pageID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var crumbs []*notes_model.Breadcrumb
+	for rows.Next() {
+		var b notes_model.Breadcrumb
+		if err := rows.Scan(&b.ID, &b.Title); err != nil {
+			return nil, err
+		}
+		crumbs = append(crumbs, &b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(crumbs) == 0 {
+		return nil, ErrPageNotFound
+	}
+	return crumbs, nil
+}
+
+// MovePage переносит страницу вместе с поддеревом под нового родителя
+// (NULL — на верхний уровень) на позицию position среди соседей.
+func (r *PageRepo) MovePage(ctx context.Context, userID, pageID int, newParentID sql.NullInt64, position int) (*notes_model.Page, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var oldParentID sql.NullInt64
+	var oldPosition int
+	qFind := `SELECT parent_page_id, position FROM pages WHERE id=$1 AND user_id=$2 FOR UPDATE;`
+	if err := tx.QueryRowContext(ctx, qFind, pageID, userID).Scan(&oldParentID, &oldPosition); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPageNotFound
+		}
+		return nil, err
+	}
+
+	if newParentID.Valid {
+		if err := r.checkParent(ctx, tx, userID, int(newParentID.Int64)); err != nil {
+			return nil, err
+		}
+
+		// Нельзя перенести страницу внутрь собственного поддерева
+		var inSubtree bool
+		qCycle := subtreeCTE + `
+		SELECT EXISTS(SELECT 1 FROM subtree WHERE id=$2);`
+		if err := tx.GetContext(ctx, &inSubtree, qCycle, pq.Array([]int{pageID}), newParentID.Int64); err != nil {
+			return nil, err
+		}
+		if inSubtree {
+			return nil, ErrInvalidParent
+		}
+	}
+
+	qCloseGap := `
+		UPDATE pages SET position=position-1
+		WHERE user_id=$1 AND parent_page_id IS NOT DISTINCT FROM $2 AND position > $3 AND id <> $4;`
+	if _, err := tx.ExecContext(ctx, qCloseGap, userID, oldParentID, oldPosition, pageID); err != nil {
+		return nil, err
+	}
+
+	var siblings int
+	qCount := `SELECT COUNT(*) FROM pages WHERE user_id=$1 AND parent_page_id IS NOT DISTINCT FROM $2 AND id <> $3;`
+	if err := tx.GetContext(ctx, &siblings, qCount, userID, newParentID, pageID); err != nil {
+		return nil, err
+	}
+	if position < 1 || position > siblings+1 {
+		position = siblings + 1
+	}
+
+	qOpenGap := `
+		UPDATE pages SET position=position+1
+		WHERE user_id=$1 AND parent_page_id IS NOT DISTINCT FROM $2 AND position >= $3 AND id <> $4;`
+	if _, err := tx.ExecContext(ctx, qOpenGap, userID, newParentID, position, pageID); err != nil {
+		return nil, err
+	}
+
+	var moved notes_model.Page
+	qMove := `UPDATE pages SET parent_page_id=$1, position=$2, updated_at=NOW() WHERE id=$3 RETURNING ` + pageColumns
+	if err := scanPage(tx.QueryRowContext(ctx, qMove, newParentID, position, pageID), &moved); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &moved, nil
+}
@@ -0,0 +1,235 @@
+package notes_repository
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag with this name already exists")
+)
+
+type TagRepo struct {
+	DB *sqlx.DB
+}
+
+func NewTagRepo(db *sqlx.DB) *TagRepo {
+	return &TagRepo{DB: db}
+}
+
+func scanTag(row rowScanner, t *notes_model.Tag) error {
+	return row.Scan(&t.ID, &t.UserID, &t.Name, &t.CreatedAt)
+}
+
+const tagColumns = `id, user_id, name, created_at`
+
+// tagError переводит нарушение уникальности имени в ErrTagExists.
+func tagError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrTagExists
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTagNotFound
+	}
+	return err
+}
+
+func (r *TagRepo) CreateTag(ctx context.Context, userID int, name string) (*notes_model.Tag, error) {
+	q := `INSERT INTO tags (user_id, name) VALUES ($1, $2) RETURNING ` + tagColumns
+	var t notes_model.Tag
+	if err := scanTag(r.DB.QueryRowContext(ctx, q, userID, name), &t); err != nil {
+		return nil, tagError(err)
+	}
+	return &t, nil
+}
+
+// GetOrCreateTag возвращает тег пользователя с таким именем (без учёта регистра), создавая его при необходимости.
+func (r *TagRepo) GetOrCreateTag(ctx context.Context, userID int, name string) (*notes_model.Tag, error) {
+	q := `
+		INSERT INTO tags (user_id, name) VALUES ($1, $2)
+		ON CONFLICT (user_id, lower(name)) DO UPDATE SET name = tags.name
+		RETURNING ` + tagColumns
+	var t notes_model.Tag
+	if err := scanTag(r.DB.QueryRowContext(ctx, q, userID, name), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TagRepo) RenameTag(ctx context.Context, userID, tagID int, name string) (*notes_model.Tag, error) {
+	q := `UPDATE tags SET name=$1 WHERE id=$2 AND user_id=$3 RETURNING ` + tagColumns
+	var t notes_model.Tag
+	if err := scanTag(r.DB.QueryRowContext(ctx, q, name, tagID, userID), &t); err != nil {
+		return nil, tagError(err)
+	}
+	return &t, nil
+}
+
+// MergeTags переносит все страницы тега sourceID на тег targetID и удаляет исходный тег.
+func (r *TagRepo) MergeTags(ctx context.Context, userID, sourceID, targetID int) (*notes_model.Tag, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var owned int
+	qOwned := `SELECT COUNT(*) FROM tags WHERE id = ANY($1) AND user_id=$2;`
+	if err := tx.GetContext(ctx, &owned, qOwned, pq.Array([]int{sourceID, targetID}), userID); err != nil {
+		return nil, err
+	}
+	if owned != 2 || sourceID == targetID {
+		return nil, ErrTagNotFound
+	}
+
+	qMove := `
+		INSERT INTO page_tags (page_id, tag_id, created_at)
+		SELECT page_id, $2, created_at FROM page_tags WHERE tag_id=$1
+		ON CONFLICT (page_id, tag_id) DO NOTHING;`
+	if _, err := tx.ExecContext(ctx, qMove, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE id=$1;`, sourceID); err != nil {
+		return nil, err
+	}
+
+	var t notes_model.Tag
+	if err := scanTag(tx.QueryRowContext(ctx, `SELECT `+tagColumns+` FROM tags WHERE id=$1;`, targetID), &t); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *TagRepo) DeleteTag(ctx context.Context, userID, tagID int) error {
+	result, err := r.DB.ExecContext(ctx, `DELETE FROM tags WHERE id=$1 AND user_id=$2;`, tagID, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// GetTagCounts возвращает теги пользователя с числом страниц вне корзины.
+// byCount сортирует облако по убыванию популярности, иначе по имени.
+func (r *TagRepo) GetTagCounts(ctx context.Context, userID int, byCount bool) ([]*notes_model.TagCount, error) {
+	order := `lower(t.name)`
+	if byCount {
+		order = `count DESC, lower(t.name)`
+	}
+	q := `
+		SELECT t.id, t.name, COUNT(p.id) AS count
+		FROM tags t
+		LEFT JOIN page_tags pt ON pt.tag_id = t.id
+		LEFT JOIN pages p ON p.id = pt.page_id AND p.is_deleted IS NOT TRUE
+		WHERE t.user_id=$1
+		GROUP BY t.id, t.name
+		ORDER BY ` + order
+	rows, err := r.DB.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*notes_model.TagCount{}
+	for rows.Next() {
+		var t notes_model.TagCount
+		if err := rows.Scan(&t.ID, &t.Name, &t.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *TagRepo) GetPageTags(ctx context.Context, userID, pageID int) ([]*notes_model.Tag, error) {
+	var exists bool
+	if err := r.DB.GetContext(ctx, &exists, `SELECT EXISTS(SELECT 1 FROM pages WHERE id=$1 AND user_id=$2);`, pageID, userID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrPageNotFound
+	}
+
+	q := `
+		SELECT t.id, t.user_id, t.name, t.created_at
+		FROM tags t JOIN page_tags pt ON pt.tag_id = t.id
+		WHERE pt.page_id=$1
+		ORDER BY lower(t.name);`
+	rows, err := r.DB.QueryContext(ctx, q, pageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*notes_model.Tag{}
+	for rows.Next() {
+		var t notes_model.Tag
+		if err := scanTag(rows, &t); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// AttachTag вешает тег на страницу; повторное добавление ничего не меняет.
+func (r *TagRepo) AttachTag(ctx context.Context, userID, pageID, tagID int) error {
+	q := `
+		INSERT INTO page_tags (page_id, tag_id)
+		SELECT p.id, t.id FROM pages p, tags t
+		WHERE p.id=$1 AND p.user_id=$3 AND t.id=$2 AND t.user_id=$3
+		ON CONFLICT (page_id, tag_id) DO NOTHING
+		RETURNING page_id;`
+	var id int
+	err := r.DB.GetContext(ctx, &id, q, pageID, tagID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Либо связь уже есть, либо страница или тег чужие
+		var linked bool
+		qLinked := `
+			SELECT EXISTS(
+				SELECT 1 FROM page_tags pt JOIN pages p ON p.id = pt.page_id
+				WHERE pt.page_id=$1 AND pt.tag_id=$2 AND p.user_id=$3
+			);`
+		if err := r.DB.GetContext(ctx, &linked, qLinked, pageID, tagID, userID); err != nil {
+			return err
+		}
+		if !linked {
+			return ErrTagNotFound
+		}
+		return nil
+	}
+	return err
+}
+
+func (r *TagRepo) DetachTag(ctx context.Context, userID, pageID, tagID int) error {
+	q := `
+		DELETE FROM page_tags pt USING pages p
+		WHERE pt.page_id = p.id AND pt.page_id=$1 AND pt.tag_id=$2 AND p.user_id=$3;`
+	result, err := r.DB.ExecContext(ctx, q, pageID, tagID, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrTagNotFound
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package notes_repository
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+// {{date}}, {{ time }} и т.п.
+var templateVar = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// ExpandTemplate подставляет переменные шаблона; неизвестные остаются как есть.
+func ExpandTemplate(text string, vars map[string]string) string {
+	return templateVar.ReplaceAllStringFunc(text, func(m string) string {
+		name := strings.ToLower(templateVar.FindStringSubmatch(m)[1])
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+func (r *PageRepo) SetTemplate(ctx context.Context, userID, pageID int, isTemplate bool) (*notes_model.Page, error) {
+	q := `UPDATE pages SET is_template=$1, updated_at=NOW() WHERE id=$2 AND user_id=$3 AND is_deleted IS NOT TRUE RETURNING ` + pageColumns
+	var p notes_model.Page
+	if err := scanPage(r.DB.QueryRowContext(ctx, q, isTemplate, pageID, userID), &p); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPageNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PageRepo) GetTemplates(ctx context.Context, userID int) ([]*notes_model.Page, error) {
+	q := `SELECT ` + pageColumns + ` FROM pages WHERE user_id=$1 AND is_template AND is_deleted IS NOT TRUE ORDER BY lower(title), id;`
+	rows, err := r.DB.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := []*notes_model.Page{}
+	for rows.Next() {
+		var p notes_model.Page
+		if err := scanPage(rows, &p); err != nil {
+			return nil, err
+		}
+		pages = append(pages, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// CreateFromTemplate создаёт страницу p копией блоков шаблона с подставленными
+// переменными. Пустой p.Title берётся из названия шаблона; переменная title
+// получает итоговое название страницы.
+func (r *PageRepo) CreateFromTemplate(ctx context.Context, p *notes_model.Page, templateID int, vars map[string]string) (*notes_model.Page, error) {
+	if p.ParentPageID.Valid {
+		if err := r.checkParent(ctx, r.DB, p.UserID, int(p.ParentPageID.Int64)); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var templateTitle string
+	qTemplate := `SELECT title FROM pages WHERE id=$1 AND user_id=$2 AND is_template AND is_deleted IS NOT TRUE;`
+	if err := tx.GetContext(ctx, &templateTitle, qTemplate, templateID, p.UserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+
+	expanded := make(map[string]string, len(vars)+1)
+	for k, v := range vars {
+		expanded[k] = v
+	}
+	if p.Title == "" {
+		expanded["title"] = ""
+		p.Title = strings.TrimSpace(ExpandTemplate(templateTitle, expanded))
+	}
+	expanded["title"] = p.Title
+
+	p.Content = ""
+	if err := insertPage(ctx, tx, p); err != nil {
+		return nil, err
+	}
+
+	blocks, err := getPageBlocks(ctx, tx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	qBlock := `INSERT INTO page_blocks (page_id, type, content, props, position) VALUES ($1, $2, $3, $4, $5);`
+	for _, b := range blocks {
+		if _, err := tx.ExecContext(ctx, qBlock, p.ID, b.Type, ExpandTemplate(b.Content, expanded), []byte(b.Props), b.Position); err != nil {
+			return nil, err
+		}
+	}
+	if err := syncPageContent(ctx, tx, p.ID); err != nil {
+		return nil, err
+	}
+
+	if err := scanPage(tx.QueryRowContext(ctx, `SELECT `+pageColumns+` FROM pages WHERE id=$1;`, p.ID), p); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,233 @@
+package sidebar_repository
+
+import (
+	"anemone_notes/internal/model/sidebar_model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrFavoriteNotFound = errors.New("favorite not found")
+	ErrItemNotFound     = errors.New("page, folder or board not found")
+)
+
+type SidebarRepo struct {
+	DB *sqlx.DB
+}
+
+func NewSidebarRepo(db *sqlx.DB) *SidebarRepo {
+	return &SidebarRepo{DB: db}
+}
+
+// itemTables — колонка-ссылка в favorites/recent_items и таблица элемента для каждого типа.
+var itemTables = map[string]struct{ column, table string }{
+	sidebar_model.ItemPage:   {"page_id", "pages"},
+	sidebar_model.ItemFolder: {"folder_id", "notes_folder"},
+	sidebar_model.ItemBoard:  {"board_id", "boards"},
+}
+
+func itemTable(itemType string) (string, string, error) {
+	t, ok := itemTables[itemType]
+	if !ok {
+		return "", "", fmt.Errorf("unknown item type %q", itemType)
+	}
+	return t.column, t.table, nil
+}
+
+// Удалённые страницы и архивные доски в сайдбаре не показываем,
+// но запись остаётся и вернётся после восстановления.
+const favoriteSelect = `
+	SELECT f.id,
+	       CASE WHEN f.page_id IS NOT NULL THEN 'page'
+	            WHEN f.folder_id IS NOT NULL THEN 'folder'
+	            ELSE 'board' END AS item_type,
+	       COALESCE(f.page_id::text, f.folder_id::text, f.board_id::text) AS item_id,
+	       COALESCE(p.title, nf.title, b.title) AS title,
+	       f.pinned, f.position, f.created_at
+	FROM favorites f
+	LEFT JOIN pages p ON p.id = f.page_id
+	LEFT JOIN notes_folder nf ON nf.id = f.folder_id
+	LEFT JOIN boards b ON b.id = f.board_id`
+
+const visibleItems = `p.is_deleted IS NOT TRUE AND b.is_archived IS NOT TRUE`
+
+func getFavorite(ctx context.Context, q sqlx.QueryerContext, userID, id int) (*sidebar_model.Favorite, error) {
+	var f sidebar_model.Favorite
+	if err := sqlx.GetContext(ctx, q, &f, favoriteSelect+` WHERE f.id=$1 AND f.user_id=$2;`, id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFavoriteNotFound
+		}
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *SidebarRepo) GetFavorites(ctx context.Context, userID int) ([]*sidebar_model.Favorite, error) {
+	items := []*sidebar_model.Favorite{}
+	q := favoriteSelect + ` WHERE f.user_id=$1 AND ` + visibleItems + ` ORDER BY f.pinned DESC, f.position, f.id;`
+	if err := r.DB.SelectContext(ctx, &items, q, userID); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// AddFavorite добавляет элемент пользователя в конец избранного. Повторное
+// добавление не создаёт дубль и может только закрепить элемент.
+func (r *SidebarRepo) AddFavorite(ctx context.Context, userID int, itemType, itemID string, pinned bool) (*sidebar_model.Favorite, error) {
+	column, table, err := itemTable(itemType)
+	if err != nil {
+		return nil, err
+	}
+
+	q := fmt.Sprintf(`
+		INSERT INTO favorites (user_id, %[1]s, pinned, position)
+		SELECT $1, t.id, $3, (SELECT COALESCE(MAX(position), 0) + 1 FROM favorites WHERE user_id=$1)
+		FROM %[2]s t WHERE t.id=$2 AND t.user_id=$1
+		ON CONFLICT (user_id, %[1]s) DO UPDATE SET pinned = favorites.pinned OR EXCLUDED.pinned
+		RETURNING id;`, column, table)
+	var id int
+	if err := r.DB.GetContext(ctx, &id, q, userID, itemID, pinned); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+	return getFavorite(ctx, r.DB, userID, id)
+}
+
+// UpdateFavorite меняет закрепление и/или позицию; соседи сдвигаются.
+func (r *SidebarRepo) UpdateFavorite(ctx context.Context, userID, id int, pinned *bool, position *int) (*sidebar_model.Favorite, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Блокируем всё избранное пользователя, чтобы параллельные перестановки не перемешали позиции
+	var positions []int
+	qLock := `SELECT position FROM favorites WHERE user_id=$1 ORDER BY position FOR UPDATE;`
+	if err := tx.SelectContext(ctx, &positions, qLock, userID); err != nil {
+		return nil, err
+	}
+
+	var oldPosition int
+	err = tx.GetContext(ctx, &oldPosition, `SELECT position FROM favorites WHERE id=$1 AND user_id=$2;`, id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrFavoriteNotFound
+		}
+		return nil, err
+	}
+
+	if pinned != nil {
+		if _, err := tx.ExecContext(ctx, `UPDATE favorites SET pinned=$1 WHERE id=$2;`, *pinned, id); err != nil {
+			return nil, err
+		}
+	}
+
+	if position != nil {
+		newPosition := *position
+		if newPosition < 1 {
+			newPosition = 1
+		}
+		if last := positions[len(positions)-1]; newPosition > last {
+			newPosition = last
+		}
+
+		var qShift string
+		switch {
+		case newPosition < oldPosition:
+			qShift = `UPDATE favorites SET position = position + 1 WHERE user_id=$1 AND position >= $2 AND position < $3;`
+		case newPosition > oldPosition:
+			qShift = `UPDATE favorites SET position = position - 1 WHERE user_id=$1 AND position <= $2 AND position > $3;`
+		}
+		if qShift != "" {
+			if _, err := tx.ExecContext(ctx, qShift, userID, newPosition, oldPosition); err != nil {
+				return nil, err
+			}
+			if _, err := tx.ExecContext(ctx, `UPDATE favorites SET position=$1 WHERE id=$2;`, newPosition, id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	f, err := getFavorite(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (r *SidebarRepo) DeleteFavorite(ctx context.Context, userID, id int) error {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var position int
+	err = tx.GetContext(ctx, &position, `DELETE FROM favorites WHERE id=$1 AND user_id=$2 RETURNING position;`, id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrFavoriteNotFound
+		}
+		return err
+	}
+
+	qShift := `UPDATE favorites SET position = position - 1 WHERE user_id=$1 AND position > $2;`
+	if _, err := tx.ExecContext(ctx, qShift, userID, position); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordView поднимает страницу или доску наверх списка недавних и оставляет
+// у пользователя не больше limit записей. Чужие элементы молча игнорируются.
+func (r *SidebarRepo) RecordView(ctx context.Context, userID int, itemType, itemID string, limit int) error {
+	column, table, err := itemTable(itemType)
+	if err != nil {
+		return err
+	}
+
+	q := fmt.Sprintf(`
+		INSERT INTO recent_items (user_id, %[1]s, viewed_at)
+		SELECT $1, t.id, NOW() FROM %[2]s t WHERE t.id=$2 AND t.user_id=$1
+		ON CONFLICT (user_id, %[1]s) DO UPDATE SET viewed_at = NOW();`, column, table)
+	if _, err := r.DB.ExecContext(ctx, q, userID, itemID); err != nil {
+		return err
+	}
+
+	qTrim := `
+		DELETE FROM recent_items
+		WHERE user_id=$1 AND id NOT IN (
+			SELECT id FROM recent_items WHERE user_id=$1 ORDER BY viewed_at DESC, id DESC LIMIT $2
+		);`
+	_, err = r.DB.ExecContext(ctx, qTrim, userID, limit)
+	return err
+}
+
+func (r *SidebarRepo) GetRecent(ctx context.Context, userID, limit int) ([]*sidebar_model.RecentItem, error) {
+	items := []*sidebar_model.RecentItem{}
+	q := `
+		SELECT CASE WHEN ri.page_id IS NOT NULL THEN 'page' ELSE 'board' END AS item_type,
+		       COALESCE(ri.page_id::text, ri.board_id::text) AS item_id,
+		       COALESCE(p.title, b.title) AS title,
+		       ri.viewed_at
+		FROM recent_items ri
+		LEFT JOIN pages p ON p.id = ri.page_id
+		LEFT JOIN boards b ON b.id = ri.board_id
+		WHERE ri.user_id=$1 AND ` + visibleItems + `
+		ORDER BY ri.viewed_at DESC, ri.id DESC
+		LIMIT $2;`
+	if err := r.DB.SelectContext(ctx, &items, q, userID, limit); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,113 @@
+package trello_repository
+
+import (
+	"anemone_notes/internal/model/trello_model"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	DefaultActivityLimit = 50
+	MaxActivityLimit     = 200
+)
+
+type ActivityRepo struct {
+	DB *sqlx.DB
+}
+
+func NewActivityRepo(db *sqlx.DB) *ActivityRepo {
+	return &ActivityRepo{DB: db}
+}
+
+type activityEntry struct {
+	BoardID  string
+	ColumnID string
+	CardID   string
+	UserID   int
+	Action   string
+	Data     map[string]any
+}
+
+// recordActivity пишет событие в ленту доски в рамках уже открытой транзакции,
+// чтобы запись активности и само изменение фиксировались вместе.
+func recordActivity(ctx context.Context, tx *sqlx.Tx, e activityEntry) error {
+	if e.Data == nil {
+		e.Data = map[string]any{}
+	}
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity data: %w", err)
+	}
+
+	q := `
+        INSERT INTO board_activity (board_id, column_id, card_id, user_id, action, data)
+        VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, NULLIF($4, 0), $5, $6);
+    `
+	_, err = tx.ExecContext(ctx, q, e.BoardID, e.ColumnID, e.CardID, e.UserID, e.Action, data)
+	if err != nil {
+		return fmt.Errorf("failed to record activity %s: %w", e.Action, err)
+	}
+	return nil
+}
+
+func normalizeActivityPage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = DefaultActivityLimit
+	}
+	if limit > MaxActivityLimit {
+		limit = MaxActivityLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func (r *ActivityRepo) GetBoardActivity(ctx context.Context, boardID string, limit, offset int) (*trello_model.ActivityPage, error) {
+	limit, offset = normalizeActivityPage(limit, offset)
+
+	// Берём на одну запись больше, чтобы понять, есть ли следующая страница
+	var items []*trello_model.Activity
+	q := `SELECT * FROM board_activity WHERE board_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3;`
+	if err := r.DB.SelectContext(ctx, &items, q, boardID, limit+1, offset); err != nil {
+		return nil, err
+	}
+
+	return buildActivityPage(items, limit, offset), nil
+}
+
+func (r *ActivityRepo) GetCardActivity(ctx context.Context, columnID, cardID string, limit, offset int) (*trello_model.ActivityPage, error) {
+	limit, offset = normalizeActivityPage(limit, offset)
+
+	var exists bool
+	qCard := `SELECT EXISTS(SELECT 1 FROM cards WHERE id = $1 AND column_id = $2)`
+	if err := r.DB.GetContext(ctx, &exists, qCard, cardID, columnID); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCardNotFound
+	}
+
+	var items []*trello_model.Activity
+	q := `SELECT * FROM board_activity WHERE card_id = $1 ORDER BY id DESC LIMIT $2 OFFSET $3;`
+	if err := r.DB.SelectContext(ctx, &items, q, cardID, limit+1, offset); err != nil {
+		return nil, err
+	}
+
+	return buildActivityPage(items, limit, offset), nil
+}
+
+func buildActivityPage(items []*trello_model.Activity, limit, offset int) *trello_model.ActivityPage {
+	page := &trello_model.ActivityPage{Items: items, Limit: limit, Offset: offset}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.HasMore = true
+	}
+	if page.Items == nil {
+		page.Items = []*trello_model.Activity{}
+	}
+	return page
+}
@@ -6,13 +6,16 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 var (
 	ErrBoardNotFound      = errors.New("board not found")
+	ErrBoardArchived      = errors.New("board is archived")
 	ErrBoardUpdateFailed  = errors.New("board update failed")
 	ErrBoardDeleteFailed  = errors.New("board delete failed")
 	ErrColumnCreateFailed = errors.New("column creation failed")
@@ -34,13 +37,9 @@ func (r *BoardRepo) CreateBoard(ctx context.Context, title string, userID int) (
 	}
 	defer tx.Rollback()
 
-	boardID := uuid.New().String()
-	board := &trello_model.Board{ID: boardID, Title: title, UserID: userID}
-
-	qBoard := `INSERT INTO boards (id, title, user_id) VALUES ($1, $2, $3) RETURNING *;`
-	err = tx.QueryRowxContext(ctx, qBoard, boardID, title, userID).StructScan(board)
+	board, err := insertBoard(ctx, tx, title, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create board: %w", err)
+		return nil, err
 	}
 
 	defaultColumns := []trello_model.DefaultColumnData{
@@ -50,22 +49,15 @@ func (r *BoardRepo) CreateBoard(ctx context.Context, title string, userID int) (
 	}
 
 	for i, colData := range defaultColumns {
-		columnID := uuid.New().String()
-		position := i + 1
-
-		qColumn := `INSERT INTO columns (id, column_title, board_id, position) VALUES ($1, $2, $3, $4);`
-		_, err = tx.ExecContext(ctx, qColumn, columnID, colData.Title, boardID, position)
-		if err != nil {
-			return nil, fmt.Errorf("%w: failed to create column: %v", ErrColumnCreateFailed, err)
+		column := &trello_model.Column{Title: colData.Title, BoardID: board.ID, Position: i + 1}
+		if err := insertColumn(ctx, tx, column); err != nil {
+			return nil, err
 		}
 
 		for j, cardContent := range colData.Cards {
-			cardID := uuid.New().String()
-			cardPosition := j + 1
-			qCard := `INSERT INTO cards (id, content, column_id, position) VALUES ($1, $2, $3, $4);`
-			_, err = tx.ExecContext(ctx, qCard, cardID, cardContent, columnID, cardPosition)
-			if err != nil {
-				return nil, fmt.Errorf("%w: failed to create card: %v", ErrCardCreateFailed, err)
+			card := &trello_model.Card{Content: cardContent, ColumnID: column.ID, Position: j + 1}
+			if err := insertCard(ctx, tx, card); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -77,9 +69,20 @@ func (r *BoardRepo) CreateBoard(ctx context.Context, title string, userID int) (
 	return board, nil
 }
 
+// GetOneUserBoard отдаёт активную доску; архивная доступна только через
+// список архива и экспорт.
 func (r *BoardRepo) GetOneUserBoard(ctx context.Context, boardID string) (*trello_model.BoardWithColumns, error) {
+	return r.getBoard(ctx, boardID, false)
+}
+
+// GetBoardForExport отдаёт доску и из архива, чтобы её можно было выгрузить.
+func (r *BoardRepo) GetBoardForExport(ctx context.Context, boardID string) (*trello_model.BoardWithColumns, error) {
+	return r.getBoard(ctx, boardID, true)
+}
+
+func (r *BoardRepo) getBoard(ctx context.Context, boardID string, includeArchived bool) (*trello_model.BoardWithColumns, error) {
 	var board trello_model.Board
-	err := r.DB.GetContext(ctx, &board, "SELECT * FROM boards WHERE id = $1", boardID)
+	err := r.DB.GetContext(ctx, &board, "SELECT * FROM boards WHERE id = $1 AND (NOT is_archived OR $2)", boardID, includeArchived)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrBoardNotFound
@@ -88,7 +91,7 @@ func (r *BoardRepo) GetOneUserBoard(ctx context.Context, boardID string) (*trell
 	}
 
 	var columns []*trello_model.Column
-	err = r.DB.SelectContext(ctx, &columns, "SELECT id, column_title, position FROM columns WHERE board_id = $1 ORDER BY position", boardID)
+	err = r.DB.SelectContext(ctx, &columns, "SELECT id, column_title, position, wip_limit, wip_policy FROM columns WHERE board_id = $1 AND NOT is_archived ORDER BY position", boardID)
 	if err != nil {
 		return nil, err
 	}
@@ -101,7 +104,7 @@ func (r *BoardRepo) GetOneUserBoard(ctx context.Context, boardID string) (*trell
 			columnMap[col.ID] = col
 		}
 
-		query, args, err := sqlx.In("SELECT id, content, column_id, position FROM cards WHERE column_id IN (?) ORDER BY column_id, position", columnIDs)
+		query, args, err := sqlx.In("SELECT id, content, column_id, position FROM cards WHERE column_id IN (?) AND NOT is_archived ORDER BY column_id, position", columnIDs)
 		if err != nil {
 			return nil, err
 		}
@@ -118,6 +121,11 @@ func (r *BoardRepo) GetOneUserBoard(ctx context.Context, boardID string) (*trell
 				col.Cards = append(col.Cards, card)
 			}
 		}
+
+		for _, col := range columns {
+			col.CardCount = len(col.Cards)
+			col.OverLimit = col.WipLimit != nil && col.CardCount > *col.WipLimit
+		}
 	}
 
 	return &trello_model.BoardWithColumns{
@@ -130,7 +138,7 @@ func (r *BoardRepo) GetOneUserBoard(ctx context.Context, boardID string) (*trell
 func (r *BoardRepo) GetAllUserBoards(ctx context.Context, userID int) ([]*trello_model.Board, error) {
 	var boards []*trello_model.Board
 
-	q := `SELECT * FROM boards WHERE user_id = $1;`
+	q := `SELECT * FROM boards WHERE user_id = $1 AND NOT is_archived;`
 	err := r.DB.SelectContext(ctx, &boards, q, userID)
 	if err != nil {
 		return nil, err
@@ -138,6 +146,120 @@ func (r *BoardRepo) GetAllUserBoards(ctx context.Context, userID int) ([]*trello
 	return boards, nil
 }
 
+func (r *BoardRepo) GetAllArchivedBoards(ctx context.Context, userID int) ([]*trello_model.Board, error) {
+	var boards []*trello_model.Board
+
+	q := `SELECT * FROM boards WHERE user_id = $1 AND is_archived ORDER BY archived_at DESC;`
+	err := r.DB.SelectContext(ctx, &boards, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	return boards, nil
+}
+
+func (r *BoardRepo) ArchiveBoard(ctx context.Context, boardID string) (*trello_model.Board, error) {
+	q := `UPDATE boards SET is_archived = true, archived_at = NOW() WHERE id = $1 AND NOT is_archived RETURNING *;`
+	var board trello_model.Board
+
+	err := r.DB.QueryRowxContext(ctx, q, boardID).StructScan(&board)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBoardNotFound
+		}
+		return nil, err
+	}
+	return &board, nil
+}
+
+func (r *BoardRepo) UnarchiveBoard(ctx context.Context, boardID string) (*trello_model.Board, error) {
+	q := `UPDATE boards SET is_archived = false, archived_at = NULL WHERE id = $1 AND is_archived RETURNING *;`
+	var board trello_model.Board
+
+	err := r.DB.QueryRowxContext(ctx, q, boardID).StructScan(&board)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBoardNotFound
+		}
+		return nil, err
+	}
+	return &board, nil
+}
+
+func (r *BoardRepo) GetArchivedItems(ctx context.Context, boardID string) (*trello_model.ArchivedBoardItems, error) {
+	items := &trello_model.ArchivedBoardItems{
+		BoardID: boardID,
+		Columns: []*trello_model.Column{},
+		Cards:   []*trello_model.Card{},
+	}
+
+	qColumns := `SELECT * FROM columns WHERE board_id = $1 AND is_archived ORDER BY archived_at DESC NULLS LAST;`
+	if err := r.DB.SelectContext(ctx, &items.Columns, qColumns, boardID); err != nil {
+		return nil, err
+	}
+
+	columnMap := make(map[string]*trello_model.Column, len(items.Columns))
+	for _, col := range items.Columns {
+		col.Cards = []*trello_model.Card{}
+		columnMap[col.ID] = col
+	}
+
+	// Карточки архивных колонок отдаём внутри колонки,
+	// а отдельно архивные карточки активных колонок — в общем списке
+	var cards []*trello_model.Card
+	qCards := `
+        SELECT ca.* 
+        FROM cards ca 
+        JOIN columns c ON c.id = ca.column_id 
+        WHERE c.board_id = $1 AND (c.is_archived OR ca.is_archived) 
+        ORDER BY ca.column_id, ca.position;
+    `
+	if err := r.DB.SelectContext(ctx, &cards, qCards, boardID); err != nil {
+		return nil, err
+	}
+
+	for _, card := range cards {
+		if col, ok := columnMap[card.ColumnID]; ok {
+			col.Cards = append(col.Cards, card)
+			continue
+		}
+		items.Cards = append(items.Cards, card)
+	}
+
+	return items, nil
+}
+
+// PurgeArchived окончательно удаляет элементы, которые лежат в архиве дольше retentionDays.
+// Элементы без даты архивации (закрытые в Trello до импорта) не удаляются.
+func (r *BoardRepo) PurgeArchived(ctx context.Context, retentionDays int) (int64, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		`DELETE FROM cards WHERE is_archived AND archived_at IS NOT NULL AND archived_at < NOW() - make_interval(days => $1);`,
+		`DELETE FROM columns WHERE is_archived AND archived_at IS NOT NULL AND archived_at < NOW() - make_interval(days => $1);`,
+		`DELETE FROM boards WHERE is_archived AND archived_at IS NOT NULL AND archived_at < NOW() - make_interval(days => $1);`,
+	}
+
+	var purged int64
+	for _, q := range queries {
+		result, err := tx.ExecContext(ctx, q, retentionDays)
+		if err != nil {
+			return 0, fmt.Errorf("failed to purge archived items: %w", err)
+		}
+		rows, _ := result.RowsAffected()
+		purged += rows
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return purged, nil
+}
+
 func (r *BoardRepo) DeleteBoard(ctx context.Context, boardID string) error {
 	q := `DELETE FROM boards WHERE id = $1 RETURNING id;`
 	result, err := r.DB.ExecContext(ctx, q, boardID)
@@ -157,12 +279,16 @@ func (r *BoardRepo) DeleteBoard(ctx context.Context, boardID string) error {
 }
 
 func (r *BoardRepo) RenameBoard(ctx context.Context, boardID string, newName string) (*trello_model.Board, error) {
-	q := `UPDATE boards SET title = $1, updated_at = NOW() WHERE id = $2 RETURNING *;`
+	q := `UPDATE boards SET title = $1, updated_at = NOW() WHERE id = $2 AND NOT is_archived RETURNING *;`
 	var board trello_model.Board
 
 	err := r.DB.QueryRowxContext(ctx, q, newName, boardID).StructScan(&board)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			var archived bool
+			if err := r.DB.GetContext(ctx, &archived, `SELECT is_archived FROM boards WHERE id = $1;`, boardID); err == nil && archived {
+				return nil, ErrBoardArchived
+			}
 			return nil, ErrBoardNotFound
 		}
 		return nil, err
@@ -182,40 +308,182 @@ func (r *BoardRepo) UpdateBoard(ctx context.Context, boardID string, userID int,
 		}
 	}()
 
-	_, err = tx.ExecContext(ctx, `
-        DELETE FROM cards 
-        WHERE column_id IN (SELECT id FROM columns WHERE board_id = $1);
+	if err = lockActiveBoard(ctx, tx, boardID); err != nil {
+		return err
+	}
+
+	// Текущее состояние активных карточек нужно для ленты активности и для
+	// политики block: она не пускает новые карточки в переполненную колонку
+	// и при массовом сохранении
+	var prevCards []*boardCardState
+	err = tx.SelectContext(ctx, &prevCards, `
+        SELECT ca.id, ca.content, ca.column_id, ca.position, c.column_title
+        FROM cards ca
+        JOIN columns c ON c.id = ca.column_id
+        WHERE c.board_id = $1 AND NOT c.is_archived AND NOT ca.is_archived
+        FOR UPDATE OF ca;
     `, boardID)
 	if err != nil {
-		return fmt.Errorf("%w: failed to delete old cards: %v", ErrBoardUpdateFailed, err)
+		return fmt.Errorf("%w: failed to load cards: %v", ErrBoardUpdateFailed, err)
+	}
+	prevByID := make(map[string]*boardCardState, len(prevCards))
+	prevCountByColumn := make(map[string]int)
+	for _, pc := range prevCards {
+		prevByID[pc.ID] = pc
+		prevCountByColumn[pc.ColumnID]++
 	}
 
-	_, err = tx.ExecContext(ctx, "DELETE FROM columns WHERE board_id = $1", boardID)
+	// Временно уводим позиции колонок и карточек в отрицательные значения,
+	// чтобы новая нумерация не упёрлась в уникальные индексы
+	_, err = tx.ExecContext(ctx, "UPDATE columns SET position = -position WHERE board_id = $1 AND NOT is_archived", boardID)
 	if err != nil {
-		return fmt.Errorf("%w: failed to delete old columns: %v", ErrBoardUpdateFailed, err)
+		return fmt.Errorf("%w: failed to reset column positions: %v", ErrBoardUpdateFailed, err)
+	}
+	_, err = tx.ExecContext(ctx, `
+        UPDATE cards SET position = -position
+        WHERE NOT is_archived
+          AND column_id IN (SELECT id FROM columns WHERE board_id = $1 AND NOT is_archived);
+    `, boardID)
+	if err != nil {
+		return fmt.Errorf("%w: failed to reset card positions: %v", ErrBoardUpdateFailed, err)
 	}
 
+	keepColumnIDs := make([]string, 0, len(boardData))
+	keepCardIDs := []string{}
+	var activity []activityEntry
 	for i, col := range boardData {
 		var columnID string
 		err = tx.GetContext(ctx, &columnID, `
             INSERT INTO columns (id, board_id, column_title, position) 
             VALUES ($1, $2, $3, $4) 
+            ON CONFLICT (id) DO UPDATE 
+                SET column_title = EXCLUDED.column_title, position = EXCLUDED.position 
+                WHERE columns.board_id = EXCLUDED.board_id AND NOT columns.is_archived 
             RETURNING id;
         `, col.ID, boardID, col.Title, i+1)
 
 		if err != nil {
 			return fmt.Errorf("%w: failed to insert column %s: %v", ErrBoardUpdateFailed, col.ID, err)
 		}
+		keepColumnIDs = append(keepColumnIDs, columnID)
+
+		// Карточки, оставшиеся в колонке, сохраняют порядок; перенесёнными
+		// внутри колонки считаем только те, что выпали из этого порядка
+		var stayedPositions []int
+		for _, card := range col.Cards {
+			if prev, ok := prevByID[card.ID]; ok && prev.ColumnID == columnID {
+				stayedPositions = append(stayedPositions, prev.Position)
+			}
+		}
+		reordered := outOfOrder(stayedPositions)
+		stayed := 0
 
 		for j, card := range col.Cards {
-			_, err = tx.ExecContext(ctx, `
-                INSERT INTO cards (id, column_id, content, position) 
-                VALUES ($1, $2, $3, $4);
-            `, card.ID, columnID, card.Content, j+1)
-
+			if card.ID == "" {
+				card.ID = uuid.New().String()
+			}
+			// Карточка обновляется на месте, а не пересоздаётся: иначе каскадом
+			// удалились бы её комментарии и вложения
+			var cardID string
+			err = tx.GetContext(ctx, &cardID, `
+                INSERT INTO cards (id, content, column_id, position)
+                VALUES ($1, $2, $3, $4)
+                ON CONFLICT (id) DO UPDATE
+                    SET content = EXCLUDED.content, column_id = EXCLUDED.column_id, position = EXCLUDED.position
+                    WHERE NOT cards.is_archived
+                      AND cards.column_id IN (SELECT id FROM columns WHERE board_id = $5 AND NOT is_archived)
+                RETURNING id;
+            `, card.ID, card.Content, columnID, j+1, boardID)
 			if err != nil {
-				return fmt.Errorf("%w: failed to insert card %s: %v", ErrBoardUpdateFailed, card.ID, err)
+				return fmt.Errorf("%w: failed to save card %s: %v", ErrBoardUpdateFailed, card.ID, err)
+			}
+			keepCardIDs = append(keepCardIDs, cardID)
+
+			prev, existed := prevByID[cardID]
+			if !existed {
+				activity = append(activity, activityEntry{
+					BoardID: boardID, ColumnID: columnID, CardID: cardID, UserID: userID,
+					Action: trello_model.ActivityCardCreated,
+					Data:   map[string]any{"title": card.Content, "column": col.Title},
+				})
+				continue
+			}
+			if prev.Content != card.Content {
+				activity = append(activity, activityEntry{
+					BoardID: boardID, ColumnID: columnID, CardID: cardID, UserID: userID,
+					Action: trello_model.ActivityCardRenamed,
+					Data:   map[string]any{"from": prev.Content, "to": card.Content},
+				})
+			}
+			moved := prev.ColumnID != columnID
+			if !moved {
+				moved = reordered[stayed]
+				stayed++
+			}
+			if moved {
+				activity = append(activity, activityEntry{
+					BoardID: boardID, ColumnID: columnID, CardID: cardID, UserID: userID,
+					Action: trello_model.ActivityCardMoved,
+					Data: map[string]any{
+						"title":         card.Content,
+						"from":          prev.ColumnTitle,
+						"to":            col.Title,
+						"from_position": prev.Position,
+						"to_position":   j + 1,
+					},
+				})
+			}
+		}
+	}
+
+	// Архивные колонки и карточки не входят в состояние доски от клиента,
+	// поэтому удаляем только активные элементы, которых нет в запросе.
+	// Карточки удаляются до колонок, чтобы перенесённые из удалённой колонки
+	// не ушли вместе с ней
+	_, err = tx.ExecContext(ctx, `
+        DELETE FROM cards 
+        WHERE NOT is_archived 
+          AND column_id IN (SELECT id FROM columns WHERE board_id = $1 AND NOT is_archived)
+          AND NOT (id::text = ANY($2));
+    `, boardID, pq.Array(keepCardIDs))
+	if err != nil {
+		return fmt.Errorf("%w: failed to delete old cards: %v", ErrBoardUpdateFailed, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        DELETE FROM columns 
+        WHERE board_id = $1 AND NOT is_archived AND NOT (id::text = ANY($2));
+    `, boardID, pq.Array(keepColumnIDs))
+	if err != nil {
+		return fmt.Errorf("%w: failed to delete old columns: %v", ErrBoardUpdateFailed, err)
+	}
+
+	var blockedColumns []*trello_model.Column
+	err = tx.SelectContext(ctx, &blockedColumns, `
+        SELECT id, column_title, wip_limit, wip_policy 
+        FROM columns 
+        WHERE board_id = $1 AND NOT is_archived AND wip_policy = $2 AND wip_limit IS NOT NULL;
+    `, boardID, trello_model.WipPolicyBlock)
+	if err != nil {
+		return fmt.Errorf("%w: failed to load WIP limits: %v", ErrBoardUpdateFailed, err)
+	}
+
+	for _, blocked := range blockedColumns {
+		for _, col := range boardData {
+			if col.ID != blocked.ID {
+				continue
 			}
+			count := len(col.Cards)
+			if count > *blocked.WipLimit && count > prevCountByColumn[col.ID] {
+				err = fmt.Errorf("%w: column %q allows at most %d cards", ErrWipLimitReached, blocked.Title, *blocked.WipLimit)
+				return err
+			}
+		}
+	}
+
+	for _, e := range activity {
+		if err = recordActivity(ctx, tx, e); err != nil {
+			return err
 		}
 	}
 
@@ -226,6 +494,46 @@ func (r *BoardRepo) UpdateBoard(ctx context.Context, boardID string, userID int,
 	return nil
 }
 
+type boardCardState struct {
+	ID          string `db:"id"`
+	Content     string `db:"content"`
+	ColumnID    string `db:"column_id"`
+	Position    int    `db:"position"`
+	ColumnTitle string `db:"column_title"`
+}
+
+// outOfOrder отмечает элементы, не входящие в наибольшую возрастающую
+// подпоследовательность: при перетаскивании одной карточки это ровно она,
+// а не все соседи, чьи позиции сдвинулись.
+func outOfOrder(positions []int) []bool {
+	moved := make([]bool, len(positions))
+	if len(positions) == 0 {
+		return moved
+	}
+	// tails[k] — индекс последнего элемента лучшей подпоследовательности длины k+1
+	tails := []int{}
+	parent := make([]int, len(positions))
+	for i, p := range positions {
+		k := sort.Search(len(tails), func(k int) bool { return positions[tails[k]] >= p })
+		parent[i] = -1
+		if k > 0 {
+			parent[i] = tails[k-1]
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+	for i := range moved {
+		moved[i] = true
+	}
+	for i := tails[len(tails)-1]; i >= 0; i = parent[i] {
+		moved[i] = false
+	}
+	return moved
+}
+
 func (r *BoardRepo) GetBoardOwnerID(ctx context.Context, boardID string) (int, error) {
 	var ownerID int
 	query := `SELECT user_id FROM boards WHERE id = $1`
@@ -258,3 +566,201 @@ func (r *BoardRepo) GetBoardOwnerIDByColumnID(ctx context.Context, columnID stri
 	}
 	return ownerID, nil
 }
+
+// CopyBoard создаёт копию доски с активными колонками и, если includeCards, с их карточками.
+// Порядок сохраняется, все идентификаторы генерируются заново.
+func (r *BoardRepo) CopyBoard(ctx context.Context, boardID string, userID int, title string, includeCards bool) (*trello_model.Board, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source trello_model.Board
+	err = tx.GetContext(ctx, &source, "SELECT * FROM boards WHERE id = $1", boardID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrBoardNotFound
+		}
+		return nil, err
+	}
+
+	if title == "" {
+		title = source.Title + " (copy)"
+	}
+
+	board, err := insertBoard(ctx, tx, title, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []*trello_model.Column
+	qColumns := `SELECT * FROM columns WHERE board_id = $1 AND NOT is_archived ORDER BY position;`
+	if err := tx.SelectContext(ctx, &columns, qColumns, boardID); err != nil {
+		return nil, err
+	}
+
+	for i, col := range columns {
+		column := &trello_model.Column{
+			Title:     col.Title,
+			BoardID:   board.ID,
+			Position:  i + 1,
+			WipLimit:  col.WipLimit,
+			WipPolicy: col.WipPolicy,
+		}
+		if err := insertColumn(ctx, tx, column); err != nil {
+			return nil, err
+		}
+
+		if includeCards {
+			if err := copyColumnCards(ctx, tx, col.ID, column.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return board, nil
+}
+
+// ImportBoard создаёт новую доску из готовой структуры в одной транзакции.
+// Архивные колонки и карточки сохраняют признак архива, cardNotes — текст
+// комментария, который нужно добавить к карточке с указанным ID.
+func (r *BoardRepo) ImportBoard(ctx context.Context, userID int, data *trello_model.BoardWithColumns, cardNotes map[string]string) (*trello_model.Board, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	board, err := insertBoard(ctx, tx, data.Title, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, col := range data.Columns {
+		col.ID = ""
+		col.BoardID = board.ID
+		if err := insertColumn(ctx, tx, col); err != nil {
+			return nil, err
+		}
+
+		for _, card := range col.Cards {
+			card.ColumnID = col.ID
+			if err := insertCard(ctx, tx, card); err != nil {
+				return nil, err
+			}
+
+			note, ok := cardNotes[card.ID]
+			if !ok || note == "" {
+				continue
+			}
+			qComment := `INSERT INTO card_comments (card_id, user_id, body) VALUES ($1, $2, $3);`
+			if _, err := tx.ExecContext(ctx, qComment, card.ID, userID, note); err != nil {
+				return nil, fmt.Errorf("failed to import card notes: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return board, nil
+}
+
+// lockActiveBoard проверяет, что доска есть и не в архиве, и до конца
+// транзакции не даёт её заархивировать.
+func lockActiveBoard(ctx context.Context, tx *sqlx.Tx, boardID string) error {
+	var archived bool
+	err := tx.GetContext(ctx, &archived, `SELECT is_archived FROM boards WHERE id = $1 FOR SHARE;`, boardID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrBoardNotFound
+		}
+		return fmt.Errorf("failed to get board: %w", err)
+	}
+	if archived {
+		return ErrBoardArchived
+	}
+	return nil
+}
+
+func insertBoard(ctx context.Context, tx *sqlx.Tx, title string, userID int) (*trello_model.Board, error) {
+	boardID := uuid.New().String()
+	board := &trello_model.Board{}
+
+	qBoard := `INSERT INTO boards (id, title, user_id) VALUES ($1, $2, $3) RETURNING *;`
+	err := tx.QueryRowxContext(ctx, qBoard, boardID, title, userID).StructScan(board)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create board: %w", err)
+	}
+	return board, nil
+}
+
+// insertColumn вставляет колонку; пустой ID заменяется новым UUID.
+// archived_at остаётся пустым: дата архивации импортированной колонки
+// неизвестна, и очистка архива такие колонки не трогает.
+func insertColumn(ctx context.Context, tx *sqlx.Tx, column *trello_model.Column) error {
+	if column.ID == "" {
+		column.ID = uuid.New().String()
+	}
+
+	if column.WipPolicy == "" {
+		column.WipPolicy = trello_model.WipPolicyWarn
+	}
+
+	qColumn := `
+        INSERT INTO columns (id, column_title, board_id, position, wip_limit, wip_policy, is_archived, archived_at) 
+        VALUES ($1, $2, $3, $4, $5, $6, $7, NULL);
+    `
+	_, err := tx.ExecContext(ctx, qColumn, column.ID, column.Title, column.BoardID, column.Position, column.WipLimit, column.WipPolicy, column.IsArchived)
+	if err != nil {
+		return fmt.Errorf("%w: failed to create column: %v", ErrColumnCreateFailed, err)
+	}
+	return nil
+}
+
+// insertCard вставляет карточку; пустой ID заменяется новым UUID.
+// Как и у колонок, archived_at у архивной карточки остаётся пустым.
+func insertCard(ctx context.Context, tx *sqlx.Tx, card *trello_model.Card) error {
+	if card.ID == "" {
+		card.ID = uuid.New().String()
+	}
+
+	qCard := `
+        INSERT INTO cards (id, content, column_id, position, is_archived, archived_at) 
+        VALUES ($1, $2, $3, $4, $5, NULL);
+    `
+	_, err := tx.ExecContext(ctx, qCard, card.ID, card.Content, card.ColumnID, card.Position, card.IsArchived)
+	if err != nil {
+		return fmt.Errorf("%w: failed to create card %s: %v", ErrCardCreateFailed, card.ID, err)
+	}
+	return nil
+}
+
+// copyColumnCards копирует активные карточки колонки в конец другой колонки, сохраняя порядок.
+func copyColumnCards(ctx context.Context, tx *sqlx.Tx, fromColumnID, toColumnID string) error {
+	var cards []*trello_model.Card
+	qCards := `SELECT * FROM cards WHERE column_id = $1 AND NOT is_archived ORDER BY position;`
+	if err := tx.SelectContext(ctx, &cards, qCards, fromColumnID); err != nil {
+		return fmt.Errorf("failed to load cards: %w", err)
+	}
+
+	var lastPosition int
+	qPos := `SELECT COALESCE(MAX(position), 0) FROM cards WHERE column_id = $1 AND NOT is_archived;`
+	if err := tx.GetContext(ctx, &lastPosition, qPos, toColumnID); err != nil {
+		return fmt.Errorf("failed to get max position: %w", err)
+	}
+
+	for i, card := range cards {
+		newCard := &trello_model.Card{Content: card.Content, ColumnID: toColumnID, Position: lastPosition + i + 1}
+		if err := insertCard(ctx, tx, newCard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
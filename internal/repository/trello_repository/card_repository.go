@@ -14,6 +14,7 @@ var (
 	ErrCardNotFound          = errors.New("card not found")
 	ErrCardMoveFailed        = errors.New("card move failed")
 	ErrColumnNotFoundForCard = errors.New("column not found for card operation")
+	ErrWipLimitReached       = errors.New("column WIP limit reached")
 )
 
 type CardRepo struct {
@@ -24,17 +25,104 @@ func NewCardRepo(db *sqlx.DB) *CardRepo {
 	return &CardRepo{DB: db}
 }
 
-func (r *CardRepo) CreateCard(ctx context.Context, columnID, cardTitle string) (*trello_model.Card, error) {
+type cardColumnInfo struct {
+	BoardID       string `db:"board_id"`
+	Title         string `db:"column_title"`
+	Archived      bool   `db:"is_archived"`
+	BoardArchived bool   `db:"board_archived"`
+}
+
+// getCardColumnInfo возвращает колонку для записи в неё карточек. Карточки
+// архивной колонки или доски менять нельзя, пока их не восстановят; доска
+// остаётся заблокированной от архивации до конца транзакции.
+func getCardColumnInfo(ctx context.Context, tx *sqlx.Tx, columnID string) (*cardColumnInfo, error) {
+	var info cardColumnInfo
+	q := `
+        SELECT c.board_id, c.column_title, c.is_archived, b.is_archived AS board_archived
+        FROM columns c
+        JOIN boards b ON b.id = c.board_id
+        WHERE c.id = $1
+        FOR SHARE OF b;
+    `
+	if err := tx.GetContext(ctx, &info, q, columnID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrColumnNotFoundForCard
+		}
+		return nil, fmt.Errorf("failed to get column: %w", err)
+	}
+	if info.BoardArchived {
+		return nil, ErrBoardArchived
+	}
+	if info.Archived {
+		return nil, ErrColumnArchived
+	}
+	return &info, nil
+}
+
+// checkWipLimit блокирует строку колонки до конца транзакции и проверяет,
+// что в неё можно добавить ещё adding карточек при политике block.
+func checkWipLimit(ctx context.Context, tx *sqlx.Tx, columnID string, adding int) error {
+	var column trello_model.Column
+	qColumn := `SELECT id, column_title, wip_limit, wip_policy FROM columns WHERE id = $1 FOR UPDATE;`
+	if err := tx.GetContext(ctx, &column, qColumn, columnID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrColumnNotFoundForCard
+		}
+		return fmt.Errorf("failed to get column WIP limit: %w", err)
+	}
+
+	if column.WipLimit == nil || column.WipPolicy != trello_model.WipPolicyBlock {
+		return nil
+	}
+
+	// Карточка с позицией 0 сейчас переносится и в подсчёт не входит
+	var count int
+	qCount := `SELECT COUNT(*) FROM cards WHERE column_id = $1 AND NOT is_archived AND position > 0;`
+	if err := tx.GetContext(ctx, &count, qCount, columnID); err != nil {
+		return fmt.Errorf("failed to count cards: %w", err)
+	}
+
+	if count+adding > *column.WipLimit {
+		return fmt.Errorf("%w: column %q allows at most %d cards", ErrWipLimitReached, column.Title, *column.WipLimit)
+	}
+	return nil
+}
+
+// shiftCardPositions сдвигает на delta позиции активных карточек колонки, начиная с fromPosition.
+// Сдвиг идёт через отрицательные значения, чтобы уникальный индекс не сработал посреди UPDATE.
+func shiftCardPositions(ctx context.Context, tx *sqlx.Tx, columnID string, fromPosition, delta int) error {
+	qNegate := `UPDATE cards SET position = -(position + $3) WHERE column_id = $1 AND NOT is_archived AND position >= $2;`
+	if _, err := tx.ExecContext(ctx, qNegate, columnID, fromPosition, delta); err != nil {
+		return fmt.Errorf("failed to shift positions: %w", err)
+	}
+
+	qRestore := `UPDATE cards SET position = -position WHERE column_id = $1 AND NOT is_archived AND position < 0;`
+	if _, err := tx.ExecContext(ctx, qRestore, columnID); err != nil {
+		return fmt.Errorf("failed to shift positions: %w", err)
+	}
+	return nil
+}
+
+func (r *CardRepo) CreateCard(ctx context.Context, userID int, columnID, cardTitle string) (*trello_model.Card, error) {
 	tx, err := r.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("could not start transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	column, err := getCardColumnInfo(ctx, tx, columnID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkWipLimit(ctx, tx, columnID, 1); err != nil {
+		return nil, err
+	}
+
 	cardID := uuid.New().String()
 
 	var newPosition int
-	qPos := `SELECT COALESCE(MAX(position), 0) + 1 FROM cards WHERE column_id = $1`
+	qPos := `SELECT COALESCE(MAX(position), 0) + 1 FROM cards WHERE column_id = $1 AND NOT is_archived`
 	err = tx.GetContext(ctx, &newPosition, qPos, columnID)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("failed to get max position: %w", err)
@@ -47,6 +135,18 @@ func (r *CardRepo) CreateCard(ctx context.Context, columnID, cardTitle string) (
 		return nil, fmt.Errorf("failed to insert card: %w", err)
 	}
 
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  column.BoardID,
+		ColumnID: columnID,
+		CardID:   card.ID,
+		UserID:   userID,
+		Action:   trello_model.ActivityCardCreated,
+		Data:     map[string]any{"title": card.Content, "column": column.Title},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("transaction commit failed: %w", err)
 	}
@@ -61,8 +161,12 @@ func (r *CardRepo) DeleteCard(ctx context.Context, columnID, cardID string) erro
 	}
 	defer tx.Rollback()
 
+	if _, err := getCardColumnInfo(ctx, tx, columnID); err != nil {
+		return err
+	}
+
 	var deletedPosition int
-	qFindPos := `SELECT position FROM cards WHERE id = $1 AND column_id = $2 FOR UPDATE;`
+	qFindPos := `SELECT position FROM cards WHERE id = $1 AND column_id = $2 AND NOT is_archived FOR UPDATE;`
 	err = tx.GetContext(ctx, &deletedPosition, qFindPos, cardID, columnID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -80,7 +184,7 @@ func (r *CardRepo) DeleteCard(ctx context.Context, columnID, cardID string) erro
 		return ErrCardNotFound
 	}
 
-	qUpdatePos := `UPDATE cards SET position = position - 1 WHERE column_id = $1 AND position > $2;`
+	qUpdatePos := `UPDATE cards SET position = position - 1 WHERE column_id = $1 AND NOT is_archived AND position > $2;`
 	_, err = tx.ExecContext(ctx, qUpdatePos, columnID, deletedPosition)
 	if err != nil {
 		return fmt.Errorf("failed to update positions: %w", err)
@@ -93,16 +197,335 @@ func (r *CardRepo) DeleteCard(ctx context.Context, columnID, cardID string) erro
 	return nil
 }
 
-func (r *CardRepo) RenameCard(ctx context.Context, columnID, cardID, newName string) (*trello_model.Card, error) {
+func (r *CardRepo) RenameCard(ctx context.Context, userID int, columnID, cardID, newName string) (*trello_model.Card, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	column, err := getCardColumnInfo(ctx, tx, columnID)
+	if err != nil {
+		return nil, err
+	}
+
+	var oldName string
+	qOld := `SELECT content FROM cards WHERE id = $1 AND column_id = $2 FOR UPDATE;`
+	err = tx.GetContext(ctx, &oldName, qOld, cardID, columnID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCardNotFound
+		}
+		return nil, err
+	}
+
 	q := `UPDATE cards SET content = $1 WHERE id = $2 AND column_id = $3 RETURNING *;`
 	var card trello_model.Card
-	err := r.DB.QueryRowxContext(ctx, q, newName, cardID, columnID).StructScan(&card)
+	err = tx.QueryRowxContext(ctx, q, newName, cardID, columnID).StructScan(&card)
+	if err != nil {
+		return nil, err
+	}
 
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  column.BoardID,
+		ColumnID: columnID,
+		CardID:   cardID,
+		UserID:   userID,
+		Action:   trello_model.ActivityCardRenamed,
+		Data:     map[string]any{"from": oldName, "to": newName},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return &card, nil
+}
+
+// MoveCard переносит карточку в колонку toColumnID той же доски на позицию position
+// (1 — начало колонки; значение за пределами колонки ставит карточку в конец).
+func (r *CardRepo) MoveCard(ctx context.Context, userID int, fromColumnID, cardID, toColumnID string, position int) (*trello_model.Card, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldPosition int
+	qFindPos := `SELECT position FROM cards WHERE id = $1 AND column_id = $2 AND NOT is_archived FOR UPDATE;`
+	err = tx.GetContext(ctx, &oldPosition, qFindPos, cardID, fromColumnID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrCardNotFound
 		}
+		return nil, fmt.Errorf("failed to get card position: %w", err)
+	}
+
+	fromColumn, err := getCardColumnInfo(ctx, tx, fromColumnID)
+	if err != nil {
 		return nil, err
 	}
-	return &card, nil
-}
\ No newline at end of file
+
+	var toColumn cardColumnInfo
+	qTarget := `SELECT board_id, column_title FROM columns WHERE id = $1 AND board_id = $2 AND NOT is_archived;`
+	err = tx.GetContext(ctx, &toColumn, qTarget, toColumnID, fromColumn.BoardID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrColumnNotFoundForCard
+		}
+		return nil, fmt.Errorf("%w: %v", ErrCardMoveFailed, err)
+	}
+
+	if toColumnID != fromColumnID {
+		if err := checkWipLimit(ctx, tx, toColumnID, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	// Временно убираем карточку из нумерации (позиция 0 не используется)
+	_, err = tx.ExecContext(ctx, `UPDATE cards SET position = 0 WHERE id = $1;`, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCardMoveFailed, err)
+	}
+
+	if err := shiftCardPositions(ctx, tx, fromColumnID, oldPosition+1, -1); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCardMoveFailed, err)
+	}
+
+	var targetCount int
+	qCount := `SELECT COUNT(*) FROM cards WHERE column_id = $1 AND NOT is_archived AND position > 0;`
+	if err := tx.GetContext(ctx, &targetCount, qCount, toColumnID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCardMoveFailed, err)
+	}
+	if position < 1 || position > targetCount+1 {
+		position = targetCount + 1
+	}
+
+	if err := shiftCardPositions(ctx, tx, toColumnID, position, 1); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCardMoveFailed, err)
+	}
+
+	card := &trello_model.Card{}
+	qMove := `UPDATE cards SET column_id = $1, position = $2 WHERE id = $3 RETURNING *;`
+	err = tx.QueryRowxContext(ctx, qMove, toColumnID, position, cardID).StructScan(card)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCardMoveFailed, err)
+	}
+
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  fromColumn.BoardID,
+		ColumnID: toColumnID,
+		CardID:   cardID,
+		UserID:   userID,
+		Action:   trello_model.ActivityCardMoved,
+		Data: map[string]any{
+			"title":         card.Content,
+			"from":          fromColumn.Title,
+			"to":            toColumn.Title,
+			"from_position": oldPosition,
+			"to_position":   position,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return card, nil
+}
+
+func (r *CardRepo) ArchiveCard(ctx context.Context, userID int, columnID, cardID string) (*trello_model.Card, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	column, err := getCardColumnInfo(ctx, tx, columnID)
+	if err != nil {
+		return nil, err
+	}
+
+	var archivedPosition int
+	qFindPos := `SELECT position FROM cards WHERE id = $1 AND column_id = $2 AND NOT is_archived FOR UPDATE;`
+	err = tx.GetContext(ctx, &archivedPosition, qFindPos, cardID, columnID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCardNotFound
+		}
+		return nil, fmt.Errorf("failed to get card position: %w", err)
+	}
+
+	card := &trello_model.Card{}
+	qArchive := `UPDATE cards SET is_archived = true, archived_at = NOW() WHERE id = $1 AND column_id = $2 RETURNING *;`
+	err = tx.QueryRowxContext(ctx, qArchive, cardID, columnID).StructScan(card)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive card: %w", err)
+	}
+
+	qUpdatePos := `UPDATE cards SET position = position - 1 WHERE column_id = $1 AND NOT is_archived AND position > $2;`
+	_, err = tx.ExecContext(ctx, qUpdatePos, columnID, archivedPosition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update positions: %w", err)
+	}
+
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  column.BoardID,
+		ColumnID: columnID,
+		CardID:   cardID,
+		UserID:   userID,
+		Action:   trello_model.ActivityCardArchived,
+		Data:     map[string]any{"title": card.Content, "column": column.Title},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return card, nil
+}
+
+func (r *CardRepo) UnarchiveCard(ctx context.Context, userID int, columnID, cardID string) (*trello_model.Card, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	column, err := getCardColumnInfo(ctx, tx, columnID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkWipLimit(ctx, tx, columnID, 1); err != nil {
+		return nil, err
+	}
+
+	var newPosition int
+	qPos := `SELECT COALESCE(MAX(position), 0) + 1 FROM cards WHERE column_id = $1 AND NOT is_archived`
+	err = tx.GetContext(ctx, &newPosition, qPos, columnID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get max position: %w", err)
+	}
+
+	card := &trello_model.Card{}
+	qRestore := `
+        UPDATE cards SET is_archived = false, archived_at = NULL, position = $1 
+        WHERE id = $2 AND column_id = $3 AND is_archived 
+        RETURNING *;
+    `
+	err = tx.QueryRowxContext(ctx, qRestore, newPosition, cardID, columnID).StructScan(card)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCardNotFound
+		}
+		return nil, fmt.Errorf("failed to restore card: %w", err)
+	}
+
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  column.BoardID,
+		ColumnID: columnID,
+		CardID:   cardID,
+		UserID:   userID,
+		Action:   trello_model.ActivityCardRestored,
+		Data:     map[string]any{"title": card.Content, "column": column.Title},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return card, nil
+}
+
+// CopyCard копирует карточку в колонку toColumnID (любой доски пользователя) на позицию position;
+// позиция вне диапазона ставит копию в конец колонки.
+func (r *CardRepo) CopyCard(ctx context.Context, userID int, columnID, cardID, toColumnID string, position int) (*trello_model.Card, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source trello_model.Card
+	qSource := `SELECT * FROM cards WHERE id = $1 AND column_id = $2;`
+	err = tx.GetContext(ctx, &source, qSource, cardID, columnID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCardNotFound
+		}
+		return nil, err
+	}
+
+	if toColumnID == "" {
+		toColumnID = columnID
+	}
+
+	var target cardColumnInfo
+	qTarget := `
+        SELECT c.board_id, c.column_title 
+        FROM columns c 
+        JOIN boards b ON b.id = c.board_id 
+        WHERE c.id = $1 AND b.user_id = $2 AND NOT c.is_archived AND NOT b.is_archived
+        FOR SHARE OF b;
+    `
+	err = tx.GetContext(ctx, &target, qTarget, toColumnID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrColumnNotFoundForCard
+		}
+		return nil, err
+	}
+
+	if err := checkWipLimit(ctx, tx, toColumnID, 1); err != nil {
+		return nil, err
+	}
+
+	var targetCount int
+	qCount := `SELECT COUNT(*) FROM cards WHERE column_id = $1 AND NOT is_archived;`
+	if err := tx.GetContext(ctx, &targetCount, qCount, toColumnID); err != nil {
+		return nil, err
+	}
+	if position < 1 || position > targetCount+1 {
+		position = targetCount + 1
+	}
+
+	if err := shiftCardPositions(ctx, tx, toColumnID, position, 1); err != nil {
+		return nil, err
+	}
+
+	card := &trello_model.Card{Content: source.Content, ColumnID: toColumnID, Position: position}
+	if err := insertCard(ctx, tx, card); err != nil {
+		return nil, err
+	}
+
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  target.BoardID,
+		ColumnID: toColumnID,
+		CardID:   card.ID,
+		UserID:   userID,
+		Action:   trello_model.ActivityCardCreated,
+		Data:     map[string]any{"title": card.Content, "column": target.Title, "copied_from": cardID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return card, nil
+}
@@ -12,9 +12,11 @@ import (
 
 var (
 	ErrColumnNotFound    = errors.New("column not found")
+	ErrColumnArchived    = errors.New("column is archived")
 	ErrColumnMoveFailed  = errors.New("column move failed")
 	ErrBoardAccessDenied = errors.New("board not found or access denied")
 	ErrNoColumnsFound    = errors.New("no columns found")
+	ErrInvalidWipLimit   = errors.New("invalid WIP limit or policy")
 )
 
 type ColumnRepo struct {
@@ -25,17 +27,21 @@ func NewColumnRepo(db *sqlx.DB) *ColumnRepo {
 	return &ColumnRepo{DB: db}
 }
 
-func (r *ColumnRepo) CreateColumn(ctx context.Context, boardID, columnTitle string) (*trello_model.Column, error) {
+func (r *ColumnRepo) CreateColumn(ctx context.Context, userID int, boardID, columnTitle string) (*trello_model.Column, error) {
 	tx, err := r.DB.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("could not start transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	if err := lockActiveBoard(ctx, tx, boardID); err != nil {
+		return nil, err
+	}
+
 	columnID := uuid.New().String()
 
 	var newPosition int
-	qPos := `SELECT COALESCE(MAX(position), 0) + 1 AS new_position FROM columns WHERE board_id = $1`
+	qPos := `SELECT COALESCE(MAX(position), 0) + 1 AS new_position FROM columns WHERE board_id = $1 AND NOT is_archived`
 	err = tx.GetContext(ctx, &newPosition, qPos, boardID)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("failed to get max position: %w", err)
@@ -48,6 +54,17 @@ func (r *ColumnRepo) CreateColumn(ctx context.Context, boardID, columnTitle stri
 		return nil, fmt.Errorf("failed to insert column: %w", err)
 	}
 
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  boardID,
+		ColumnID: column.ID,
+		UserID:   userID,
+		Action:   trello_model.ActivityColumnCreated,
+		Data:     map[string]any{"title": column.Title},
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("transaction commit failed: %w", err)
 	}
@@ -62,8 +79,12 @@ func (r *ColumnRepo) DeleteColumn(ctx context.Context, boardID, columnID string)
 	}
 	defer tx.Rollback()
 
+	if err := lockActiveBoard(ctx, tx, boardID); err != nil {
+		return err
+	}
+
 	var deletedPosition int
-	qFindPos := `SELECT position FROM columns WHERE id = $1 AND board_id = $2 FOR UPDATE;` // FOR UPDATE для транзакции
+	qFindPos := `SELECT position FROM columns WHERE id = $1 AND board_id = $2 AND NOT is_archived FOR UPDATE;` // FOR UPDATE для транзакции
 	err = tx.GetContext(ctx, &deletedPosition, qFindPos, columnID, boardID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -81,7 +102,7 @@ func (r *ColumnRepo) DeleteColumn(ctx context.Context, boardID, columnID string)
 		return ErrColumnNotFound
 	}
 
-	qUpdatePos := `UPDATE columns SET position = position - 1 WHERE board_id = $1 AND position > $2;`
+	qUpdatePos := `UPDATE columns SET position = position - 1 WHERE board_id = $1 AND NOT is_archived AND position > $2;`
 	_, err = tx.ExecContext(ctx, qUpdatePos, boardID, deletedPosition)
 	if err != nil {
 		return fmt.Errorf("failed to update positions: %w", err)
@@ -94,16 +115,291 @@ func (r *ColumnRepo) DeleteColumn(ctx context.Context, boardID, columnID string)
 	return nil
 }
 
-func (r *ColumnRepo) RenameColumn(ctx context.Context, boardID, columnID, newName string) (*trello_model.Column, error) {
+func (r *ColumnRepo) RenameColumn(ctx context.Context, userID int, boardID, columnID, newName string) (*trello_model.Column, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveBoard(ctx, tx, boardID); err != nil {
+		return nil, err
+	}
+	if err := lockActiveColumn(ctx, tx, boardID, columnID); err != nil {
+		return nil, err
+	}
+
+	var oldName string
+	qOld := `SELECT column_title FROM columns WHERE id = $1 AND board_id = $2 FOR UPDATE;`
+	err = tx.GetContext(ctx, &oldName, qOld, columnID, boardID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrColumnNotFound
+		}
+		return nil, err
+	}
+
 	q := `UPDATE columns SET column_title = $1 WHERE id = $2 AND board_id = $3 RETURNING *;`
 	var column trello_model.Column
-	err := r.DB.QueryRowxContext(ctx, q, newName, columnID, boardID).StructScan(&column)
+	err = tx.QueryRowxContext(ctx, q, newName, columnID, boardID).StructScan(&column)
+	if err != nil {
+		return nil, err
+	}
 
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  boardID,
+		ColumnID: columnID,
+		UserID:   userID,
+		Action:   trello_model.ActivityColumnRenamed,
+		Data:     map[string]any{"from": oldName, "to": newName},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return &column, nil
+}
+
+func (r *ColumnRepo) ArchiveColumn(ctx context.Context, userID int, boardID, columnID string) (*trello_model.Column, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveBoard(ctx, tx, boardID); err != nil {
+		return nil, err
+	}
+
+	var archivedPosition int
+	qFindPos := `SELECT position FROM columns WHERE id = $1 AND board_id = $2 AND NOT is_archived FOR UPDATE;`
+	err = tx.GetContext(ctx, &archivedPosition, qFindPos, columnID, boardID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrColumnNotFound
 		}
+		return nil, fmt.Errorf("failed to get column position: %w", err)
+	}
+
+	column := &trello_model.Column{}
+	qArchive := `UPDATE columns SET is_archived = true, archived_at = NOW() WHERE id = $1 AND board_id = $2 RETURNING *;`
+	err = tx.QueryRowxContext(ctx, qArchive, columnID, boardID).StructScan(column)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive column: %w", err)
+	}
+
+	qUpdatePos := `UPDATE columns SET position = position - 1 WHERE board_id = $1 AND NOT is_archived AND position > $2;`
+	_, err = tx.ExecContext(ctx, qUpdatePos, boardID, archivedPosition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update positions: %w", err)
+	}
+
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  boardID,
+		ColumnID: columnID,
+		UserID:   userID,
+		Action:   trello_model.ActivityColumnArchived,
+		Data:     map[string]any{"title": column.Title},
+	})
+	if err != nil {
 		return nil, err
 	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return column, nil
+}
+
+func (r *ColumnRepo) UnarchiveColumn(ctx context.Context, userID int, boardID, columnID string) (*trello_model.Column, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveBoard(ctx, tx, boardID); err != nil {
+		return nil, err
+	}
+
+	// Восстановленная колонка встаёт в конец доски
+	var newPosition int
+	qPos := `SELECT COALESCE(MAX(position), 0) + 1 FROM columns WHERE board_id = $1 AND NOT is_archived`
+	err = tx.GetContext(ctx, &newPosition, qPos, boardID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get max position: %w", err)
+	}
+
+	column := &trello_model.Column{}
+	qRestore := `
+        UPDATE columns SET is_archived = false, archived_at = NULL, position = $1 
+        WHERE id = $2 AND board_id = $3 AND is_archived 
+        RETURNING *;
+    `
+	err = tx.QueryRowxContext(ctx, qRestore, newPosition, columnID, boardID).StructScan(column)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrColumnNotFound
+		}
+		return nil, fmt.Errorf("failed to restore column: %w", err)
+	}
+
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  boardID,
+		ColumnID: columnID,
+		UserID:   userID,
+		Action:   trello_model.ActivityColumnRestored,
+		Data:     map[string]any{"title": column.Title},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return column, nil
+}
+
+// CopyColumn копирует колонку с активными карточками в конец доски toBoardID,
+// которая должна принадлежать тому же пользователю.
+func (r *ColumnRepo) CopyColumn(ctx context.Context, userID int, boardID, columnID, toBoardID, title string) (*trello_model.Column, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source trello_model.Column
+	qSource := `SELECT * FROM columns WHERE id = $1 AND board_id = $2 AND NOT is_archived;`
+	err = tx.GetContext(ctx, &source, qSource, columnID, boardID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrColumnNotFound
+		}
+		return nil, err
+	}
+
+	if toBoardID == "" {
+		toBoardID = boardID
+	}
+
+	var ownsTarget bool
+	qOwner := `SELECT EXISTS(SELECT 1 FROM boards WHERE id = $1 AND user_id = $2);`
+	if err := tx.GetContext(ctx, &ownsTarget, qOwner, toBoardID, userID); err != nil {
+		return nil, err
+	}
+	if !ownsTarget {
+		return nil, ErrBoardAccessDenied
+	}
+	if err := lockActiveBoard(ctx, tx, toBoardID); err != nil {
+		return nil, err
+	}
+
+	if title == "" {
+		title = source.Title
+	}
+
+	var newPosition int
+	qPos := `SELECT COALESCE(MAX(position), 0) + 1 FROM columns WHERE board_id = $1 AND NOT is_archived`
+	if err := tx.GetContext(ctx, &newPosition, qPos, toBoardID); err != nil {
+		return nil, fmt.Errorf("failed to get max position: %w", err)
+	}
+
+	column := &trello_model.Column{
+		Title:     title,
+		BoardID:   toBoardID,
+		Position:  newPosition,
+		WipLimit:  source.WipLimit,
+		WipPolicy: source.WipPolicy,
+	}
+	if err := insertColumn(ctx, tx, column); err != nil {
+		return nil, err
+	}
+
+	if err := copyColumnCards(ctx, tx, columnID, column.ID); err != nil {
+		return nil, err
+	}
+
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  toBoardID,
+		ColumnID: column.ID,
+		UserID:   userID,
+		Action:   trello_model.ActivityColumnCreated,
+		Data:     map[string]any{"title": column.Title, "copied_from": columnID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.SelectContext(ctx, &column.Cards, `SELECT * FROM cards WHERE column_id = $1 ORDER BY position;`, column.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return column, nil
+}
+
+// SetWipLimit задаёт WIP-лимит колонки; nil снимает ограничение.
+func (r *ColumnRepo) SetWipLimit(ctx context.Context, boardID, columnID string, limit *int, policy string) (*trello_model.Column, error) {
+	if (limit != nil && *limit <= 0) || (policy != trello_model.WipPolicyWarn && policy != trello_model.WipPolicyBlock) {
+		return nil, ErrInvalidWipLimit
+	}
+
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveBoard(ctx, tx, boardID); err != nil {
+		return nil, err
+	}
+	if err := lockActiveColumn(ctx, tx, boardID, columnID); err != nil {
+		return nil, err
+	}
+
+	q := `UPDATE columns SET wip_limit = $1, wip_policy = $2 WHERE id = $3 AND board_id = $4 RETURNING *;`
+	var column trello_model.Column
+	if err := tx.QueryRowxContext(ctx, q, limit, policy, columnID, boardID).StructScan(&column); err != nil {
+		return nil, err
+	}
+
+	qCount := `SELECT COUNT(*) FROM cards WHERE column_id = $1 AND NOT is_archived;`
+	if err := tx.GetContext(ctx, &column.CardCount, qCount, columnID); err != nil {
+		return nil, err
+	}
+	column.OverLimit = column.WipLimit != nil && column.CardCount > *column.WipLimit
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
 	return &column, nil
 }
+
+// lockActiveColumn блокирует колонку доски до конца транзакции;
+// архивную колонку сначала нужно восстановить.
+func lockActiveColumn(ctx context.Context, tx *sqlx.Tx, boardID, columnID string) error {
+	var archived bool
+	q := `SELECT is_archived FROM columns WHERE id = $1 AND board_id = $2 FOR UPDATE;`
+	if err := tx.GetContext(ctx, &archived, q, columnID, boardID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrColumnNotFound
+		}
+		return fmt.Errorf("failed to get column: %w", err)
+	}
+	if archived {
+		return ErrColumnArchived
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package trello_repository
+
+import (
+	"anemone_notes/internal/model/trello_model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrCommentForbidden = errors.New("only the author can change a comment")
+)
+
+type CommentRepo struct {
+	DB *sqlx.DB
+}
+
+func NewCommentRepo(db *sqlx.DB) *CommentRepo {
+	return &CommentRepo{DB: db}
+}
+
+func (r *CommentRepo) GetCardComments(ctx context.Context, columnID, cardID string) ([]*trello_model.Comment, error) {
+	comments := []*trello_model.Comment{}
+	q := `
+        SELECT cc.*
+        FROM card_comments cc
+        JOIN cards ca ON ca.id = cc.card_id
+        WHERE cc.card_id = $1 AND ca.column_id = $2
+        ORDER BY cc.created_at;
+    `
+	if err := r.DB.SelectContext(ctx, &comments, q, cardID, columnID); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *CommentRepo) CreateComment(ctx context.Context, userID int, columnID, cardID, body string) (*trello_model.Comment, error) {
+	tx, err := r.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var boardID string
+	qCard := `
+        SELECT c.board_id
+        FROM cards ca
+        JOIN columns c ON c.id = ca.column_id
+        WHERE ca.id = $1 AND ca.column_id = $2;
+    `
+	err = tx.GetContext(ctx, &boardID, qCard, cardID, columnID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCardNotFound
+		}
+		return nil, err
+	}
+
+	comment := &trello_model.Comment{}
+	qInsert := `INSERT INTO card_comments (id, card_id, user_id, body) VALUES ($1, $2, $3, $4) RETURNING *;`
+	err = tx.QueryRowxContext(ctx, qInsert, uuid.New().String(), cardID, userID, body).StructScan(comment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert comment: %w", err)
+	}
+
+	err = recordActivity(ctx, tx, activityEntry{
+		BoardID:  boardID,
+		ColumnID: columnID,
+		CardID:   cardID,
+		UserID:   userID,
+		Action:   trello_model.ActivityCommentAdded,
+		Data:     map[string]any{"comment_id": comment.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("transaction commit failed: %w", err)
+	}
+
+	return comment, nil
+}
+
+// getCommentAuthor возвращает автора комментария, заодно проверяя, что комментарий относится к карточке.
+func (r *CommentRepo) getCommentAuthor(ctx context.Context, columnID, cardID, commentID string) (int, error) {
+	var authorID int
+	q := `
+        SELECT cc.user_id
+        FROM card_comments cc
+        JOIN cards ca ON ca.id = cc.card_id
+        WHERE cc.id = $1 AND cc.card_id = $2 AND ca.column_id = $3;
+    `
+	err := r.DB.GetContext(ctx, &authorID, q, commentID, cardID, columnID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrCommentNotFound
+		}
+		return 0, err
+	}
+	return authorID, nil
+}
+
+func (r *CommentRepo) UpdateComment(ctx context.Context, userID int, columnID, cardID, commentID, body string) (*trello_model.Comment, error) {
+	authorID, err := r.getCommentAuthor(ctx, columnID, cardID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if authorID != userID {
+		return nil, ErrCommentForbidden
+	}
+
+	q := `UPDATE card_comments SET body = $1, updated_at = NOW() WHERE id = $2 AND user_id = $3 RETURNING *;`
+	var comment trello_model.Comment
+	err = r.DB.QueryRowxContext(ctx, q, body, commentID, userID).StructScan(&comment)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *CommentRepo) DeleteComment(ctx context.Context, userID int, columnID, cardID, commentID string) error {
+	authorID, err := r.getCommentAuthor(ctx, columnID, cardID, commentID)
+	if err != nil {
+		return err
+	}
+	if authorID != userID {
+		return ErrCommentForbidden
+	}
+
+	q := `DELETE FROM card_comments WHERE id = $1 AND user_id = $2;`
+	result, err := r.DB.ExecContext(ctx, q, commentID, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
+}
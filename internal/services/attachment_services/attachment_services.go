@@ -0,0 +1,270 @@
+package attachment_services
+
+import (
+	"anemone_notes/internal/model/attachment_model"
+	"anemone_notes/internal/repository/attachment_repository"
+	"anemone_notes/internal/storage"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrFileTooLarge     = errors.New("file is too large")
+	ErrEmptyFile        = errors.New("file is empty")
+	ErrMimeNotAllowed   = errors.New("file type is not allowed")
+	ErrInvalidSignature = errors.New("download link is invalid or expired")
+)
+
+// allowedMimeTypes — типы, определяемые по содержимому файла (http.DetectContentType).
+// SVG и HTML сюда намеренно не входят: их нельзя безопасно отдавать inline.
+var allowedMimeTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+	"application/zip": true,
+	"audio/mpeg":      true,
+	"audio/wave":      true,
+	"video/mp4":       true,
+	"video/webm":      true,
+}
+
+// Сирот моложе этого возраста не удаляем, чтобы не мешать идущим загрузкам
+const orphanGracePeriod = time.Hour
+
+type AttachmentService struct {
+	Repo       *attachment_repository.AttachmentRepo
+	Store      storage.Storage
+	MaxBytes   int64
+	SigningKey []byte
+	URLTTL     time.Duration
+}
+
+func NewAttachmentService(r *attachment_repository.AttachmentRepo, s storage.Storage, maxBytes int, signingKey string, urlTTL time.Duration) *AttachmentService {
+	return &AttachmentService{
+		Repo:       r,
+		Store:      s,
+		MaxBytes:   int64(maxBytes),
+		SigningKey: []byte(signingKey),
+		URLTTL:     urlTTL,
+	}
+}
+
+func (s *AttachmentService) UploadToPage(ctx context.Context, userID, pageID int, fileName string, body io.Reader) (*attachment_model.Attachment, error) {
+	ok, err := s.Repo.PageOwned(ctx, userID, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, attachment_repository.ErrParentNotFound
+	}
+	return s.upload(ctx, &attachment_model.Attachment{UserID: userID, PageID: &pageID}, fileName, body)
+}
+
+func (s *AttachmentService) UploadToCard(ctx context.Context, userID int, columnID, cardID, fileName string, body io.Reader) (*attachment_model.Attachment, error) {
+	ok, err := s.Repo.CardInColumn(ctx, columnID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, attachment_repository.ErrParentNotFound
+	}
+	return s.upload(ctx, &attachment_model.Attachment{UserID: userID, CardID: &cardID}, fileName, body)
+}
+
+// upload складывает файл во временный файл, по пути считая sha256 и размер,
+// проверяет тип по содержимому и только потом отдаёт его в хранилище.
+// Одинаковое содержимое хранится один раз.
+func (s *AttachmentService) upload(ctx context.Context, a *attachment_model.Attachment, fileName string, body io.Reader) (*attachment_model.Attachment, error) {
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(body, s.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if size > s.MaxBytes {
+		return nil, ErrFileTooLarge
+	}
+	if size == 0 {
+		return nil, ErrEmptyFile
+	}
+
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	mimeType := http.DetectContentType(head[:n])
+	if base, _, _ := strings.Cut(mimeType, ";"); !allowedMimeTypes[base] {
+		return nil, ErrMimeNotAllowed
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	blob := &attachment_model.Blob{
+		SHA256:   sum,
+		Size:     size,
+		MimeType: mimeType,
+		// Случайный суффикс: удаление старого объекта сборщиком сирот
+		// не заденет тот же файл, загруженный заново
+		StorageKey: fmt.Sprintf("blobs/%s/%s-%s", sum[:2], sum, uuid.New().String()[:8]),
+	}
+
+	a.ID = uuid.New().String()
+	a.FileName = cleanFileName(fileName)
+
+	uploaded := false
+	_, err = s.Repo.CreateAttachment(ctx, a, blob, func() error {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		uploaded = true
+		return s.Store.Put(ctx, blob.StorageKey, tmp, size, mimeType)
+	})
+	if err != nil {
+		if uploaded {
+			if delErr := s.Store.Delete(context.Background(), blob.StorageKey); delErr != nil {
+				log.Printf("WARN: failed to remove stored file %s: %v", blob.StorageKey, delErr)
+			}
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '/' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." {
+		return "file"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return name
+}
+
+func (s *AttachmentService) GetPageAttachments(ctx context.Context, userID, pageID int) ([]*attachment_model.Attachment, error) {
+	ok, err := s.Repo.PageOwned(ctx, userID, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, attachment_repository.ErrParentNotFound
+	}
+	return s.Repo.GetPageAttachments(ctx, pageID)
+}
+
+func (s *AttachmentService) GetCardAttachments(ctx context.Context, columnID, cardID string) ([]*attachment_model.Attachment, error) {
+	ok, err := s.Repo.CardInColumn(ctx, columnID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, attachment_repository.ErrParentNotFound
+	}
+	return s.Repo.GetCardAttachments(ctx, cardID)
+}
+
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, userID int, id string) error {
+	return s.Repo.DeleteAttachment(ctx, userID, id)
+}
+
+func (s *AttachmentService) sign(id string, expires int64) string {
+	mac := hmac.New(sha256.New, s.SigningKey)
+	mac.Write([]byte(id + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedURL выдаёт ссылку на скачивание, по которой файл можно получить без токена
+// (например, в <img src>) до истечения срока.
+func (s *AttachmentService) SignedURL(ctx context.Context, userID int, id string) (*attachment_model.SignedURL, error) {
+	a, err := s.Repo.GetUserAttachment(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.URLTTL).Truncate(time.Second)
+	expires := expiresAt.Unix()
+	url := fmt.Sprintf("/api/v1/attachments/%s/download?expires=%d&sig=%s", a.ID, expires, s.sign(a.ID, expires))
+	return &attachment_model.SignedURL{URL: url, ExpiresAt: expiresAt.UTC()}, nil
+}
+
+// Open проверяет подпись ссылки и открывает файл вложения.
+func (s *AttachmentService) Open(ctx context.Context, id, expiresRaw, sig string) (*attachment_model.Attachment, io.ReadCloser, error) {
+	expires, err := strconv.ParseInt(expiresRaw, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return nil, nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.sign(id, expires))) {
+		return nil, nil, ErrInvalidSignature
+	}
+
+	a, err := s.Repo.GetAttachment(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := s.Store.Get(ctx, a.StorageKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return nil, nil, attachment_repository.ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	return a, body, nil
+}
+
+// CleanupOrphans удаляет файлы, на которые не осталось вложений: записи
+// вложений уходят каскадно при очистке корзины заметок и удалении досок.
+func (s *AttachmentService) CleanupOrphans(ctx context.Context) (int, error) {
+	blobs, err := s.Repo.GetOrphanBlobs(ctx, orphanGracePeriod, 500)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, b := range blobs {
+		deleted, err := s.Repo.DeleteOrphanBlob(ctx, b.SHA256)
+		if err != nil {
+			log.Printf("WARN: failed to delete orphan blob %s: %v", b.SHA256, err)
+			continue
+		}
+		if !deleted {
+			continue
+		}
+		if err := s.Store.Delete(ctx, b.StorageKey); err != nil {
+			log.Printf("WARN: failed to delete stored file %s: %v", b.StorageKey, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,125 @@
+package auth_services
+
+import (
+	"anemone_notes/internal/mailer"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Без пароля (вход только через SSO) удалить аккаунт можно лишь из свежей сессии
+const reauthMaxAge = 15 * time.Minute
+
+var (
+	ErrReauthRequired       = errors.New("sign in again to confirm this action")
+	ErrDeletionNotScheduled = errors.New("account is not scheduled for deletion")
+)
+
+func deletionGrace() time.Duration {
+	return time.Duration(cfg.AccountDeletionGraceDays) * 24 * time.Hour
+}
+
+// RequestAccountDeletion помечает аккаунт к удалению после повторной проверки
+// пароля и, если включена 2FA, кода. Все сессии и персональные токены
+// отзываются сразу, сами данные удаляются через ACCOUNT_DELETION_GRACE_DAYS.
+// До этого можно войти заново и отменить удаление. Возвращает дату удаления.
+func (s *AuthService) RequestAccountDeletion(ctx context.Context, userID int, sessionID, password, code string) (time.Time, error) {
+	u, err := s.Users.GetByID(ctx, float64(userID))
+	if err != nil {
+		return time.Time{}, errors.New("user data not found")
+	}
+	hash, err := s.Users.GetPasswordHash(ctx, userID)
+	if err != nil {
+		return time.Time{}, errors.New("user data not found")
+	}
+
+	if hash != "" {
+		if err := s.checkAttempts(ctx, accountKey(u.Email), ""); err != nil {
+			return time.Time{}, err
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(strings.TrimSpace(password))) != nil {
+			s.loginFailed(ctx, u, u.Email, nil)
+			return time.Time{}, ErrInvalidPassword
+		}
+	} else if err := s.checkRecentLogin(ctx, userID, sessionID); err != nil {
+		return time.Time{}, err
+	}
+
+	mfaEnabled, err := s.TOTP.IsEnabled(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if mfaEnabled {
+		if err := s.verifySecondFactor(ctx, userID, code); err != nil {
+			if errors.Is(err, ErrInvalidMFACode) {
+				s.loginFailed(ctx, u, u.Email, nil)
+			}
+			return time.Time{}, err
+		}
+	}
+	if err := s.Attempts.Reset(ctx, accountKey(u.Email)); err != nil {
+		log.Printf("ERROR resetting login attempts: %v", err)
+	}
+
+	requestedAt, err := s.Users.ScheduleDeletion(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := s.Refresh.DeleteAllForUser(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+	if err := s.PersonalTokens.DeleteAllForUser(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+
+	deleteAt := requestedAt.Add(deletionGrace())
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Your account is scheduled for deletion",
+		Text: fmt.Sprintf("Your Anemone account and all its data will be permanently deleted on %s.\n\nAll sessions and personal access tokens were revoked. Changed your mind? Sign in before that date and restore the account:\n%s\n",
+			deleteAt.UTC().Format("2 January 2006 15:04 MST"), strings.TrimRight(cfg.AppBaseURL, "/")+"/restore-account"),
+	})
+	if err != nil {
+		log.Printf("ERROR sending account deletion email to user %d: %v", userID, err)
+	}
+	return deleteAt, nil
+}
+
+// checkRecentLogin заменяет проверку пароля для аккаунтов без пароля:
+// сессия должна быть открыта входом не дольше reauthMaxAge назад.
+func (s *AuthService) checkRecentLogin(ctx context.Context, userID int, sessionID string) error {
+	if uuid.Validate(sessionID) != nil {
+		return ErrReauthRequired
+	}
+	createdAt, err := s.Refresh.SessionCreatedAt(ctx, userID, sessionID)
+	if err != nil {
+		return ErrReauthRequired
+	}
+	if time.Since(createdAt) > reauthMaxAge {
+		return ErrReauthRequired
+	}
+	return nil
+}
+
+// CancelAccountDeletion отменяет удаление, пока не истёк льготный период.
+func (s *AuthService) CancelAccountDeletion(ctx context.Context, userID int) error {
+	cancelled, err := s.Users.CancelDeletion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return ErrDeletionNotScheduled
+	}
+	return nil
+}
+
+// PurgeDeletedAccounts окончательно удаляет аккаунты, у которых истёк льготный период.
+func (s *AuthService) PurgeDeletedAccounts(ctx context.Context) (int64, error) {
+	return s.Users.DeleteScheduled(ctx, time.Now().Add(-deletionGrace()))
+}
@@ -2,7 +2,11 @@ package auth_services
 
 import (
 	"anemone_notes/internal/config"
+	"anemone_notes/internal/lockout"
+	"anemone_notes/internal/mailer"
 	"anemone_notes/internal/model/auth_model"
+	"anemone_notes/internal/oidc"
+	"anemone_notes/internal/passwords"
 	"anemone_notes/internal/repository/auth_repository"
 	"context"
 	"errors"
@@ -11,24 +15,51 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var cfg = config.Load() 
 
 type AuthService struct {
-	Users   *auth_repository.UserRepo
-	Refresh *auth_repository.RefreshRepo
+	Users    *auth_repository.UserRepo
+	Profiles *auth_repository.ProfileRepo
+	Refresh  *auth_repository.RefreshRepo
+	Tokens   *auth_repository.UserTokenRepo
+	TOTP     *auth_repository.TOTPRepo
+	Mailer   mailer.Mailer
+
+	Identities IdentityStore
+	Providers  map[string]oidc.Provider
+
+	PersonalTokens *auth_repository.PersonalTokenRepo
+	Attempts       *lockout.Guard
+	Passwords      *passwords.Policy
 }
 
-func NewAuthService(u *auth_repository.UserRepo, r *auth_repository.RefreshRepo) *AuthService {
-	return &AuthService{Users: u, Refresh: r}
+func NewAuthService(u *auth_repository.UserRepo, profiles *auth_repository.ProfileRepo, r *auth_repository.RefreshRepo, t *auth_repository.UserTokenRepo, totp *auth_repository.TOTPRepo, ids IdentityStore, providers map[string]oidc.Provider, pat *auth_repository.PersonalTokenRepo, attempts *lockout.Guard, policy *passwords.Policy, m mailer.Mailer) *AuthService {
+	return &AuthService{Users: u, Profiles: profiles, Refresh: r, Tokens: t, TOTP: totp, Identities: ids, Providers: providers, PersonalTokens: pat, Attempts: attempts, Passwords: policy, Mailer: m}
 }
 
-func (s *AuthService) Register(ctx context.Context, email, password string) (string, string, *auth_model.User, error) {
-	email = strings.TrimSpace(email)
+func (s *AuthService) Register(ctx context.Context, email, password string, client auth_model.ClientInfo) (string, string, *auth_model.User, error) {
 	password = strings.TrimSpace(password)
 
+	v := &ValidationError{}
+	email = checkEmail(v, "email", email)
+	if email != "" {
+		taken, err := s.Profiles.EmailInUse(ctx, email)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if taken {
+			v.add("email", CodeTaken, "an account with this email already exists")
+		}
+	}
+	s.checkPassword(ctx, v, "password", password, email)
+	if err := v.err(); err != nil {
+		return "", "", nil, err
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return "", "", nil, errors.New("failed to hash password")
@@ -36,61 +67,161 @@ func (s *AuthService) Register(ctx context.Context, email, password string) (str
 
 	u := &auth_model.User{Email: email, Password: string(hash)}
 	if err := s.Users.Create(ctx, u); err != nil {
+		if errors.Is(err, auth_repository.ErrEmailTaken) {
+			v.add("email", CodeTaken, "an account with this email already exists")
+			return "", "", nil, v
+		}
 		return "", "", nil, err
 	}
-	accessToken, refreshToken, err := s.generateTokens(ctx, u)
+	// Письмо не должно ломать регистрацию: ссылку можно запросить повторно
+	if err := s.sendVerification(ctx, u); err != nil {
+		log.Printf("ERROR sending verification email to user %d: %v", u.ID, err)
+	}
+	accessToken, refreshToken, err := s.generateTokens(ctx, u, client)
 	if err != nil {
 		return "", "", nil, err
 	}
 	return accessToken, refreshToken, u, nil
 }
 
-func (s *AuthService) generateTokens(ctx context.Context, u *auth_model.User) (string, string, error) {
-	accessClaims := jwt.MapClaims{
-		"user_id": u.ID,
-		"exp":     time.Now().Add(60 * time.Minute).Unix(),
-	}
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessToken, err := at.SignedString([]byte(cfg.AccessSecret))
-	if err != nil {
+// generateTokens открывает новую сессию: access-токен и первый refresh-токен семейства.
+func (s *AuthService) generateTokens(ctx context.Context, u *auth_model.User, client auth_model.ClientInfo) (string, string, error) {
+	sessionID := uuid.NewString()
+	access := newAccess()
+	if err := s.signAccess(u, sessionID, access); err != nil {
 		log.Printf("ERROR signing access token: %v", err)
 		return "", "", err
 	}
 
-	refreshExp := time.Now().Add(7 * 24 * time.Hour)
-	refreshClaims := jwt.MapClaims{
-		"user_id": u.ID,
-		"exp":     refreshExp.Unix(),
-	}
-	rt := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshToken, err := rt.SignedString([]byte(cfg.RefreshSecret))
+	refreshToken, refreshExp, err := signRefresh(u.ID)
 	if err != nil {
 		log.Printf("ERROR signing refresh token: %v", err)
 		return "", "", err
 	}
 
-	// сохранить refresh в БД
-	if err := s.Refresh.Store(ctx, u.ID, refreshToken, refreshExp); err != nil {
+	// в БД хранится только хэш refresh-токена
+	err = s.Refresh.Store(ctx, auth_repository.RefreshIssue{
+		UserID:          u.ID,
+		FamilyID:        sessionID,
+		TokenHash:       hashToken(refreshToken),
+		ExpiresAt:       refreshExp,
+		AccessJTI:       access.JTI,
+		AccessExpiresAt: access.ExpiresAt,
+		Client:          client,
+	})
+	if err != nil {
 		log.Printf("ERROR storing refresh token: %v", err)
 		return "", "", err
 	}
 
-	return accessToken, refreshToken, nil
+	return access.Token, refreshToken, nil
+}
+
+type signedAccess struct {
+	Token     string
+	JTI       string
+	ExpiresAt time.Time
+}
+
+// newAccess резервирует jti и срок access-токена: они сохраняются вместе с
+// refresh-токеном, чтобы access-токен можно было отозвать раньше срока.
+func newAccess() *signedAccess {
+	return &signedAccess{
+		JTI:       uuid.NewString(),
+		ExpiresAt: time.Now().Add(time.Duration(cfg.AccessTokenTTLMinutes) * time.Minute),
+	}
+}
+
+// signAccess подписывает access-токен сессии sessionID.
+func (s *AuthService) signAccess(u *auth_model.User, sessionID string, access *signedAccess) error {
+	accessClaims := jwt.MapClaims{
+		"user_id": u.ID,
+		"jti":     access.JTI,
+		"sid":     sessionID,
+		"exp":     access.ExpiresAt.Unix(),
+	}
+	if s.isRestricted(u) {
+		accessClaims["unverified"] = true
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString([]byte(cfg.AccessSecret))
+	if err != nil {
+		return err
+	}
+	access.Token = token
+	return nil
+}
+
+// signRefresh подписывает refresh-токен. jti делает каждый токен уникальным,
+// даже если два выпущены в одну секунду.
+func signRefresh(userID int) (string, time.Time, error) {
+	refreshExp := time.Now().Add(time.Duration(cfg.RefreshTokenTTLDays) * 24 * time.Hour)
+	refreshClaims := jwt.MapClaims{
+		"user_id": userID,
+		"jti":     uuid.NewString(),
+		"exp":     refreshExp.Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(cfg.RefreshSecret))
+	return token, refreshExp, err
+}
+
+// parseRefresh проверяет подпись и срок refresh-токена и возвращает его владельца.
+func parseRefresh(refreshToken string) (int, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(refreshToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(cfg.RefreshSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return 0, errors.New("invalid refresh token")
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, errors.New("invalid user_id in token")
+	}
+	return int(userID), nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, string, *auth_model.User, error) {
+func (s *AuthService) Login(ctx context.Context, email, password string, client auth_model.ClientInfo) (string, string, *auth_model.User, error) {
 	email = strings.TrimSpace(email)
 	password = strings.TrimSpace(password)
 
+	if err := s.checkAttempts(ctx, accountKey(email), s.ipKey(client)); err != nil {
+		return "", "", nil, err
+	}
+
 	u, err := s.Users.GetByEmail(ctx, email)
-	if err != nil {
+	if err != nil || u.Password == "" {
+		burnPasswordCheck(password)
+		s.loginFailed(ctx, nil, email, &client)
 		return "", "", nil, errors.New("invalid credentials")
 	}
 
 	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)) != nil {
+		s.loginFailed(ctx, u, email, &client)
 		return "", "", nil, errors.New("invalid credentials")
 	}
-	access, refresh, err := s.generateTokens(ctx, u)
+	if err := s.Attempts.Reset(ctx, accountKey(email)); err != nil {
+		log.Printf("ERROR resetting login attempts: %v", err)
+	}
+
+	return s.completeLogin(ctx, u, client)
+}
+
+// completeLogin выдаёт токены после проверки первого фактора или, если
+// включена 2FA, challenge для второго шага.
+func (s *AuthService) completeLogin(ctx context.Context, u *auth_model.User, client auth_model.ClientInfo) (string, string, *auth_model.User, error) {
+	mfaEnabled, err := s.TOTP.IsEnabled(ctx, u.ID)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if mfaEnabled {
+		challenge, err := signMFAChallenge(u.ID)
+		if err != nil {
+			return "", "", nil, err
+		}
+		return "", "", nil, &MFARequiredError{Token: challenge}
+	}
+
+	access, refresh, err := s.generateTokens(ctx, u, client)
 	if err != nil {
 		return "", "", nil, err
 	}
@@ -98,107 +229,159 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (string
 }
 
 func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
-	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(refreshToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(cfg.RefreshSecret), nil
-	})
-	if err != nil || !token.Valid {
+	if _, err := parseRefresh(refreshToken); err != nil {
 		return errors.New("invalid refresh token format")
 	}
 
-	userID := int(claims["user_id"].(float64))
-
-	if err := s.Refresh.Delete(ctx, userID, refreshToken); err != nil {
+	if err := s.Refresh.DeleteFamily(ctx, hashToken(refreshToken)); err != nil {
 		log.Printf("ERROR deleting refresh token: %v", err)
 		return errors.New("failed to logout")
 	}
 	return nil
 }
 
-func (s *AuthService) ChangePassword(ctx context.Context, email, oldPassword, newPassword string) error {
+// ChangePassword меняет пароль по старому. Маршрут открыт без access-токена,
+// поэтому при включённой 2FA нужен ещё и код: одного пароля мало.
+func (s *AuthService) ChangePassword(ctx context.Context, email, oldPassword, newPassword, code string) error {
 	email = strings.TrimSpace(email)
 	oldPassword = strings.TrimSpace(oldPassword)
 	newPassword = strings.TrimSpace(newPassword)
 
+	// проверка старого пароля — такой же подбор, как вход
+	if err := s.checkAttempts(ctx, accountKey(email), ""); err != nil {
+		return err
+	}
+
 	u, err := s.Users.GetByEmail(ctx, email)
 	if err != nil {
+		burnPasswordCheck(oldPassword)
+		s.loginFailed(ctx, nil, email, nil)
 		return errors.New("user not found")
 	}
 
 	if bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(oldPassword)) != nil {
+		s.loginFailed(ctx, u, email, nil)
 		return errors.New("invalid old password")
 	}
 
+	mfaEnabled, err := s.TOTP.IsEnabled(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	if mfaEnabled {
+		if err := s.verifySecondFactor(ctx, u.ID, code); err != nil {
+			if errors.Is(err, ErrInvalidMFACode) {
+				s.loginFailed(ctx, u, email, nil)
+			}
+			return err
+		}
+	}
+
+	v := &ValidationError{}
+	s.checkPassword(ctx, v, "new_password", newPassword, u.Email)
+	if newPassword == oldPassword {
+		v.add("new_password", CodeNoChange, "new password must differ from the old one")
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+
 	newHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return errors.New("failed to hash new password")
 	}
 
-	return s.Users.UpdatePassword(ctx, u.ID, string(newHash))
+	if err := s.Users.UpdatePassword(ctx, u.ID, string(newHash)); err != nil {
+		return err
+	}
+	// Старые сессии и персональные токены не должны пережить смену пароля
+	if err := s.Refresh.DeleteAllForUser(ctx, u.ID); err != nil {
+		return err
+	}
+	return s.PersonalTokens.DeleteAllForUser(ctx, u.ID)
 }
 
-func (s *AuthService) ResetPassword(ctx context.Context, email, newPassword string) error {
-	email = strings.TrimSpace(email)
-	newPassword = strings.TrimSpace(newPassword)
-	
-	u, err := s.Users.GetByEmail(ctx, email)
+// RefreshToken обменивает refresh-токен на новую пару. Старый токен после
+// этого недействителен, а его повторное предъявление завершает всю сессию.
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, client auth_model.ClientInfo) (string, string, *auth_model.User, error) {
+	userID, err := parseRefresh(refreshToken)
 	if err != nil {
-		return errors.New("user not found")
+		return "", "", nil, err
 	}
 
-	hash, _ := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
-	return s.Users.UpdatePassword(ctx, u.ID, string(hash))
-}
+	newRefresh, refreshExp, err := signRefresh(userID)
+	if err != nil {
+		return "", "", nil, errors.New("error")
+	}
 
-func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string) (string, *auth_model.User, error) {
-	claims := jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(refreshToken, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(cfg.RefreshSecret), nil
+	access := newAccess()
+	sessionID, err := s.Refresh.Rotate(ctx, hashToken(refreshToken), auth_repository.RefreshIssue{
+		TokenHash:       hashToken(newRefresh),
+		ExpiresAt:       refreshExp,
+		AccessJTI:       access.JTI,
+		AccessExpiresAt: access.ExpiresAt,
+		Client:          client,
 	})
-	if err != nil || !token.Valid {
-		return "", nil, errors.New("invalid refresh token")
+	if errors.Is(err, auth_repository.ErrRefreshReused) {
+		log.Printf("WARN: refresh token reuse for user %d, session revoked", userID)
+		return "", "", nil, err
 	}
-
-	userID, ok := claims["user_id"].(float64)
-	if !ok {
-		return "", nil, errors.New("invalid user_id in token")
+	if err != nil {
+		return "", "", nil, auth_repository.ErrRefreshNotFound
 	}
 
-	exp, ok := claims["exp"].(float64)
-	if !ok {
-		return "", nil, errors.New("invalid exp in token")
+	ud, err := s.Users.GetByID(ctx, float64(userID))
+	if err != nil {
+		return "", "", nil, errors.New("user data not found")
 	}
 
-	ok, err = s.Refresh.Check(ctx, int(userID), refreshToken, time.Unix(int64(exp), 0))
-	if err != nil || !ok {
-		return "", nil, errors.New("refresh token not found or expired")
+	if err := s.signAccess(ud, sessionID, access); err != nil {
+		return "", "", nil, errors.New("error")
 	}
 
-	accessClaims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(15 * time.Minute).Unix(),
-	}
-	at, err := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims).SignedString([]byte(cfg.AccessSecret))
-	if err != nil {
-		return "", nil, errors.New("error")
-	}
+	return access.Token, newRefresh, ud, nil
+}
 
-	ud, err := s.Users.GetByID(ctx, userID)
+// PurgeExpiredRefresh удаляет истёкшие refresh-токены.
+func (s *AuthService) PurgeExpiredRefresh(ctx context.Context) (int64, error) {
+	return s.Refresh.DeleteExpired(ctx)
+}
+
+func (s *AuthService) ParseAccessToken(tokenStr string) (int, error) {
+	claims, err := s.ParseAccess(tokenStr)
 	if err != nil {
-		return "", nil, errors.New("user data not found")
+		return 0, err
 	}
+	return claims.UserID, nil
+}
 
-	return at, ud, nil
+// AccessClaims — то, что middleware нужно знать о запросе из access-токена.
+type AccessClaims struct {
+	UserID     int
+	SessionID  string
+	JTI        string
+	Unverified bool
 }
 
-func (s *AuthService) ParseAccessToken(tokenStr string) (int, error) {
+func (s *AuthService) ParseAccess(tokenStr string) (*AccessClaims, error) {
 	claims := jwt.MapClaims{}
 	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(cfg.AccessSecret), nil
 	})
 	if err != nil || !token.Valid {
-		return 0, errors.New("invalid token")
+		return nil, errors.New("invalid token")
+	}
+	// токены с typ (например, MFA challenge) не дают доступа к API
+	if _, typed := claims["typ"]; typed {
+		return nil, errors.New("invalid token")
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, errors.New("invalid token")
 	}
-	userID := int(claims["user_id"].(float64))
-	return userID, nil
-}
\ No newline at end of file
+	ac := &AccessClaims{UserID: int(userID)}
+	ac.SessionID, _ = claims["sid"].(string)
+	ac.JTI, _ = claims["jti"].(string)
+	ac.Unverified, _ = claims["unverified"].(bool)
+	return ac, nil
+}
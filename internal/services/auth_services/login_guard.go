@@ -0,0 +1,148 @@
+package auth_services
+
+import (
+	"anemone_notes/internal/lockout"
+	"anemone_notes/internal/mailer"
+	"anemone_notes/internal/model/auth_model"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TooManyAttemptsError одинаков для существующих и несуществующих аккаунтов,
+// и для задержки, и для блокировки: по ответу нельзя понять, есть ли аккаунт.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return "too many failed login attempts, try again later"
+}
+
+// Аккаунт: первые 3 ошибки без задержки, затем 1с, 2с, 4с... и блокировка
+// после LOGIN_MAX_FAILURES. IP: задержка начинается позже, без блокировки,
+// чтобы из-за общего NAT не страдали чужие аккаунты.
+func accountPolicy() lockout.Policy {
+	return lockout.Policy{
+		FreeFailures: 3,
+		BaseDelay:    time.Second,
+		MaxDelay:     5 * time.Minute,
+		MaxFailures:  cfg.LoginMaxFailures,
+		LockDuration: time.Duration(cfg.LoginLockoutMinutes) * time.Minute,
+		Window:       24 * time.Hour,
+	}
+}
+
+func ipPolicy() lockout.Policy {
+	return lockout.Policy{
+		FreeFailures: cfg.LoginIPMaxFailures,
+		BaseDelay:    time.Second,
+		MaxDelay:     15 * time.Minute,
+		Window:       time.Hour,
+	}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *AuthService) ipKey(client auth_model.ClientInfo) string {
+	if cfg.TrustProxyHeaders && client.IPAddress != "" {
+		return "ip:" + client.IPAddress
+	}
+	return "ip:" + client.RemoteIP
+}
+
+// checkAttempts возвращает TooManyAttemptsError, если с попыткой надо
+// подождать. Пустой ipKey — лимит по IP не проверяется.
+func (s *AuthService) checkAttempts(ctx context.Context, accKey, ipKey string) error {
+	wait, err := s.Attempts.Wait(ctx, accKey, accountPolicy())
+	if err != nil {
+		return err
+	}
+	if ipKey != "" {
+		ipWait, err := s.Attempts.Wait(ctx, ipKey, ipPolicy())
+		if err != nil {
+			return err
+		}
+		if ipWait > wait {
+			wait = ipWait
+		}
+	}
+	if wait > 0 {
+		return &TooManyAttemptsError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// loginFailed учитывает неудачу. Если она заблокировала аккаунт, владельцу
+// уходит письмо со ссылкой для разблокировки.
+func (s *AuthService) loginFailed(ctx context.Context, u *auth_model.User, email string, client *auth_model.ClientInfo) {
+	if client != nil {
+		if _, err := s.Attempts.Fail(ctx, s.ipKey(*client), ipPolicy()); err != nil {
+			log.Printf("ERROR recording failed login: %v", err)
+		}
+	}
+	locked, err := s.Attempts.Fail(ctx, accountKey(email), accountPolicy())
+	if err != nil {
+		log.Printf("ERROR recording failed login: %v", err)
+		return
+	}
+	if locked && u != nil {
+		if err := s.sendUnlock(ctx, u); err != nil {
+			log.Printf("ERROR sending unlock email to user %d: %v", u.ID, err)
+		}
+	}
+}
+
+func (s *AuthService) sendUnlock(ctx context.Context, u *auth_model.User) error {
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+	if err := s.Tokens.Create(ctx, u.ID, auth_model.TokenUnlockAccount, hash, time.Now().Add(24*time.Hour)); err != nil {
+		return err
+	}
+	return s.Mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Your account was temporarily locked",
+		Text: fmt.Sprintf("We blocked sign-in to your Anemone account for %d minutes after too many failed attempts.\n\nIf it was you, unlock it now:\n%s\n\nIf it wasn't, consider changing your password.\n",
+			cfg.LoginLockoutMinutes, appLink("/unlock-account", token)),
+	})
+}
+
+// UnlockAccount снимает блокировку по ссылке из письма.
+func (s *AuthService) UnlockAccount(ctx context.Context, token string) error {
+	userID, err := s.Tokens.Consume(ctx, auth_model.TokenUnlockAccount, hashToken(strings.TrimSpace(token)))
+	if err != nil {
+		return err
+	}
+	u, err := s.Users.GetByID(ctx, float64(userID))
+	if err != nil {
+		return err
+	}
+	return s.Attempts.Reset(ctx, accountKey(u.Email))
+}
+
+func (s *AuthService) CleanupLoginAttempts(ctx context.Context) (int64, error) {
+	return s.Attempts.Cleanup(ctx, 24*time.Hour)
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// burnPasswordCheck тратит на неизвестный аккаунт столько же времени, сколько
+// на проверку настоящего пароля.
+func burnPasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("anemone-dummy-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
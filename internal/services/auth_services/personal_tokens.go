@@ -0,0 +1,167 @@
+package auth_services
+
+import (
+	"anemone_notes/internal/model/auth_model"
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PersonalTokenPrefix отличает персональные токены от JWT в заголовке Authorization.
+const PersonalTokenPrefix = "anp_"
+
+const (
+	personalTokenMaxPerUser  = 50
+	personalTokenDefaultDays = 30
+	personalTokenMaxDays     = 365
+)
+
+var (
+	ErrInvalidTokenName   = errors.New("token name is required and must be at most 100 characters")
+	ErrInvalidTokenScopes = errors.New("at least one valid scope is required")
+	ErrInvalidTokenExpiry = errors.New("expires_in_days must be between 1 and 365")
+	ErrTooManyTokens      = errors.New("too many personal access tokens")
+)
+
+// Группы маршрутов, доступные персональным токенам. Запись в группу
+// (любой метод, кроме GET/HEAD) требует scope "<группа>:write", чтение —
+// ":read" или ":write". Маршруты вне этих групп (аккаунт, сессии, сами
+// токены) доступны только после обычного входа.
+var personalTokenRoutes = []struct {
+	prefix string
+	groups []string
+}{
+	{"/api/v1/notes", []string{"notes"}},
+	{"/api/v1/folder", []string{"notes"}},
+	{"/api/v1/tags", []string{"notes"}},
+	{"/api/v1/trello", []string{"trello"}},
+	{"/api/v1/mail", []string{"mail"}},
+	// вложения бывают и у страниц, и у карточек
+	{"/api/v1/attachments", []string{"notes", "trello"}},
+}
+
+var personalTokenScopes = map[string]bool{
+	"notes:read": true, "notes:write": true,
+	"trello:read": true, "trello:write": true,
+	"mail:read": true, "mail:write": true,
+}
+
+// PersonalTokenScopes — список допустимых scope для формы создания токена.
+func PersonalTokenScopes() []string {
+	scopes := make([]string, 0, len(personalTokenScopes))
+	for scope := range personalTokenScopes {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// PersonalTokenAllows решает, покрывают ли scopes токена запрос method path.
+func PersonalTokenAllows(scopes []string, method, path string) bool {
+	granted := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		granted[scope] = true
+	}
+	readOnly := method == http.MethodGet || method == http.MethodHead
+
+	for _, route := range personalTokenRoutes {
+		if path != route.prefix && !strings.HasPrefix(path, route.prefix+"/") {
+			continue
+		}
+		for _, group := range route.groups {
+			if granted[group+":write"] || (readOnly && granted[group+":read"]) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// CreatePersonalToken выпускает токен. Открытое значение возвращается
+// только здесь, в базе остаётся хэш.
+func (s *AuthService) CreatePersonalToken(ctx context.Context, userID int, name string, scopes []string, expiresInDays int) (string, *auth_model.PersonalToken, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > 100 {
+		return "", nil, ErrInvalidTokenName
+	}
+
+	seen := map[string]bool{}
+	var clean []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !personalTokenScopes[scope] {
+			return "", nil, ErrInvalidTokenScopes
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			clean = append(clean, scope)
+		}
+	}
+	if len(clean) == 0 {
+		return "", nil, ErrInvalidTokenScopes
+	}
+	sort.Strings(clean)
+
+	if expiresInDays == 0 {
+		expiresInDays = personalTokenDefaultDays
+	}
+	if expiresInDays < 1 || expiresInDays > personalTokenMaxDays {
+		return "", nil, ErrInvalidTokenExpiry
+	}
+
+	count, err := s.PersonalTokens.CountForUser(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if count >= personalTokenMaxPerUser {
+		return "", nil, ErrTooManyTokens
+	}
+
+	secret, _, err := newToken()
+	if err != nil {
+		return "", nil, err
+	}
+	plain := PersonalTokenPrefix + secret
+	t := &auth_model.PersonalToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plain[:len(PersonalTokenPrefix)+6],
+		Scopes:    clean,
+		ExpiresAt: time.Now().Add(time.Duration(expiresInDays) * 24 * time.Hour),
+	}
+	if err := s.PersonalTokens.Create(ctx, t, hashToken(plain)); err != nil {
+		return "", nil, err
+	}
+	return plain, t, nil
+}
+
+func (s *AuthService) ListPersonalTokens(ctx context.Context, userID int) ([]*auth_model.PersonalToken, error) {
+	return s.PersonalTokens.GetForUser(ctx, userID)
+}
+
+func (s *AuthService) RevokePersonalToken(ctx context.Context, userID, tokenID int) error {
+	return s.PersonalTokens.Delete(ctx, userID, tokenID)
+}
+
+// ParsePersonalToken проверяет персональный токен из заголовка Authorization.
+// Токен живёт долго, поэтому статус почты владельца проверяется на каждый запрос.
+func (s *AuthService) ParsePersonalToken(ctx context.Context, token string) (*auth_model.PersonalToken, error) {
+	pat, err := s.PersonalTokens.Use(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.Users.GetByID(ctx, float64(pat.UserID))
+	if err != nil {
+		return nil, err
+	}
+	pat.Unverified = s.isRestricted(u)
+	return pat, nil
+}
+
+func (s *AuthService) PurgeExpiredPersonalTokens(ctx context.Context) (int64, error) {
+	return s.PersonalTokens.DeleteExpired(ctx)
+}
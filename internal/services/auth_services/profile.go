@@ -0,0 +1,213 @@
+package auth_services
+
+import (
+	"anemone_notes/internal/mailer"
+	"anemone_notes/internal/model/auth_model"
+	"anemone_notes/internal/repository/auth_repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	maxDisplayNameLength = 64
+	maxAvatarURLLength   = 2048
+)
+
+// Язык, необязательные письменность и регион: en, ru-RU, zh-Hant-TW, es-419
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Z][a-z]{3})?(-([A-Z]{2}|[0-9]{3}))?$`)
+
+func (s *AuthService) GetProfile(ctx context.Context, userID int) (*auth_model.Profile, error) {
+	p, err := s.Profiles.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if p.DeletionRequestedAt != nil {
+		at := p.DeletionRequestedAt.Add(deletionGrace())
+		p.DeletionScheduledAt = &at
+	}
+	return p, nil
+}
+
+// UpdateProfile меняет только переданные поля и возвращает профиль целиком.
+// Ошибки во всех полях возвращаются вместе одной ValidationError.
+func (s *AuthService) UpdateProfile(ctx context.Context, userID int, upd auth_repository.ProfileUpdate) (*auth_model.Profile, error) {
+	v := &ValidationError{}
+	if upd.DisplayName != nil {
+		name := strings.TrimSpace(*upd.DisplayName)
+		if utf8.RuneCountInString(name) > maxDisplayNameLength || strings.IndexFunc(name, unicode.IsControl) >= 0 {
+			v.add("display_name", CodeInvalid, fmt.Sprintf("display name must be at most %d characters without control characters", maxDisplayNameLength))
+		}
+		upd.DisplayName = &name
+	}
+	if upd.AvatarURL != nil {
+		avatar := strings.TrimSpace(*upd.AvatarURL)
+		if avatar != "" && !validAvatarURL(avatar) {
+			v.add("avatar_url", CodeInvalid, "avatar must be an http(s) url")
+		}
+		upd.AvatarURL = &avatar
+	}
+	if upd.Timezone != nil {
+		// "Local" зависит от сервера, а не от пользователя
+		if _, err := time.LoadLocation(*upd.Timezone); err != nil || *upd.Timezone == "" || *upd.Timezone == "Local" {
+			v.add("timezone", CodeInvalid, "unknown timezone")
+		}
+	}
+	if upd.Locale != nil && !localePattern.MatchString(*upd.Locale) {
+		v.add("locale", CodeInvalid, "locale must be a language tag like en or pt-BR")
+	}
+	if upd.Theme != nil {
+		switch *upd.Theme {
+		case auth_model.ThemeSystem, auth_model.ThemeLight, auth_model.ThemeDark:
+		default:
+			v.add("preferences.theme", CodeInvalid, "theme must be system, light or dark")
+		}
+	}
+	if upd.DefaultBoardID != nil && *upd.DefaultBoardID != "" {
+		owned := false
+		if uuid.Validate(*upd.DefaultBoardID) == nil {
+			var err error
+			if owned, err = s.Profiles.BoardOwned(ctx, userID, *upd.DefaultBoardID); err != nil {
+				return nil, err
+			}
+		}
+		if !owned {
+			v.add("preferences.default_board_id", CodeNotFound, "default board not found")
+		}
+	}
+	if upd.DefaultFolderID != nil && *upd.DefaultFolderID != 0 {
+		owned, err := s.Profiles.FolderOwned(ctx, userID, *upd.DefaultFolderID)
+		if err != nil {
+			return nil, err
+		}
+		if !owned {
+			v.add("preferences.default_folder_id", CodeNotFound, "default folder not found")
+		}
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.Profiles.Update(ctx, userID, upd); err != nil {
+		return nil, err
+	}
+	return s.GetProfile(ctx, userID)
+}
+
+func validAvatarURL(raw string) bool {
+	if len(raw) > maxAvatarURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil {
+		return false
+	}
+	return u.Scheme == "https" || u.Scheme == "http"
+}
+
+// RequestEmailChange отправляет ссылку подтверждения на новый адрес. До
+// перехода по ней вход и письма работают со старым адресом.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID int, sessionID, newEmail, password string) error {
+	v := &ValidationError{}
+	newEmail = checkEmail(v, "email", newEmail)
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	u, err := s.Users.GetByID(ctx, float64(userID))
+	if err != nil {
+		return errors.New("user data not found")
+	}
+	if strings.EqualFold(u.Email, newEmail) {
+		v.add("email", CodeNoChange, "this is already your email")
+		return v
+	}
+
+	hash, err := s.Users.GetPasswordHash(ctx, userID)
+	if err != nil {
+		return errors.New("user data not found")
+	}
+	if hash != "" {
+		if err := s.checkAttempts(ctx, accountKey(u.Email), ""); err != nil {
+			return err
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(strings.TrimSpace(password))) != nil {
+			s.loginFailed(ctx, u, u.Email, nil)
+			return ErrInvalidPassword
+		}
+	} else if err := s.checkRecentLogin(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	taken, err := s.Profiles.EmailInUse(ctx, newEmail)
+	if err != nil {
+		return err
+	}
+	if taken {
+		v.add("email", CodeTaken, "an account with this email already exists")
+		return v
+	}
+
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return err
+	}
+	if err := s.Profiles.SetPendingEmail(ctx, userID, newEmail); err != nil {
+		return err
+	}
+	expires := time.Now().Add(time.Duration(cfg.EmailVerifyTTLHours) * time.Hour)
+	if err := s.Tokens.Create(ctx, userID, auth_model.TokenChangeEmail, tokenHash, expires); err != nil {
+		return err
+	}
+
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email",
+		Text: fmt.Sprintf("Confirm that you want to use this address for your Anemone account:\n%s\n\nThe link is valid for %d hours.\n",
+			appLink("/confirm-email-change", token), cfg.EmailVerifyTTLHours),
+	})
+	if err != nil {
+		return err
+	}
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Email change requested",
+		Text:    fmt.Sprintf("Someone asked to change the email of your Anemone account to %s.\n\nIf it wasn't you, change your password: the change only takes effect after the new address is confirmed.\n", newEmail),
+	})
+	if err != nil {
+		log.Printf("ERROR sending email change notice to user %d: %v", userID, err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange применяет смену почты по ссылке из письма.
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, token string) error {
+	userID, err := s.Tokens.Consume(ctx, auth_model.TokenChangeEmail, hashToken(strings.TrimSpace(token)))
+	if err != nil {
+		return err
+	}
+	oldEmail, newEmail, err := s.Profiles.ConfirmEmailChange(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      oldEmail,
+		Subject: "Your email was changed",
+		Text:    fmt.Sprintf("The email of your Anemone account is now %s. Sign in with the new address from now on.\n", newEmail),
+	})
+	if err != nil {
+		log.Printf("ERROR sending email changed notice to user %d: %v", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package auth_services
+
+import (
+	"anemone_notes/internal/model/auth_model"
+	"anemone_notes/internal/repository/auth_repository"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnknownSession = errors.New("current session is unknown, sign in again")
+
+// ListSessions возвращает сессии пользователя, помечая ту, из которой пришёл запрос.
+func (s *AuthService) ListSessions(ctx context.Context, userID int, currentSessionID string) ([]*auth_model.Session, error) {
+	sessions, err := s.Refresh.GetSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+func (s *AuthService) RevokeSession(ctx context.Context, userID int, sessionID string) error {
+	if uuid.Validate(sessionID) != nil {
+		return auth_repository.ErrSessionNotFound
+	}
+	return s.Refresh.RevokeSession(ctx, userID, sessionID)
+}
+
+// RevokeOtherSessions завершает все сессии, кроме текущей. Токены, выпущенные
+// до появления сессий, не знают своей сессии, и для них операция недоступна.
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID int, currentSessionID string) (int64, error) {
+	if uuid.Validate(currentSessionID) != nil {
+		return 0, ErrUnknownSession
+	}
+	return s.Refresh.RevokeOtherSessions(ctx, userID, currentSessionID)
+}
+
+// IsAccessRevoked проверяет access-токен по denylist отозванных сессий.
+func (s *AuthService) IsAccessRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	return s.Refresh.IsAccessRevoked(ctx, jti)
+}
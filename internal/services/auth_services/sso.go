@@ -0,0 +1,229 @@
+package auth_services
+
+import (
+	"anemone_notes/internal/model/auth_model"
+	"anemone_notes/internal/oidc"
+	"anemone_notes/internal/repository/auth_repository"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	oidcStateTTL = 10 * time.Minute
+	ssoCodeTTL   = 2 * time.Minute
+)
+
+// SSOStateTTL — сколько живут state и cookie, привязывающая его к браузеру.
+const SSOStateTTL = oidcStateTTL
+
+var (
+	ErrSSOEmailRequired    = errors.New("login provider did not return an email address")
+	ErrSSOEmailNotVerified = errors.New("an account with this email already exists; the provider has not verified the email, so it cannot be linked")
+	ErrSSOStateMismatch    = errors.New("login was started in another browser")
+	ErrSSODenied           = errors.New("login was cancelled at the provider")
+	ErrSSOProvider         = errors.New("login provider returned an error")
+)
+
+// IdentityStore — привязки к провайдерам входа и незавершённые входы.
+// Реализуется auth_repository.IdentityRepo.
+type IdentityStore interface {
+	Touch(ctx context.Context, provider, subject, email string) (int, error)
+	FindUserByEmail(ctx context.Context, email string) (int, error)
+	Link(ctx context.Context, userID int, provider, subject, email string) error
+	CreateUser(ctx context.Context, provider, subject, email string, emailVerified bool) (int, error)
+	SaveState(ctx context.Context, stateHash, provider, verifier, nonce string, expiresAt time.Time) error
+	ConsumeState(ctx context.Context, stateHash, provider string) (string, string, error)
+	DeleteExpiredStates(ctx context.Context) (int64, error)
+}
+
+// ProviderNames — настроенные провайдеры для кнопок входа на фронтенде.
+func (s *AuthService) ProviderNames() []string {
+	names := make([]string, 0, len(s.Providers))
+	for name := range s.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *AuthService) provider(name string) (oidc.Provider, error) {
+	p, ok := s.Providers[name]
+	if !ok {
+		return nil, oidc.ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// StartSSO запоминает state, PKCE verifier и nonce и возвращает адрес
+// страницы входа провайдера и state. State нужно сохранить в браузере
+// (cookie) и передать в FinishSSO: так вход нельзя завершить в чужом браузере.
+func (s *AuthService) StartSSO(ctx context.Context, providerName string) (string, string, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := oidc.NewState()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := p.AuthURL(ctx, state, nonce, oidc.Challenge(verifier))
+	if err != nil {
+		return "", "", err
+	}
+	if err := s.Identities.SaveState(ctx, hashToken(state), providerName, verifier, nonce, time.Now().Add(oidcStateTTL)); err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// FinishSSO обрабатывает возврат с провайдера: меняет код на личность,
+// находит, привязывает или создаёт пользователя и выдаёт одноразовый код,
+// которым фронтенд заберёт токены через ExchangeSSOCode. browserState —
+// state из cookie браузера, который начал вход.
+func (s *AuthService) FinishSSO(ctx context.Context, providerName, state, browserState, code string) (string, error) {
+	userID, err := s.authenticateSSO(ctx, providerName, state, browserState, code)
+	if err != nil {
+		return "", err
+	}
+
+	loginCode, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.Tokens.Create(ctx, userID, auth_model.TokenSSOLogin, hash, time.Now().Add(ssoCodeTTL)); err != nil {
+		return "", err
+	}
+	return loginCode, nil
+}
+
+// authenticateSSO проверяет возврат с провайдера и возвращает пользователя.
+func (s *AuthService) authenticateSSO(ctx context.Context, providerName, state, browserState, code string) (int, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return 0, err
+	}
+	// Без этой проверки злоумышленник мог бы войти у провайдера сам и
+	// подсунуть ссылку возврата жертве, чтобы та оказалась в его аккаунте
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return 0, ErrSSOStateMismatch
+	}
+	verifier, nonce, err := s.Identities.ConsumeState(ctx, hashToken(state), providerName)
+	if err != nil {
+		return 0, err
+	}
+
+	identity, err := p.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		return 0, err
+	}
+	return s.resolveIdentity(ctx, identity)
+}
+
+// resolveIdentity: сначала привязка по sub, затем существующий аккаунт по
+// подтверждённой провайдером почте, иначе новый пользователь без пароля.
+func (s *AuthService) resolveIdentity(ctx context.Context, id *oidc.Identity) (int, error) {
+	userID, err := s.Identities.Touch(ctx, id.Provider, id.Subject, id.Email)
+	if err == nil {
+		return userID, nil
+	}
+	if !errors.Is(err, auth_repository.ErrIdentityNotFound) {
+		return 0, err
+	}
+
+	if id.Email == "" {
+		return 0, ErrSSOEmailRequired
+	}
+	userID, err = s.Identities.FindUserByEmail(ctx, id.Email)
+	switch {
+	case err == nil:
+		if !id.EmailVerified {
+			return 0, ErrSSOEmailNotVerified
+		}
+		if err := s.Identities.Link(ctx, userID, id.Provider, id.Subject, id.Email); err != nil {
+			return 0, err
+		}
+		log.Printf("INFO: linked %s identity to user %d", id.Provider, userID)
+		return userID, nil
+	case !errors.Is(err, auth_repository.ErrIdentityNotFound):
+		return 0, err
+	}
+
+	userID, err = s.Identities.CreateUser(ctx, id.Provider, id.Subject, strings.ToLower(id.Email), id.EmailVerified)
+	if err != nil {
+		// параллельный вход того же пользователя мог успеть создать привязку
+		if existing, touchErr := s.Identities.Touch(ctx, id.Provider, id.Subject, id.Email); touchErr == nil {
+			return existing, nil
+		}
+		return 0, err
+	}
+	return userID, nil
+}
+
+// ExchangeSSOCode — последний шаг входа через SSO. Включённая 2FA
+// спрашивается так же, как при входе по паролю.
+func (s *AuthService) ExchangeSSOCode(ctx context.Context, code string, client auth_model.ClientInfo) (string, string, *auth_model.User, error) {
+	userID, err := s.Tokens.Consume(ctx, auth_model.TokenSSOLogin, hashToken(strings.TrimSpace(code)))
+	if err != nil {
+		return "", "", nil, err
+	}
+	u, err := s.Users.GetByID(ctx, float64(userID))
+	if err != nil {
+		return "", "", nil, errors.New("user data not found")
+	}
+	return s.completeLogin(ctx, u, client)
+}
+
+// SSOErrorCode — код ошибки входа для фронтенда. Текст внутренних ошибок
+// (ответы провайдера, сбои discovery) наружу не отдаётся.
+func SSOErrorCode(err error) string {
+	switch {
+	case errors.Is(err, oidc.ErrUnknownProvider):
+		return "unknown_provider"
+	case errors.Is(err, auth_repository.ErrLoginStateExpired):
+		return "login_expired"
+	case errors.Is(err, ErrSSOStateMismatch):
+		return "state_mismatch"
+	case errors.Is(err, ErrSSOEmailRequired):
+		return "email_required"
+	case errors.Is(err, ErrSSOEmailNotVerified):
+		return "email_not_verified"
+	case errors.Is(err, ErrSSODenied):
+		return "access_denied"
+	case errors.Is(err, ErrSSOProvider):
+		return "provider_error"
+	}
+	return "sso_failed"
+}
+
+// SSORedirectURL — куда вернуть браузер после входа: на фронтенд с кодом
+// или с кодом ошибки.
+func SSORedirectURL(loginCode string, loginErr error) string {
+	q := url.Values{}
+	if loginErr != nil {
+		q.Set("error", SSOErrorCode(loginErr))
+	} else {
+		q.Set("code", loginCode)
+	}
+	return strings.TrimRight(cfg.AppBaseURL, "/") + "/sso/callback?" + q.Encode()
+}
+
+// CleanupSSOStates удаляет брошенные попытки входа.
+func (s *AuthService) CleanupSSOStates(ctx context.Context) (int64, error) {
+	return s.Identities.DeleteExpiredStates(ctx)
+}
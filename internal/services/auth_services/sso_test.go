@@ -0,0 +1,274 @@
+package auth_services
+
+import (
+	"anemone_notes/internal/config"
+	"anemone_notes/internal/oidc"
+	"anemone_notes/internal/oidc/oidctest"
+	"anemone_notes/internal/repository/auth_repository"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeState struct {
+	provider, verifier, nonce string
+}
+
+// fakeIdentities — IdentityStore в памяти.
+type fakeIdentities struct {
+	states     map[string]fakeState
+	identities map[string]int
+	users      map[string]int
+	verified   map[int]bool
+	linked     []int
+	nextID     int
+}
+
+func newFakeIdentities() *fakeIdentities {
+	return &fakeIdentities{
+		states:     map[string]fakeState{},
+		identities: map[string]int{},
+		users:      map[string]int{},
+		verified:   map[int]bool{},
+		nextID:     100,
+	}
+}
+
+func (f *fakeIdentities) Touch(ctx context.Context, provider, subject, email string) (int, error) {
+	if id, ok := f.identities[provider+"|"+subject]; ok {
+		return id, nil
+	}
+	return 0, auth_repository.ErrIdentityNotFound
+}
+
+func (f *fakeIdentities) FindUserByEmail(ctx context.Context, email string) (int, error) {
+	if id, ok := f.users[strings.ToLower(email)]; ok {
+		return id, nil
+	}
+	return 0, auth_repository.ErrIdentityNotFound
+}
+
+func (f *fakeIdentities) Link(ctx context.Context, userID int, provider, subject, email string) error {
+	f.identities[provider+"|"+subject] = userID
+	f.verified[userID] = true
+	f.linked = append(f.linked, userID)
+	return nil
+}
+
+func (f *fakeIdentities) CreateUser(ctx context.Context, provider, subject, email string, emailVerified bool) (int, error) {
+	f.nextID++
+	f.users[email] = f.nextID
+	f.verified[f.nextID] = emailVerified
+	f.identities[provider+"|"+subject] = f.nextID
+	return f.nextID, nil
+}
+
+func (f *fakeIdentities) SaveState(ctx context.Context, stateHash, provider, verifier, nonce string, expiresAt time.Time) error {
+	f.states[stateHash] = fakeState{provider: provider, verifier: verifier, nonce: nonce}
+	return nil
+}
+
+func (f *fakeIdentities) ConsumeState(ctx context.Context, stateHash, provider string) (string, string, error) {
+	st, ok := f.states[stateHash]
+	if !ok || st.provider != provider {
+		return "", "", auth_repository.ErrLoginStateExpired
+	}
+	delete(f.states, stateHash)
+	return st.verifier, st.nonce, nil
+}
+
+func (f *fakeIdentities) DeleteExpiredStates(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func newSSOTestService(t *testing.T) (*AuthService, *oidctest.Provider, *fakeIdentities) {
+	t.Helper()
+	p, err := oidctest.New("anemone-test")
+	if err != nil {
+		t.Fatalf("start mock provider: %v", err)
+	}
+	t.Cleanup(p.Close)
+
+	client := oidc.NewClient(config.OIDCProvider{
+		Name:        "mock",
+		Issuer:      p.Issuer(),
+		ClientID:    "anemone-test",
+		RedirectURL: "http://localhost/api/v1/auth/oidc/mock/callback",
+		Scopes:      []string{"openid", "email"},
+	}, &http.Client{Timeout: 5 * time.Second})
+
+	ids := newFakeIdentities()
+	s := &AuthService{Identities: ids, Providers: map[string]oidc.Provider{"mock": client}}
+	return s, p, ids
+}
+
+// startLogin начинает вход и проходит страницу провайдера; возвращает state и код.
+func startLogin(t *testing.T, s *AuthService, p *oidctest.Provider, claims map[string]any) (string, string) {
+	t.Helper()
+	authURL, state, err := s.StartSSO(context.Background(), "mock")
+	if err != nil {
+		t.Fatalf("StartSSO: %v", err)
+	}
+	u, _ := url.Parse(authURL)
+	if u.Query().Get("state") != state {
+		t.Fatalf("auth URL state %q differs from returned state %q", u.Query().Get("state"), state)
+	}
+	code, err := p.Authorize(authURL, claims)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	return state, code
+}
+
+func TestSSOCreatesUserAndReusesIdentity(t *testing.T) {
+	s, p, ids := newSSOTestService(t)
+	ctx := context.Background()
+	claims := map[string]any{"sub": "u-1", "email": "New@Example.com", "email_verified": true}
+
+	state, code := startLogin(t, s, p, claims)
+	userID, err := s.authenticateSSO(ctx, "mock", state, state, code)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	if ids.users["new@example.com"] != userID || !ids.verified[userID] {
+		t.Fatalf("user not created with verified lowercase email: users=%v verified=%v", ids.users, ids.verified)
+	}
+
+	state, code = startLogin(t, s, p, claims)
+	again, err := s.authenticateSSO(ctx, "mock", state, state, code)
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again != userID || len(ids.users) != 1 {
+		t.Fatalf("second login created another user: %d vs %d, users=%v", again, userID, ids.users)
+	}
+}
+
+func TestSSOForwardsStoredPKCEVerifier(t *testing.T) {
+	s, p, ids := newSSOTestService(t)
+
+	state, code := startLogin(t, s, p, map[string]any{"sub": "u-1", "email": "a@example.com", "email_verified": true})
+	stored := ids.states[hashToken(state)].verifier
+	if stored == "" {
+		t.Fatal("StartSSO did not store a verifier")
+	}
+	if _, err := s.authenticateSSO(context.Background(), "mock", state, state, code); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if p.LastVerifier() != stored {
+		t.Fatalf("token endpoint got verifier %q, want stored %q", p.LastVerifier(), stored)
+	}
+}
+
+func TestSSOStateMustMatchBrowser(t *testing.T) {
+	s, p, ids := newSSOTestService(t)
+	ctx := context.Background()
+
+	state, code := startLogin(t, s, p, map[string]any{"sub": "u-1", "email": "a@example.com", "email_verified": true})
+	for _, browserState := range []string{"", "attacker-state", state + "x"} {
+		if _, err := s.authenticateSSO(ctx, "mock", state, browserState, code); !errors.Is(err, ErrSSOStateMismatch) {
+			t.Fatalf("browser state %q: err = %v, want ErrSSOStateMismatch", browserState, err)
+		}
+	}
+	// чужой браузер не должен сжечь state настоящего пользователя
+	if _, ok := ids.states[hashToken(state)]; !ok {
+		t.Fatal("state was consumed by a mismatching browser")
+	}
+	if _, err := s.authenticateSSO(ctx, "mock", state, state, code); err != nil {
+		t.Fatalf("login from the starting browser: %v", err)
+	}
+	if _, err := s.authenticateSSO(ctx, "mock", state, state, code); !errors.Is(err, auth_repository.ErrLoginStateExpired) {
+		t.Fatalf("reused state: err = %v, want ErrLoginStateExpired", err)
+	}
+}
+
+func TestSSOEmailLinking(t *testing.T) {
+	tests := []struct {
+		name      string
+		verified  any
+		wantErr   error
+		wantLinks int
+	}{
+		{name: "verified email links", verified: true, wantLinks: 1},
+		{name: "verified as string links", verified: "true", wantLinks: 1},
+		{name: "unverified email refused", verified: false, wantErr: ErrSSOEmailNotVerified},
+		{name: "missing claim refused", verified: nil, wantErr: ErrSSOEmailNotVerified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, p, ids := newSSOTestService(t)
+			ids.users["alice@example.com"] = 7
+
+			claims := map[string]any{"sub": "u-alice", "email": "Alice@Example.com"}
+			if tt.verified != nil {
+				claims["email_verified"] = tt.verified
+			}
+			state, code := startLogin(t, s, p, claims)
+			userID, err := s.authenticateSSO(context.Background(), "mock", state, state, code)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(ids.linked) != tt.wantLinks {
+				t.Fatalf("Link called %d times, want %d", len(ids.linked), tt.wantLinks)
+			}
+			if tt.wantErr == nil && userID != 7 {
+				t.Fatalf("logged in as %d, want existing user 7", userID)
+			}
+			if len(ids.users) != 1 {
+				t.Fatalf("a new user was created next to the existing one: %v", ids.users)
+			}
+		})
+	}
+}
+
+func TestSSOEmailRequired(t *testing.T) {
+	s, p, _ := newSSOTestService(t)
+
+	state, code := startLogin(t, s, p, map[string]any{"sub": "u-1"})
+	if _, err := s.authenticateSSO(context.Background(), "mock", state, state, code); !errors.Is(err, ErrSSOEmailRequired) {
+		t.Fatalf("err = %v, want ErrSSOEmailRequired", err)
+	}
+}
+
+func TestSSOInvalidTokenRejected(t *testing.T) {
+	s, p, ids := newSSOTestService(t)
+
+	state, code := startLogin(t, s, p, map[string]any{"sub": "u-1", "email": "a@example.com", "email_verified": true, "aud": "other-client"})
+	if _, err := s.authenticateSSO(context.Background(), "mock", state, state, code); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+	}
+	if len(ids.users) != 0 || len(ids.identities) != 0 {
+		t.Fatal("rejected token still created a user")
+	}
+}
+
+func TestSSORedirectURLHidesInternalErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{auth_repository.ErrLoginStateExpired, "login_expired"},
+		{ErrSSOStateMismatch, "state_mismatch"},
+		{ErrSSOEmailNotVerified, "email_not_verified"},
+		{fmt.Errorf("%w: nonce mismatch", oidc.ErrInvalidIDToken), "sso_failed"},
+		{errors.New("oidc token request failed: invalid_client secret=hunter2"), "sso_failed"},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(SSORedirectURL("", tt.err))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := u.Query().Get("error"); got != tt.code {
+			t.Errorf("%v: error code %q, want %q", tt.err, got, tt.code)
+		}
+		if strings.Contains(u.RawQuery, "hunter2") || strings.Contains(u.RawQuery, "nonce") {
+			t.Errorf("redirect leaks error text: %s", u.RawQuery)
+		}
+	}
+}
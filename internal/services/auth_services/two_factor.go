@@ -0,0 +1,259 @@
+package auth_services
+
+import (
+	"anemone_notes/internal/model/auth_model"
+	"anemone_notes/internal/repository/auth_repository"
+	"anemone_notes/internal/totp"
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	mfaChallengeTTL    = 5 * time.Minute
+	mfaChallengeType   = "mfa_challenge"
+	recoveryCodesCount = 10
+)
+
+var (
+	ErrInvalidMFACode      = errors.New("invalid two-factor code")
+	ErrInvalidMFAChallenge = errors.New("invalid or expired mfa token")
+	ErrInvalidPassword     = errors.New("invalid password")
+)
+
+// MFARequiredError возвращается из Login, когда пароль верен, но включён
+// второй фактор: вход завершается через CompleteMFALogin с этим токеном.
+type MFARequiredError struct {
+	Token string
+}
+
+func (e *MFARequiredError) Error() string {
+	return "mfa_required"
+}
+
+// TOTPSetup — данные для добавления аккаунта в приложение-аутентификатор.
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+func signMFAChallenge(userID int) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"typ":     mfaChallengeType,
+		"jti":     uuid.NewString(),
+		"exp":     time.Now().Add(mfaChallengeTTL).Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.AccessSecret))
+}
+
+func parseMFAChallenge(tokenStr string) (int, string, time.Time, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(cfg.AccessSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid || claims["typ"] != mfaChallengeType {
+		return 0, "", time.Time{}, ErrInvalidMFAChallenge
+	}
+	userID, ok := claims["user_id"].(float64)
+	jti, _ := claims["jti"].(string)
+	exp, err := claims.GetExpirationTime()
+	if !ok || jti == "" || err != nil || exp == nil {
+		return 0, "", time.Time{}, ErrInvalidMFAChallenge
+	}
+	return int(userID), jti, exp.Time, nil
+}
+
+// CompleteMFALogin — второй шаг входа: обменивает challenge-токен и код из
+// приложения (или код восстановления) на обычную пару токенов.
+func (s *AuthService) CompleteMFALogin(ctx context.Context, mfaToken, code string, client auth_model.ClientInfo) (string, string, *auth_model.User, error) {
+	userID, jti, exp, err := parseMFAChallenge(mfaToken)
+	if err != nil {
+		return "", "", nil, err
+	}
+	used, err := s.Refresh.IsAccessRevoked(ctx, jti)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if used {
+		return "", "", nil, ErrInvalidMFAChallenge
+	}
+
+	u, err := s.Users.GetByID(ctx, float64(userID))
+	if err != nil {
+		return "", "", nil, errors.New("user data not found")
+	}
+	// неверные коды считаются вместе с неверными паролями этого аккаунта
+	if err := s.checkAttempts(ctx, accountKey(u.Email), ""); err != nil {
+		return "", "", nil, err
+	}
+	if err := s.verifySecondFactor(ctx, userID, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.loginFailed(ctx, u, u.Email, nil)
+		}
+		return "", "", nil, err
+	}
+	// challenge одноразовый
+	if err := s.Refresh.DenyAccess(ctx, jti, exp); err != nil {
+		return "", "", nil, err
+	}
+	if err := s.Attempts.Reset(ctx, accountKey(u.Email)); err != nil {
+		log.Printf("ERROR resetting login attempts: %v", err)
+	}
+	access, refresh, err := s.generateTokens(ctx, u, client)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return access, refresh, u, nil
+}
+
+// verifySecondFactor принимает шестизначный TOTP-код или код восстановления.
+func (s *AuthService) verifySecondFactor(ctx context.Context, userID int, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		t, err := s.TOTP.Get(ctx, userID)
+		if err != nil {
+			if errors.Is(err, auth_repository.ErrTOTPNotFound) {
+				return ErrInvalidMFACode
+			}
+			return err
+		}
+		step, ok := totp.Validate(t.Secret, code, time.Now())
+		if !ok {
+			return ErrInvalidMFACode
+		}
+		fresh, err := s.TOTP.UseStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	ok, err := s.TOTP.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// SetupTOTP выдаёт новый секрет. Второй фактор заработает только после ConfirmTOTP.
+func (s *AuthService) SetupTOTP(ctx context.Context, userID int) (*TOTPSetup, error) {
+	u, err := s.Users.GetByID(ctx, float64(userID))
+	if err != nil {
+		return nil, errors.New("user data not found")
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.TOTP.SavePending(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+	return &TOTPSetup{Secret: secret, URI: totp.URI(cfg.TOTPIssuer, u.Email, secret)}, nil
+}
+
+// ConfirmTOTP включает второй фактор по первому коду из приложения и
+// возвращает коды восстановления. Они показываются один раз.
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userID int, code string) ([]string, error) {
+	t, err := s.TOTP.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if t.ConfirmedAt.Valid {
+		return nil, auth_repository.ErrTOTPAlreadyEnabled
+	}
+	step, ok := totp.Validate(t.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.TOTP.Confirm(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP выключает второй фактор. Требует пароль (без пароля — свежую
+// сессию) и действующий код, чтобы украденного access-токена для этого было
+// недостаточно. Неверные пароль и код считаются попытками входа.
+func (s *AuthService) DisableTOTP(ctx context.Context, userID int, sessionID, password, code string) error {
+	u, err := s.Users.GetByID(ctx, float64(userID))
+	if err != nil {
+		return errors.New("user data not found")
+	}
+	hash, err := s.Users.GetPasswordHash(ctx, userID)
+	if err != nil {
+		return errors.New("user data not found")
+	}
+	if err := s.checkAttempts(ctx, accountKey(u.Email), ""); err != nil {
+		return err
+	}
+
+	if hash != "" {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(strings.TrimSpace(password))) != nil {
+			s.loginFailed(ctx, u, u.Email, nil)
+			return ErrInvalidPassword
+		}
+	} else if err := s.checkRecentLogin(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	enabled, err := s.TOTP.IsEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return auth_repository.ErrTOTPNotFound
+	}
+	if err := s.verifySecondFactor(ctx, userID, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.loginFailed(ctx, u, u.Email, nil)
+		}
+		return err
+	}
+	if err := s.Attempts.Reset(ctx, accountKey(u.Email)); err != nil {
+		log.Printf("ERROR resetting login attempts: %v", err)
+	}
+	return s.TOTP.Delete(ctx, userID)
+}
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newRecoveryCodes генерирует коды вида abcd-efgh-ijkl и их хэши для базы.
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		raw := make([]byte, 8)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		plain := strings.ToLower(recoveryEncoding.EncodeToString(raw))[:12]
+		codes = append(codes, plain[:4]+"-"+plain[4:8]+"-"+plain[8:])
+		hashes = append(hashes, hashToken(plain))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,101 @@
+package auth_services
+
+import (
+	"context"
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// Коды ошибок полей, кроме кодов политики паролей из пакета passwords
+const (
+	CodeRequired = "required"
+	CodeInvalid  = "invalid"
+	CodeTaken    = "taken"
+	CodeNotFound = "not_found"
+	CodeNoChange = "unchanged"
+)
+
+const (
+	maxEmailLength     = 254
+	maxEmailLocalBytes = 64
+)
+
+// Домен только в ASCII (IDN — в виде punycode) и хотя бы с одной точкой
+var emailDomainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z0-9-]{2,63}$`)
+
+// FieldError — ошибка в одном поле запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError собирает все ошибки запроса сразу, чтобы клиент мог
+// подсветить каждое поле, а не исправлять их по одному.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) add(field, code, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+// err возвращает nil, если ошибок нет: так его можно вернуть как error без
+// ловушки с типизированным nil.
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// NormalizeEmail приводит адрес к виду, в котором он хранится: без пробелов
+// по краям и в нижнем регистре. Второе значение — код ошибки, если адрес
+// некорректен.
+func NormalizeEmail(raw string) (string, string) {
+	email := strings.ToLower(strings.TrimSpace(raw))
+	if email == "" {
+		return "", CodeRequired
+	}
+	if len(email) > maxEmailLength {
+		return "", CodeInvalid
+	}
+	// только голый адрес: без имени и угловых скобок
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "", CodeInvalid
+	}
+	local, domain, _ := strings.Cut(email, "@")
+	if len(local) > maxEmailLocalBytes || !emailDomainPattern.MatchString(domain) {
+		return "", CodeInvalid
+	}
+	return email, ""
+}
+
+// checkEmail нормализует адрес поля field и записывает ошибку в v.
+func checkEmail(v *ValidationError, field, raw string) string {
+	email, code := NormalizeEmail(raw)
+	switch code {
+	case CodeRequired:
+		v.add(field, code, "email is required")
+	case CodeInvalid:
+		v.add(field, code, "enter a valid email address")
+	}
+	return email
+}
+
+// checkPassword проверяет новый пароль по политике и записывает нарушения в v.
+func (s *AuthService) checkPassword(ctx context.Context, v *ValidationError, field, password, email string) {
+	for _, violation := range s.Passwords.Check(ctx, password, email) {
+		v.add(field, violation.Code, violation.Message)
+	}
+}
@@ -0,0 +1,190 @@
+package auth_services
+
+import (
+	"anemone_notes/internal/mailer"
+	"anemone_notes/internal/model/auth_model"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Политики для аккаунтов с неподтверждённой почтой (UNVERIFIED_POLICY)
+const (
+	UnverifiedAllow    = "allow"
+	UnverifiedReadOnly = "read_only"
+	UnverifiedBlock    = "block"
+)
+
+var ErrEmailNotVerified = errors.New("email is not verified")
+
+// newToken возвращает токен для письма и его sha256 для хранения в базе.
+func newToken() (string, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func appLink(path, token string) string {
+	return strings.TrimRight(cfg.AppBaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// isRestricted — почта не подтверждена и льготный период после регистрации истёк.
+func (s *AuthService) isRestricted(u *auth_model.User) bool {
+	if u.EmailVerifiedAt.Valid || cfg.UnverifiedPolicy == UnverifiedAllow {
+		return false
+	}
+	grace := time.Duration(cfg.UnverifiedGraceHours) * time.Hour
+	return time.Since(u.CreatedAt) > grace
+}
+
+// AllowUnverified решает, пропускать ли запрос с ограниченным токеном.
+// В режиме read_only разрешено только чтение.
+func (s *AuthService) AllowUnverified(method string) bool {
+	switch cfg.UnverifiedPolicy {
+	case UnverifiedAllow:
+		return true
+	case UnverifiedBlock:
+		return false
+	}
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func (s *AuthService) sendVerification(ctx context.Context, u *auth_model.User) error {
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+	expires := time.Now().Add(time.Duration(cfg.EmailVerifyTTLHours) * time.Hour)
+	if err := s.Tokens.Create(ctx, u.ID, auth_model.TokenVerifyEmail, hash, expires); err != nil {
+		return err
+	}
+	return s.Mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Confirm your email",
+		Text: fmt.Sprintf("Welcome to Anemone!\n\nConfirm your email by opening this link:\n%s\n\nThe link is valid for %d hours.\n",
+			appLink("/verify-email", token), cfg.EmailVerifyTTLHours),
+	})
+}
+
+// ResendVerification отправляет новую ссылку. Ответ не зависит от того,
+// есть ли такой аккаунт, чтобы по нему нельзя было перебирать адреса.
+func (s *AuthService) ResendVerification(ctx context.Context, email string) error {
+	u, err := s.Users.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil || u.EmailVerifiedAt.Valid {
+		return nil
+	}
+	if err := s.sendVerification(ctx, u); err != nil {
+		log.Printf("ERROR sending verification email to user %d: %v", u.ID, err)
+	}
+	return nil
+}
+
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := s.Tokens.Consume(ctx, auth_model.TokenVerifyEmail, hashToken(strings.TrimSpace(token)))
+	if err != nil {
+		return err
+	}
+	return s.Users.MarkEmailVerified(ctx, userID)
+}
+
+// RequestPasswordReset отправляет одноразовую ссылку для сброса пароля.
+// Как и ResendVerification, молча ничего не делает для неизвестного адреса.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) error {
+	u, err := s.Users.GetByEmail(ctx, strings.TrimSpace(email))
+	if err != nil {
+		return nil
+	}
+
+	token, hash, err := newToken()
+	if err != nil {
+		return err
+	}
+	expires := time.Now().Add(time.Duration(cfg.PasswordResetTTLMinutes) * time.Minute)
+	if err := s.Tokens.Create(ctx, u.ID, auth_model.TokenResetPassword, hash, expires); err != nil {
+		return err
+	}
+	err = s.Mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Text: fmt.Sprintf("Someone requested a password reset for your Anemone account.\n\nOpen this link to choose a new password:\n%s\n\nThe link is valid for %d minutes and works once. If it wasn't you, ignore this email.\n",
+			appLink("/reset-password", token), cfg.PasswordResetTTLMinutes),
+	})
+	if err != nil {
+		log.Printf("ERROR sending password reset email to user %d: %v", u.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword меняет пароль по токену из письма, завершает все сессии
+// и отзывает персональные токены.
+// Переход по ссылке из письма заодно подтверждает почту.
+func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	newPassword = strings.TrimSpace(newPassword)
+	tokenHash := hashToken(strings.TrimSpace(token))
+
+	// пароль проверяется до того, как токен израсходован: с неподходящим
+	// паролем ссылкой из письма можно воспользоваться ещё раз
+	userID, err := s.Tokens.Peek(ctx, auth_model.TokenResetPassword, tokenHash)
+	if err != nil {
+		return err
+	}
+	u, err := s.Users.GetByID(ctx, float64(userID))
+	if err != nil {
+		return errors.New("user data not found")
+	}
+	v := &ValidationError{}
+	s.checkPassword(ctx, v, "new_password", newPassword, u.Email)
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	if _, err := s.Tokens.Consume(ctx, auth_model.TokenResetPassword, tokenHash); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	if err := s.Users.UpdatePassword(ctx, userID, string(hash)); err != nil {
+		return err
+	}
+	if err := s.Users.MarkEmailVerified(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.Refresh.DeleteAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.PersonalTokens.DeleteAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	// сброс пароля по почте — такое же доказательство владения, как ссылка разблокировки
+	if err := s.Attempts.Reset(ctx, accountKey(u.Email)); err != nil {
+		log.Printf("ERROR resetting login attempts: %v", err)
+	}
+	return nil
+}
+
+// CleanupTokens удаляет отработавшие токены из писем.
+func (s *AuthService) CleanupTokens(ctx context.Context) (int64, error) {
+	return s.Tokens.DeleteStale(ctx)
+}
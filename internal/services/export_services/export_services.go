@@ -0,0 +1,163 @@
+package export_services
+
+import (
+	"anemone_notes/internal/model/export_model"
+	"anemone_notes/internal/repository/export_repository"
+	"anemone_notes/internal/storage"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrExportNotReady = errors.New("export is not ready")
+
+// Сколько выгрузок собирать за один запуск задачи
+const exportsPerRun = 10
+
+type ExportService struct {
+	Repo  *export_repository.ExportRepo
+	Store storage.Storage
+	TTL   time.Duration
+}
+
+func NewExportService(r *export_repository.ExportRepo, s storage.Storage, ttl time.Duration) *ExportService {
+	return &ExportService{Repo: r, Store: s, TTL: ttl}
+}
+
+// RequestExport ставит выгрузку в очередь; сам архив соберёт ProcessPending.
+func (s *ExportService) RequestExport(ctx context.Context, userID int) (*export_model.Export, error) {
+	return s.Repo.Create(ctx, userID)
+}
+
+func (s *ExportService) GetExport(ctx context.Context, userID int, id string) (*export_model.Export, error) {
+	if uuid.Validate(id) != nil {
+		return nil, export_repository.ErrExportNotFound
+	}
+	return s.Repo.GetUserExport(ctx, userID, id)
+}
+
+// Open отдаёт готовый архив. Вызывающий закрывает поток.
+func (s *ExportService) Open(ctx context.Context, userID int, id string) (*export_model.Export, io.ReadCloser, error) {
+	e, err := s.GetExport(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.Status != export_model.StatusReady || e.StorageKey == nil {
+		return nil, nil, ErrExportNotReady
+	}
+	if e.ExpiresAt != nil && e.ExpiresAt.Before(time.Now()) {
+		return nil, nil, export_repository.ErrExportNotFound
+	}
+	body, err := s.Store.Get(ctx, *e.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return e, body, nil
+}
+
+// ProcessPending собирает выгрузки из очереди и возвращает число готовых.
+func (s *ExportService) ProcessPending(ctx context.Context) (int, error) {
+	done := 0
+	for i := 0; i < exportsPerRun; i++ {
+		e, err := s.Repo.ClaimNext(ctx)
+		if err != nil {
+			return done, err
+		}
+		if e == nil {
+			return done, nil
+		}
+
+		if err := s.build(ctx, e); err != nil {
+			log.Printf("ERROR building data export %s: %v", e.ID, err)
+			if err := s.Repo.MarkFailed(ctx, e.ID, "failed to build export", time.Now().Add(s.TTL)); err != nil {
+				return done, err
+			}
+			continue
+		}
+		done++
+	}
+	return done, nil
+}
+
+// build пишет архив во временный файл, а не в память: у активных
+// пользователей заметок может быть много.
+func (s *ExportService) build(ctx context.Context, e *export_model.Export) error {
+	if e.UserID == nil {
+		return errors.New("export has no owner")
+	}
+	sections, err := s.Repo.CollectUserData(ctx, *e.UserID)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp("", "anemone-export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	zw := zip.NewWriter(tmp)
+	for _, section := range sections {
+		f, err := zw.Create(section.Name + ".json")
+		if err != nil {
+			return err
+		}
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, section.Data, "", "  "); err != nil {
+			return fmt.Errorf("invalid %s data: %w", section.Name, err)
+		}
+		if _, err := pretty.WriteTo(f); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	key := "exports/" + e.ID + ".zip"
+	if err := s.Store.Put(ctx, key, tmp, size, "application/zip"); err != nil {
+		return err
+	}
+	return s.Repo.MarkReady(ctx, e.ID, key, size, time.Now().Add(s.TTL))
+}
+
+// CleanupExpired удаляет просроченные архивы и архивы удалённых аккаунтов.
+func (s *ExportService) CleanupExpired(ctx context.Context) (int, error) {
+	exports, err := s.Repo.GetExpired(ctx, 500)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, e := range exports {
+		if e.StorageKey != nil {
+			if err := s.Store.Delete(ctx, *e.StorageKey); err != nil {
+				log.Printf("WARN: failed to remove export file %s: %v", *e.StorageKey, err)
+				continue
+			}
+		}
+		if err := s.Repo.Delete(ctx, e.ID); err != nil {
+			log.Printf("WARN: failed to delete export %s: %v", e.ID, err)
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,66 @@
+package notes_services
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"anemone_notes/internal/repository/notes_repository"
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrInvalidBlockType  = errors.New("unknown block type")
+	ErrInvalidBlockProps = errors.New("block props must be a JSON object")
+)
+
+type BlockService struct {
+	Repo *notes_repository.BlockRepo
+}
+
+func NewBlockService(r *notes_repository.BlockRepo) *BlockService {
+	return &BlockService{Repo: r}
+}
+
+func validateBlockProps(props []byte) error {
+	if len(props) == 0 {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(props, &m); err != nil || m == nil {
+		return ErrInvalidBlockProps
+	}
+	return nil
+}
+
+func (s *BlockService) GetPageBlocks(ctx context.Context, userID, pageID int) ([]*notes_model.Block, error) {
+	return s.Repo.GetPageBlocks(ctx, userID, pageID)
+}
+
+func (s *BlockService) InsertBlock(ctx context.Context, userID, pageID int, blockType, content string, props []byte, position int) (*notes_model.Block, error) {
+	if !notes_model.IsValidBlockType(blockType) {
+		return nil, ErrInvalidBlockType
+	}
+	if err := validateBlockProps(props); err != nil {
+		return nil, err
+	}
+	b := &notes_model.Block{Type: blockType, Content: content, Props: props, Position: position}
+	return s.Repo.InsertBlock(ctx, userID, pageID, b)
+}
+
+func (s *BlockService) UpdateBlock(ctx context.Context, userID, pageID, blockID int, upd notes_repository.BlockUpdate) (*notes_model.Block, error) {
+	if upd.Type != nil && !notes_model.IsValidBlockType(*upd.Type) {
+		return nil, ErrInvalidBlockType
+	}
+	if err := validateBlockProps(upd.Props); err != nil {
+		return nil, err
+	}
+	return s.Repo.UpdateBlock(ctx, userID, pageID, blockID, upd)
+}
+
+func (s *BlockService) MoveBlock(ctx context.Context, userID, pageID, blockID, position int) (*notes_model.Block, error) {
+	return s.Repo.MoveBlock(ctx, userID, pageID, blockID, position)
+}
+
+func (s *BlockService) DeleteBlock(ctx context.Context, userID, pageID, blockID int) error {
+	return s.Repo.DeleteBlock(ctx, userID, pageID, blockID)
+}
@@ -14,8 +14,8 @@ func NewFolderService(fr *notes_repository.FolderRepo) *FolderService {
 	return &FolderService{FolderRepo: fr}
 }
 
-func (s *FolderService) CreateFolder(ctx context.Context, user_id int, title string) (*notes_model.Folder, error) {
-	p := &notes_model.Folder{UserID: user_id, Title: title}
+func (s *FolderService) CreateFolder(ctx context.Context, user_id int, title string, parentFolderID *int) (*notes_model.Folder, error) {
+	p := &notes_model.Folder{UserID: user_id, Title: title, ParentFolderID: toNullInt(parentFolderID)}
 	res, err := s.FolderRepo.CreateFolder(ctx, p)
 	if err != nil {
 		return nil, err
@@ -31,6 +31,18 @@ func (s *FolderService) UpdateTitleFolder(ctx context.Context, id int, newTitle
 	return s.FolderRepo.UpdateTitleFolder(ctx, id, newTitle)
 }
 
-func (s *FolderService) DeleteFolders(ctx context.Context, id int) error {
-	return s.FolderRepo.DeleteFolderByID(ctx, id)
+func (s *FolderService) GetFolderTree(ctx context.Context, userID int) ([]*notes_model.FolderNode, error) {
+	return s.FolderRepo.GetFolderTree(ctx, userID)
+}
+
+func (s *FolderService) MoveFolder(ctx context.Context, userID, folderID int, newParentID *int, position int) (*notes_model.Folder, error) {
+	return s.FolderRepo.MoveFolder(ctx, userID, folderID, toNullInt(newParentID), position)
+}
+
+// DeleteFolders по умолчанию переносит содержимое папки в родительскую
+func (s *FolderService) DeleteFolders(ctx context.Context, userID, id int, strategy string) error {
+	if strategy == "" {
+		strategy = notes_model.FolderDeleteMove
+	}
+	return s.FolderRepo.DeleteFolderByID(ctx, userID, id, strategy)
 }
@@ -4,6 +4,7 @@ import (
 	"anemone_notes/internal/model/notes_model"
 	"anemone_notes/internal/repository/notes_repository"
 	"context"
+	"database/sql"
 )
 
 type PageService struct {
@@ -14,8 +15,15 @@ func NewPageService(r *notes_repository.PageRepo) *PageService {
 	return &PageService{Repo: r}
 }
 
-func (s *PageService) CreatePage(ctx context.Context, userID int, title, content string) (*notes_model.Page, error) {
-	p := &notes_model.Page{UserID: userID, Title: title, Content: content}
+func toNullInt(v *int) sql.NullInt64 {
+	if v == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*v), Valid: true}
+}
+
+func (s *PageService) CreatePage(ctx context.Context, userID int, title, content string, parentPageID *int) (*notes_model.Page, error) {
+	p := &notes_model.Page{UserID: userID, Title: title, Content: content, ParentPageID: toNullInt(parentPageID)}
 	res, err := s.Repo.CreateNote(ctx, p)
 	if err != nil {
 		return nil, err
@@ -27,14 +35,33 @@ func (s *PageService) GetPage(ctx context.Context, id int) (*notes_model.Page, e
 	return s.Repo.GetOneNoteByID(ctx, id)
 }
 
-func (s *PageService) GetAllPages(ctx context.Context, user_id int) ([]*notes_model.Page, error) {
-	return s.Repo.GetAll(ctx, user_id)
+func (s *PageService) GetPageWithBlocks(ctx context.Context, id int) (*notes_model.PageWithBlocks, error) {
+	return s.Repo.GetPageWithBlocks(ctx, id)
+}
+
+func (s *PageService) GetAllPages(ctx context.Context, user_id int, includeDeleted bool) ([]*notes_model.Page, error) {
+	return s.Repo.GetAll(ctx, user_id, includeDeleted)
+}
+
+// GetPagesByTags — matchAll требует все теги, иначе достаточно любого.
+// Повторяющиеся id тегов не влияют на режим all.
+func (s *PageService) GetPagesByTags(ctx context.Context, userID int, tagIDs []int, matchAll, includeDeleted bool) ([]*notes_model.Page, error) {
+	seen := make(map[int]bool, len(tagIDs))
+	unique := make([]int, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return s.Repo.GetAllByTags(ctx, userID, unique, matchAll, includeDeleted)
 }
 
-func (s *PageService) UpdateTitle(ctx context.Context, id int, new_title string) (*notes_model.Page, error) {
-	return s.Repo.UpdateTitleByID(ctx, id,new_title);
+func (s *PageService) UpdateTitle(ctx context.Context, userID, id int, new_title string, rewriteLinks bool) (*notes_model.Page, error) {
+	return s.Repo.UpdateTitleByID(ctx, userID, id, new_title, rewriteLinks)
 }
 
+// UpdateContent сохраняет текст; ссылки [[...]] из него индексируются в той же транзакции.
 func (s *PageService) UpdateContent(ctx context.Context, id int, new_content string) (*notes_model.Page, error) {
 	return s.Repo.UpdateNoteByID(ctx, id, new_content)
 }
@@ -77,4 +104,44 @@ func (s *PageService) UnmarkDeletedAllNotes(ctx context.Context, userID int) err
 
 func (s *PageService) DeleteAllMarkNotes(ctx context.Context, userID int) error {
 	return s.Repo.DeleteAllMarkNotes(ctx, userID)
-}
\ No newline at end of file
+}
+
+func (s *PageService) GetTrash(ctx context.Context, userID int) ([]*notes_model.TrashItem, error) {
+	return s.Repo.GetTrash(ctx, userID)
+}
+
+func (s *PageService) PurgeTrashedNote(ctx context.Context, userID, noteID int) error {
+	return s.Repo.PurgeTrashedNote(ctx, userID, noteID)
+}
+
+// PurgeExpiredTrash — retentionDays <= 0 отключает автоочистку корзины.
+func (s *PageService) PurgeExpiredTrash(ctx context.Context, retentionDays int) (int64, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+	return s.Repo.PurgeExpiredTrash(ctx, retentionDays)
+}
+
+func (s *PageService) GetTree(ctx context.Context, userID int, rootID *int) ([]*notes_model.PageNode, error) {
+	return s.Repo.GetTree(ctx, userID, toNullInt(rootID))
+}
+
+func (s *PageService) GetBreadcrumbs(ctx context.Context, userID, pageID int) ([]*notes_model.Breadcrumb, error) {
+	return s.Repo.GetBreadcrumbs(ctx, userID, pageID)
+}
+
+func (s *PageService) MovePage(ctx context.Context, userID, pageID int, newParentID *int, position int) (*notes_model.Page, error) {
+	return s.Repo.MovePage(ctx, userID, pageID, toNullInt(newParentID), position)
+}
+
+func (s *PageService) GetPageLinks(ctx context.Context, userID, pageID int) ([]*notes_model.PageLink, error) {
+	return s.Repo.GetPageLinks(ctx, userID, pageID)
+}
+
+func (s *PageService) GetBacklinks(ctx context.Context, userID, pageID int) ([]*notes_model.Backlink, error) {
+	return s.Repo.GetBacklinks(ctx, userID, pageID)
+}
+
+func (s *PageService) GetBrokenLinks(ctx context.Context, userID int) ([]*notes_model.BrokenLink, error) {
+	return s.Repo.GetBrokenLinks(ctx, userID)
+}
@@ -0,0 +1,112 @@
+package notes_services
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"anemone_notes/internal/repository/notes_repository"
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const MaxTagNameLength = 64
+
+var ErrInvalidTagName = errors.New("tag name must be 1-64 characters")
+
+type TagService struct {
+	Repo *notes_repository.TagRepo
+}
+
+func NewTagService(r *notes_repository.TagRepo) *TagService {
+	return &TagService{Repo: r}
+}
+
+func normalizeTagName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" || utf8.RuneCountInString(name) > MaxTagNameLength {
+		return "", ErrInvalidTagName
+	}
+	return name, nil
+}
+
+func (s *TagService) CreateTag(ctx context.Context, userID int, name string) (*notes_model.Tag, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.CreateTag(ctx, userID, name)
+}
+
+func (s *TagService) RenameTag(ctx context.Context, userID, tagID int, name string) (*notes_model.Tag, error) {
+	name, err := normalizeTagName(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.RenameTag(ctx, userID, tagID, name)
+}
+
+func (s *TagService) MergeTags(ctx context.Context, userID, sourceID, targetID int) (*notes_model.Tag, error) {
+	return s.Repo.MergeTags(ctx, userID, sourceID, targetID)
+}
+
+func (s *TagService) DeleteTag(ctx context.Context, userID, tagID int) error {
+	return s.Repo.DeleteTag(ctx, userID, tagID)
+}
+
+func (s *TagService) GetTags(ctx context.Context, userID int) ([]*notes_model.TagCount, error) {
+	return s.Repo.GetTagCounts(ctx, userID, false)
+}
+
+// GetTagCloud отдаёт только используемые теги, самые популярные первыми.
+func (s *TagService) GetTagCloud(ctx context.Context, userID, limit int) ([]*notes_model.TagCount, error) {
+	tags, err := s.Repo.GetTagCounts(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+	cloud := []*notes_model.TagCount{}
+	for _, t := range tags {
+		if t.Count == 0 || (limit > 0 && len(cloud) >= limit) {
+			break
+		}
+		cloud = append(cloud, t)
+	}
+	return cloud, nil
+}
+
+func (s *TagService) GetPageTags(ctx context.Context, userID, pageID int) ([]*notes_model.Tag, error) {
+	return s.Repo.GetPageTags(ctx, userID, pageID)
+}
+
+// AttachTag принимает либо id тега, либо имя; по имени тег создаётся, если его ещё нет.
+func (s *TagService) AttachTag(ctx context.Context, userID, pageID int, tagID *int, name string) (*notes_model.Tag, error) {
+	if tagID == nil {
+		name, err := normalizeTagName(name)
+		if err != nil {
+			return nil, err
+		}
+		tag, err := s.Repo.GetOrCreateTag(ctx, userID, name)
+		if err != nil {
+			return nil, err
+		}
+		tagID = &tag.ID
+	}
+
+	if err := s.Repo.AttachTag(ctx, userID, pageID, *tagID); err != nil {
+		return nil, err
+	}
+
+	tags, err := s.Repo.GetPageTags(ctx, userID, pageID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tags {
+		if t.ID == *tagID {
+			return t, nil
+		}
+	}
+	return nil, notes_repository.ErrTagNotFound
+}
+
+func (s *TagService) DetachTag(ctx context.Context, userID, pageID, tagID int) error {
+	return s.Repo.DetachTag(ctx, userID, pageID, tagID)
+}
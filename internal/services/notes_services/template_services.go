@@ -0,0 +1,179 @@
+package notes_services
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"anemone_notes/internal/repository/notes_repository"
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrInvalidTimezone = errors.New("unknown timezone")
+
+// Название папки, которая создаётся для ежедневных заметок, если её не настроили
+const defaultDailyFolder = "Daily"
+
+type TemplateService struct {
+	Pages   *notes_repository.PageRepo
+	Folders *notes_repository.FolderRepo
+	Daily   *notes_repository.DailyRepo
+	now     func() time.Time
+}
+
+func NewTemplateService(p *notes_repository.PageRepo, f *notes_repository.FolderRepo, d *notes_repository.DailyRepo) *TemplateService {
+	return &TemplateService{Pages: p, Folders: f, Daily: d, now: time.Now}
+}
+
+// templateVars — переменные шаблона, посчитанные в часовом поясе пользователя.
+// {{title}} подставляет репозиторий, когда название страницы уже известно.
+func templateVars(t time.Time) map[string]string {
+	return map[string]string{
+		"date":     t.Format("2006-01-02"),
+		"time":     t.Format("15:04"),
+		"datetime": t.Format("2006-01-02 15:04"),
+		"weekday":  t.Weekday().String(),
+	}
+}
+
+// location выбирает часовой пояс: явно переданный tz или пояс из профиля пользователя.
+func (s *TemplateService) location(ctx context.Context, userID int, tz string) (*time.Location, error) {
+	if tz == "" {
+		var err error
+		if tz, err = s.Daily.GetTimezone(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, ErrInvalidTimezone
+	}
+	return loc, nil
+}
+
+func (s *TemplateService) SetTemplate(ctx context.Context, userID, pageID int, isTemplate bool) (*notes_model.Page, error) {
+	return s.Pages.SetTemplate(ctx, userID, pageID, isTemplate)
+}
+
+func (s *TemplateService) GetTemplates(ctx context.Context, userID int) ([]*notes_model.Page, error) {
+	return s.Pages.GetTemplates(ctx, userID)
+}
+
+func (s *TemplateService) CreateFromTemplate(ctx context.Context, userID, templateID int, title string, parentPageID *int, tz string) (*notes_model.Page, error) {
+	loc, err := s.location(ctx, userID, tz)
+	if err != nil {
+		return nil, err
+	}
+	p := &notes_model.Page{UserID: userID, Title: title, ParentPageID: toNullInt(parentPageID)}
+	return s.Pages.CreateFromTemplate(ctx, p, templateID, templateVars(s.now().In(loc)))
+}
+
+func (s *TemplateService) GetDailySettings(ctx context.Context, userID int) (*notes_model.DailySettings, error) {
+	return s.Daily.GetSettings(ctx, userID)
+}
+
+func (s *TemplateService) UpdateDailySettings(ctx context.Context, userID int, folderID, templatePageID *int, tz string) (*notes_model.DailySettings, error) {
+	// Пояс общий с профилем; пустой tz его не меняет, "Local" зависит от сервера
+	if tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+			return nil, ErrInvalidTimezone
+		}
+	}
+	return s.Daily.SaveSettings(ctx, &notes_model.DailySettings{
+		UserID:         userID,
+		FolderID:       toNullInt(folderID),
+		TemplatePageID: toNullInt(templatePageID),
+		Timezone:       tz,
+	})
+}
+
+// dailyFolder возвращает папку ежедневных заметок, при необходимости создавая «Daily».
+func (s *TemplateService) dailyFolder(ctx context.Context, settings *notes_model.DailySettings) (int, error) {
+	if settings.FolderID.Valid {
+		return int(settings.FolderID.Int64), nil
+	}
+
+	folder, err := s.Folders.CreateFolder(ctx, &notes_model.Folder{UserID: settings.UserID, Title: defaultDailyFolder})
+	if err != nil {
+		return 0, err
+	}
+	ok, err := s.Daily.SetDefaultFolder(ctx, settings.UserID, folder.ID)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return folder.ID, nil
+	}
+
+	// Параллельный запрос уже создал папку — лишнюю убираем
+	if err := s.Folders.DeleteFolderByID(ctx, settings.UserID, folder.ID, notes_model.FolderDeleteMove); err != nil {
+		return 0, err
+	}
+	current, err := s.Daily.GetSettings(ctx, settings.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return int(current.FolderID.Int64), nil
+}
+
+// TodayNote находит или создаёт ежедневную заметку за сегодня по часовому поясу
+// пользователя. created сообщает, была ли страница создана этим вызовом.
+func (s *TemplateService) TodayNote(ctx context.Context, userID int, tz string) (*notes_model.Page, bool, error) {
+	loc, err := s.location(ctx, userID, tz)
+	if err != nil {
+		return nil, false, err
+	}
+	now := s.now().In(loc)
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if id, found, err := s.Daily.GetDailyPageID(ctx, userID, date); err != nil {
+		return nil, false, err
+	} else if found {
+		p, err := s.Pages.GetOneNoteByID(ctx, id)
+		return p, false, err
+	}
+
+	settings, err := s.Daily.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	folderID, err := s.dailyFolder(ctx, settings)
+	if err != nil {
+		return nil, false, err
+	}
+
+	p := &notes_model.Page{UserID: userID, Title: date.Format("2006-01-02"), FolderID: toNullInt(&folderID)}
+	created := false
+	if settings.TemplatePageID.Valid {
+		_, err = s.Pages.CreateFromTemplate(ctx, p, int(settings.TemplatePageID.Int64), templateVars(now))
+		created = err == nil
+		if errors.Is(err, notes_repository.ErrTemplateNotFound) {
+			err = nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if !created {
+		if _, err := s.Pages.CreateNote(ctx, p); err != nil {
+			return nil, false, err
+		}
+	}
+
+	claimed, err := s.Daily.ClaimDailyPage(ctx, userID, date, p.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if claimed == p.ID {
+		return p, true, nil
+	}
+
+	// Заметку за этот день успел создать параллельный запрос — свою удаляем
+	if err := s.Pages.MarkDeletedNote(ctx, p.ID); err != nil {
+		return nil, false, err
+	}
+	if err := s.Pages.PurgeTrashedNote(ctx, userID, p.ID); err != nil {
+		return nil, false, err
+	}
+	existing, err := s.Pages.GetOneNoteByID(ctx, claimed)
+	return existing, false, err
+}
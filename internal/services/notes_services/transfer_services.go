@@ -0,0 +1,584 @@
+package notes_services
+
+import (
+	"anemone_notes/internal/model/notes_model"
+	"anemone_notes/internal/repository/notes_repository"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+const (
+	ExportMarkdown = "markdown"
+	ExportHTML     = "html"
+	ExportJSON     = "json"
+
+	ExportScopePage    = "page"
+	ExportScopeFolder  = "folder"
+	ExportScopeAccount = "account"
+)
+
+const (
+	maxImportFiles     = 5000
+	maxImportFileBytes = 5 << 20
+	maxImportTotal     = 100 << 20
+)
+
+var (
+	ErrUnknownExportFormat = errors.New("unknown export format, expected markdown, html or json")
+	ErrInvalidImportZip    = errors.New("import must be a zip archive")
+	ErrImportTooLarge      = errors.New("import archive is too large")
+)
+
+type TransferService struct {
+	Pages   *notes_repository.PageRepo
+	Folders *notes_repository.FolderRepo
+}
+
+func NewTransferService(p *notes_repository.PageRepo, f *notes_repository.FolderRepo) *TransferService {
+	return &TransferService{Pages: p, Folders: f}
+}
+
+// ExportFile — готовый к отдаче файл экспорта.
+type ExportFile struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Export выгружает страницу, папку (с вложенными папками и подстраницами)
+// или весь аккаунт. Страница отдаётся одним файлом, папка и аккаунт в markdown
+// и html — zip-архивом с такой же структурой папок.
+func (s *TransferService) Export(ctx context.Context, userID int, scope string, id int, format string) (*ExportFile, error) {
+	if format != ExportMarkdown && format != ExportHTML && format != ExportJSON {
+		return nil, ErrUnknownExportFormat
+	}
+
+	folders, pages, name, err := s.collectExport(ctx, userID, scope, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == ExportJSON {
+		data, err := json.MarshalIndent(&notes_model.NotesExport{ExportedAt: time.Now().UTC(), Folders: folders, Pages: pages}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return &ExportFile{Name: name + ".json", ContentType: "application/json", Data: data}, nil
+	}
+
+	if scope == ExportScopePage {
+		if format == ExportMarkdown {
+			return &ExportFile{Name: name + ".md", ContentType: "text/markdown; charset=utf-8", Data: []byte(pageMarkdown(pages[0]))}, nil
+		}
+		return &ExportFile{Name: name + ".html", ContentType: "text/html; charset=utf-8", Data: []byte(pageHTML(pages[0]))}, nil
+	}
+
+	data, err := buildExportZip(folders, pages, format)
+	if err != nil {
+		return nil, err
+	}
+	return &ExportFile{Name: name + ".zip", ContentType: "application/zip", Data: data}, nil
+}
+
+func (s *TransferService) collectExport(ctx context.Context, userID int, scope string, id int) ([]*notes_model.Folder, []*notes_model.PageWithBlocks, string, error) {
+	allFolders, err := s.Folders.GetAllFolders(ctx, userID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	allPages, err := s.Pages.GetUserPagesWithBlocks(ctx, userID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	switch scope {
+	case ExportScopeAccount:
+		if allFolders == nil {
+			allFolders = []*notes_model.Folder{}
+		}
+		return allFolders, allPages, "notes-export", nil
+
+	case ExportScopePage:
+		for _, p := range allPages {
+			if p.ID == id {
+				return []*notes_model.Folder{}, []*notes_model.PageWithBlocks{p}, safeFileName(p.Title), nil
+			}
+		}
+		return nil, nil, "", notes_repository.ErrPageNotFound
+
+	case ExportScopeFolder:
+		var root *notes_model.Folder
+		for _, f := range allFolders {
+			if f.ID == id {
+				root = f
+			}
+		}
+		if root == nil {
+			return nil, nil, "", notes_repository.ErrFolderNotFound
+		}
+
+		// Папки поддерева
+		included := map[int]bool{root.ID: true}
+		folders := []*notes_model.Folder{root}
+		for changed := true; changed; {
+			changed = false
+			for _, f := range allFolders {
+				if !included[f.ID] && f.ParentFolderID.Valid && included[int(f.ParentFolderID.Int64)] {
+					included[f.ID] = true
+					folders = append(folders, f)
+					changed = true
+				}
+			}
+		}
+
+		// Страницы из этих папок вместе с их подстраницами
+		pageIncluded := make(map[int]bool)
+		var pages []*notes_model.PageWithBlocks
+		for changed := true; changed; {
+			changed = false
+			for _, p := range allPages {
+				if pageIncluded[p.ID] {
+					continue
+				}
+				inFolder := p.FolderID.Valid && included[int(p.FolderID.Int64)]
+				underPage := p.ParentPageID.Valid && pageIncluded[int(p.ParentPageID.Int64)]
+				if inFolder || underPage {
+					pageIncluded[p.ID] = true
+					pages = append(pages, p)
+					changed = true
+				}
+			}
+		}
+		if pages == nil {
+			pages = []*notes_model.PageWithBlocks{}
+		}
+		return folders, pages, safeFileName(root.Title), nil
+	}
+
+	return nil, nil, "", fmt.Errorf("unknown export scope %q", scope)
+}
+
+var unsafeNameChars = regexp.MustCompile(`[/\\:*?"<>|\x00-\x1f]+`)
+
+func safeFileName(title string) string {
+	name := strings.Trim(unsafeNameChars.ReplaceAllString(title, "-"), " .")
+	if name == "" {
+		name = "Untitled"
+	}
+	if utf8.RuneCountInString(name) > 100 {
+		name = strings.TrimSpace(string([]rune(name)[:100]))
+	}
+	return name
+}
+
+// exportPaths раскладывает папки и страницы по каталогам архива. Подстраницы
+// лежат в каталоге с именем родительской страницы, как в экспорте Notion.
+// Имена папок и страниц в одном каталоге не повторяются.
+type exportPaths struct {
+	used    map[string]map[string]bool
+	folders map[int]string
+	pages   map[int]string
+}
+
+func (e *exportPaths) unique(dir, name string) string {
+	if e.used[dir] == nil {
+		e.used[dir] = make(map[string]bool)
+	}
+	key := strings.ToLower(name)
+	candidate := name
+	for i := 2; e.used[dir][key]; i++ {
+		candidate = fmt.Sprintf("%s (%d)", name, i)
+		key = strings.ToLower(candidate)
+	}
+	e.used[dir][key] = true
+	return candidate
+}
+
+func buildExportPaths(folders []*notes_model.Folder, pages []*notes_model.PageWithBlocks) *exportPaths {
+	e := &exportPaths{used: make(map[string]map[string]bool), folders: make(map[int]string), pages: make(map[int]string)}
+
+	folderByID := make(map[int]*notes_model.Folder, len(folders))
+	for _, f := range folders {
+		folderByID[f.ID] = f
+	}
+	var folderPath func(f *notes_model.Folder) string
+	folderPath = func(f *notes_model.Folder) string {
+		if p, ok := e.folders[f.ID]; ok {
+			return p
+		}
+		dir := ""
+		if parent, ok := folderByID[int(f.ParentFolderID.Int64)]; ok && f.ParentFolderID.Valid {
+			dir = folderPath(parent)
+		}
+		p := path.Join(dir, e.unique(dir, safeFileName(f.Title)))
+		e.folders[f.ID] = p
+		return p
+	}
+	for _, f := range folders {
+		folderPath(f)
+	}
+
+	pageByID := make(map[int]*notes_model.PageWithBlocks, len(pages))
+	for _, p := range pages {
+		pageByID[p.ID] = p
+	}
+	var pagePath func(p *notes_model.PageWithBlocks) string
+	pagePath = func(p *notes_model.PageWithBlocks) string {
+		if pp, ok := e.pages[p.ID]; ok {
+			return pp
+		}
+		dir := ""
+		if parent, ok := pageByID[int(p.ParentPageID.Int64)]; ok && p.ParentPageID.Valid {
+			dir = pagePath(parent)
+		} else if p.FolderID.Valid {
+			dir = e.folders[int(p.FolderID.Int64)]
+		}
+		pp := path.Join(dir, e.unique(dir, safeFileName(p.Title)))
+		e.pages[p.ID] = pp
+		return pp
+	}
+	for _, p := range pages {
+		pagePath(p)
+	}
+	return e
+}
+
+func buildExportZip(folders []*notes_model.Folder, pages []*notes_model.PageWithBlocks, format string) ([]byte, error) {
+	paths := buildExportPaths(folders, pages)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	// Каталоги пишутся отдельными записями, чтобы не потерять пустые папки
+	for _, f := range folders {
+		if _, err := zw.Create(paths.folders[f.ID] + "/"); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, p := range pages {
+		name, body := paths.pages[p.ID]+".md", pageMarkdown(p)
+		if format == ExportHTML {
+			name, body = paths.pages[p.ID]+".html", pageHTML(p)
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: p.UpdatedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func pageMarkdown(p *notes_model.PageWithBlocks) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	b.WriteString("title: " + strconv.Quote(p.Title) + "\n")
+	b.WriteString("created: " + p.CreatedAt.UTC().Format(time.RFC3339) + "\n")
+	b.WriteString("updated: " + p.UpdatedAt.UTC().Format(time.RFC3339) + "\n")
+	b.WriteString("---\n\n")
+	b.WriteString(p.Content)
+	if !strings.HasSuffix(p.Content, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func pageHTML(p *notes_model.PageWithBlocks) string {
+	body := bluemonday.UGCPolicy().Sanitize(renderBlocksHTML(p.Blocks))
+	title := html.EscapeString(p.Title)
+	return "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>" + title + "</title>\n</head>\n<body>\n<h1>" +
+		title + "</h1>\n" + body + "\n</body>\n</html>\n"
+}
+
+func renderBlocksHTML(blocks []*notes_model.Block) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		props := map[string]any{}
+		if len(block.Props) > 0 {
+			_ = json.Unmarshal(block.Props, &props)
+		}
+		url, _ := props["url"].(string)
+		text := html.EscapeString(block.Content)
+
+		switch block.Type {
+		case notes_model.BlockHeading:
+			level := 1
+			if l, ok := props["level"].(float64); ok && l >= 1 && l <= 6 {
+				level = int(l)
+			}
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, text, level)
+		case notes_model.BlockTodo:
+			mark := "☐"
+			if checked, _ := props["checked"].(bool); checked {
+				mark = "☑"
+			}
+			fmt.Fprintf(&b, "<p>%s %s</p>\n", mark, text)
+		case notes_model.BlockCode:
+			lang, _ := props["language"].(string)
+			fmt.Fprintf(&b, "<pre><code class=\"language-%s\">%s</code></pre>\n", html.EscapeString(lang), text)
+		case notes_model.BlockQuote:
+			fmt.Fprintf(&b, "<blockquote>%s</blockquote>\n", strings.ReplaceAll(text, "\n", "<br>"))
+		case notes_model.BlockImage:
+			fmt.Fprintf(&b, "<img src=\"%s\" alt=\"%s\">\n", html.EscapeString(url), text)
+		case notes_model.BlockDivider:
+			b.WriteString("<hr>\n")
+		case notes_model.BlockEmbed:
+			if url == "" {
+				url = block.Content
+			}
+			fmt.Fprintf(&b, "<p><a href=\"%s\">%s</a></p>\n", html.EscapeString(url), html.EscapeString(url))
+		default:
+			fmt.Fprintf(&b, "<p>%s</p>\n", strings.ReplaceAll(text, "\n", "<br>"))
+		}
+	}
+	return b.String()
+}
+
+// notionID — суффикс с id страницы, который Notion дописывает к именам файлов.
+var notionID = regexp.MustCompile(`\s+[0-9a-f]{32}$`)
+
+func importTitle(name string) string {
+	title := strings.TrimSpace(notionID.ReplaceAllString(name, ""))
+	if title == "" {
+		return "Untitled"
+	}
+	return title
+}
+
+// ImportMarkdownZip разбирает zip с markdown-файлами (экспорт Obsidian или Notion)
+// и создаёт папки и страницы одной транзакцией. Каталоги становятся папками;
+// каталог рядом с одноимённым .md файлом — подстраницами этого файла.
+func (s *TransferService) ImportMarkdownZip(ctx context.Context, userID int, data []byte) (*notes_model.NotesImportReport, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalidImportZip
+	}
+	if len(zr.File) > maxImportFiles {
+		return nil, ErrImportTooLarge
+	}
+
+	report := &notes_model.NotesImportReport{Skipped: []*notes_model.SkippedFile{}}
+	skip := func(name, reason string) {
+		report.Skipped = append(report.Skipped, &notes_model.SkippedFile{Path: name, Reason: reason})
+	}
+
+	dirs := make(map[string]bool)
+	pages := make(map[string]*notes_model.ImportPage)
+	var total uint64
+
+	for _, f := range zr.File {
+		name := strings.Trim(strings.ReplaceAll(f.Name, "\\", "/"), "/")
+		if name == "" {
+			continue
+		}
+		clean := path.Clean(name)
+		if clean != name || strings.HasPrefix(clean, "../") || clean == ".." {
+			skip(f.Name, "unsafe path")
+			continue
+		}
+		if isHiddenPath(clean) {
+			skip(f.Name, "hidden or system file")
+			continue
+		}
+
+		if f.FileInfo().IsDir() {
+			dirs[clean] = true
+			continue
+		}
+
+		ext := strings.ToLower(path.Ext(clean))
+		if ext != ".md" && ext != ".markdown" {
+			skip(f.Name, "not a markdown file")
+			continue
+		}
+		if f.UncompressedSize64 > maxImportFileBytes {
+			skip(f.Name, "file too large")
+			continue
+		}
+		total += f.UncompressedSize64
+		if total > maxImportTotal {
+			return nil, ErrImportTooLarge
+		}
+
+		content, err := readZipFile(f)
+		if err != nil {
+			skip(f.Name, "cannot read file")
+			continue
+		}
+		if !utf8.Valid(content) {
+			skip(f.Name, "not valid UTF-8")
+			continue
+		}
+
+		key := strings.TrimSuffix(clean, path.Ext(clean))
+		if _, dup := pages[key]; dup {
+			skip(f.Name, "duplicate page")
+			continue
+		}
+
+		page := parseMarkdownPage(string(content))
+		page.Key = key
+		if page.Title == "" {
+			page.Title = importTitle(path.Base(key))
+		}
+		pages[key] = page
+
+		for dir := path.Dir(key); dir != "."; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+
+	// Ближайший каталог-предок, который является папкой, а не подстраницами
+	folderOf := func(dir string) string {
+		for ; dir != "." && dir != ""; dir = path.Dir(dir) {
+			if _, isPage := pages[dir]; !isPage {
+				return dir
+			}
+		}
+		return ""
+	}
+
+	imp := &notes_model.NotesImport{}
+	for dir := range dirs {
+		if _, isPage := pages[dir]; isPage {
+			continue
+		}
+		imp.Folders = append(imp.Folders, &notes_model.ImportFolder{
+			Key:       dir,
+			ParentKey: folderOf(path.Dir(dir)),
+			Title:     importTitle(path.Base(dir)),
+		})
+	}
+	sort.Slice(imp.Folders, func(i, j int) bool { return pathLess(imp.Folders[i].Key, imp.Folders[j].Key) })
+
+	for key, page := range pages {
+		dir := path.Dir(key)
+		if _, ok := pages[dir]; ok {
+			page.ParentKey = dir
+		}
+		page.FolderKey = folderOf(dir)
+		imp.Pages = append(imp.Pages, page)
+	}
+	sort.Slice(imp.Pages, func(i, j int) bool { return pathLess(imp.Pages[i].Key, imp.Pages[j].Key) })
+
+	if err := s.Pages.ImportNotes(ctx, userID, imp); err != nil {
+		return nil, err
+	}
+
+	report.Folders = len(imp.Folders)
+	report.Pages = len(imp.Pages)
+	return report, nil
+}
+
+func isHiddenPath(p string) bool {
+	for _, seg := range strings.Split(p, "/") {
+		if strings.HasPrefix(seg, ".") || seg == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// pathLess ставит родителей раньше детей: сначала по глубине, потом по имени.
+func pathLess(a, b string) bool {
+	da, db := strings.Count(a, "/"), strings.Count(b, "/")
+	if da != db {
+		return da < db
+	}
+	return a < b
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	// Размер из заголовка может врать, поэтому читаем не больше лимита
+	return io.ReadAll(io.LimitReader(rc, maxImportFileBytes))
+}
+
+// parseMarkdownPage отделяет front-matter (title, created, updated) от текста страницы.
+func parseMarkdownPage(text string) *notes_model.ImportPage {
+	page := &notes_model.ImportPage{}
+	text = strings.ReplaceAll(strings.TrimPrefix(text, "\ufeff"), "\r\n", "\n")
+
+	if !strings.HasPrefix(text, "---\n") {
+		page.Content = strings.TrimSpace(text)
+		return page
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(text))
+	sc.Buffer(make([]byte, 0, 64*1024), maxImportFileBytes)
+	sc.Scan() // открывающий ---
+	consumed := len(sc.Text()) + 1
+	closed := false
+	meta := make(map[string]string)
+	for sc.Scan() {
+		line := sc.Text()
+		consumed += len(line) + 1
+		if strings.TrimSpace(line) == "---" {
+			closed = true
+			break
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			meta[strings.ToLower(strings.TrimSpace(k))] = unquoteYAML(strings.TrimSpace(v))
+		}
+	}
+	if !closed {
+		page.Content = strings.TrimSpace(text)
+		return page
+	}
+
+	if consumed > len(text) {
+		consumed = len(text)
+	}
+	page.Content = strings.TrimSpace(text[consumed:])
+	page.Title = meta["title"]
+	page.CreatedAt = parseImportTime(meta["created"])
+	page.UpdatedAt = parseImportTime(meta["updated"])
+	return page
+}
+
+func unquoteYAML(v string) string {
+	if strings.HasPrefix(v, `"`) {
+		if u, err := strconv.Unquote(v); err == nil {
+			return u
+		}
+	}
+	if len(v) >= 2 && strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'") {
+		return strings.ReplaceAll(v[1:len(v)-1], "''", "'")
+	}
+	return v
+}
+
+func parseImportTime(v string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,76 @@
+package sidebar_services
+
+import (
+	"anemone_notes/internal/model/sidebar_model"
+	"anemone_notes/internal/repository/sidebar_repository"
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidItem = errors.New("invalid item type or id")
+
+type SidebarService struct {
+	Repo        *sidebar_repository.SidebarRepo
+	RecentLimit int
+}
+
+func NewSidebarService(r *sidebar_repository.SidebarRepo, recentLimit int) *SidebarService {
+	if recentLimit <= 0 {
+		recentLimit = 20
+	}
+	return &SidebarService{Repo: r, RecentLimit: recentLimit}
+}
+
+// validItem проверяет формат id: у страниц и папок он числовой, у досок — UUID.
+func validItem(itemType, itemID string) bool {
+	switch itemType {
+	case sidebar_model.ItemPage, sidebar_model.ItemFolder:
+		id, err := strconv.Atoi(itemID)
+		return err == nil && id > 0
+	case sidebar_model.ItemBoard:
+		return uuid.Validate(itemID) == nil
+	}
+	return false
+}
+
+func (s *SidebarService) GetSidebar(ctx context.Context, userID int) (*sidebar_model.Sidebar, error) {
+	favorites, err := s.Repo.GetFavorites(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := s.Repo.GetRecent(ctx, userID, s.RecentLimit)
+	if err != nil {
+		return nil, err
+	}
+	return &sidebar_model.Sidebar{Favorites: favorites, Recent: recent}, nil
+}
+
+func (s *SidebarService) GetFavorites(ctx context.Context, userID int) ([]*sidebar_model.Favorite, error) {
+	return s.Repo.GetFavorites(ctx, userID)
+}
+
+func (s *SidebarService) AddFavorite(ctx context.Context, userID int, itemType, itemID string, pinned bool) (*sidebar_model.Favorite, error) {
+	if !validItem(itemType, itemID) {
+		return nil, ErrInvalidItem
+	}
+	return s.Repo.AddFavorite(ctx, userID, itemType, itemID, pinned)
+}
+
+func (s *SidebarService) UpdateFavorite(ctx context.Context, userID, id int, pinned *bool, position *int) (*sidebar_model.Favorite, error) {
+	return s.Repo.UpdateFavorite(ctx, userID, id, pinned, position)
+}
+
+func (s *SidebarService) DeleteFavorite(ctx context.Context, userID, id int) error {
+	return s.Repo.DeleteFavorite(ctx, userID, id)
+}
+
+func (s *SidebarService) RecordPageView(ctx context.Context, userID, pageID int) error {
+	return s.Repo.RecordView(ctx, userID, sidebar_model.ItemPage, strconv.Itoa(pageID), s.RecentLimit)
+}
+
+func (s *SidebarService) RecordBoardView(ctx context.Context, userID int, boardID string) error {
+	return s.Repo.RecordView(ctx, userID, sidebar_model.ItemBoard, boardID, s.RecentLimit)
+}
@@ -0,0 +1,23 @@
+package trello_services
+
+import (
+	"anemone_notes/internal/model/trello_model"
+	"anemone_notes/internal/repository/trello_repository"
+	"context"
+)
+
+type ActivityService struct {
+	Repo *trello_repository.ActivityRepo
+}
+
+func NewActivityService(r *trello_repository.ActivityRepo) *ActivityService {
+	return &ActivityService{Repo: r}
+}
+
+func (s *ActivityService) GetBoardActivity(ctx context.Context, boardID string, limit, offset int) (*trello_model.ActivityPage, error) {
+	return s.Repo.GetBoardActivity(ctx, boardID, limit, offset)
+}
+
+func (s *ActivityService) GetCardActivity(ctx context.Context, columnID, cardID string, limit, offset int) (*trello_model.ActivityPage, error) {
+	return s.Repo.GetCardActivity(ctx, columnID, cardID, limit, offset)
+}
@@ -0,0 +1,248 @@
+package trello_services
+
+import (
+	"anemone_notes/internal/model/trello_model"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var ErrImportEmpty = errors.New("import contains no lists")
+
+// ExportTrello отдаёт доску в формате экспорта Trello (lists/cards).
+// Меток и чеклистов у нас нет, поэтому соответствующие массивы пустые.
+func (s *BoardService) ExportTrello(ctx context.Context, boardID string) (*trello_model.TrelloBoardExport, error) {
+	board, err := s.Repo.GetBoardForExport(ctx, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &trello_model.TrelloBoardExport{
+		ID:         board.ID,
+		Name:       board.Title,
+		Lists:      []*trello_model.TrelloList{},
+		Cards:      []*trello_model.TrelloCard{},
+		Labels:     []*trello_model.TrelloLabel{},
+		Checklists: []*trello_model.TrelloChecklist{},
+	}
+
+	for _, col := range board.Columns {
+		export.Lists = append(export.Lists, &trello_model.TrelloList{
+			ID:   col.ID,
+			Name: col.Title,
+			Pos:  float64(col.Position),
+		})
+		for _, card := range col.Cards {
+			export.Cards = append(export.Cards, &trello_model.TrelloCard{
+				ID:           card.ID,
+				Name:         card.Content,
+				IDList:       col.ID,
+				Pos:          float64(card.Position),
+				IDLabels:     []string{},
+				IDChecklists: []string{},
+			})
+		}
+	}
+
+	return export, nil
+}
+
+// ExportCSV отдаёт доску плоской таблицей: одна строка на карточку,
+// пустые колонки попадают в файл строкой без карточки.
+func (s *BoardService) ExportCSV(ctx context.Context, boardID string) (string, []byte, error) {
+	board, err := s.Repo.GetBoardForExport(ctx, boardID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	rows := [][]string{{"column", "column_position", "card_id", "card", "card_position"}}
+
+	for _, col := range board.Columns {
+		colPos := strconv.Itoa(col.Position)
+		if len(col.Cards) == 0 {
+			rows = append(rows, []string{col.Title, colPos, "", "", ""})
+			continue
+		}
+		for _, card := range col.Cards {
+			rows = append(rows, []string{col.Title, colPos, card.ID, card.Content, strconv.Itoa(card.Position)})
+		}
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return "", nil, fmt.Errorf("failed to write csv: %w", err)
+	}
+
+	return board.Title, buf.Bytes(), nil
+}
+
+// ImportTrello создаёт новую доску из экспорта Trello: списки становятся колонками,
+// карточки — карточками, закрытые элементы уходят в архив. Описание, метки и
+// чеклисты карточки переносятся одним markdown-комментарием.
+// При dryRun в базу ничего не пишется, возвращается только отчёт.
+func (s *BoardService) ImportTrello(ctx context.Context, userID int, export *trello_model.TrelloBoardExport, dryRun bool) (*trello_model.ImportReport, error) {
+	report := &trello_model.ImportReport{DryRun: dryRun, BoardTitle: strings.TrimSpace(export.Name), Warnings: []string{}}
+	if report.BoardTitle == "" {
+		report.BoardTitle = "Imported board"
+	}
+
+	// null в массивах файла пропускаем, а не падаем на нём
+	export.Labels = skipNull(report, "label", export.Labels)
+	export.Checklists = skipNull(report, "checklist", export.Checklists)
+	export.Lists = skipNull(report, "list", export.Lists)
+	export.Cards = skipNull(report, "card", export.Cards)
+	for _, cl := range export.Checklists {
+		cl.CheckItems = skipNull(report, fmt.Sprintf("checklist %q item", cl.Name), cl.CheckItems)
+	}
+
+	if len(export.Lists) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	labels := make(map[string]*trello_model.TrelloLabel, len(export.Labels))
+	for _, l := range export.Labels {
+		labels[l.ID] = l
+	}
+
+	checklistsByCard := make(map[string][]*trello_model.TrelloChecklist)
+	for _, cl := range export.Checklists {
+		checklistsByCard[cl.IDCard] = append(checklistsByCard[cl.IDCard], cl)
+	}
+
+	lists := append([]*trello_model.TrelloList(nil), export.Lists...)
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].Pos < lists[j].Pos })
+
+	cardsByList := make(map[string][]*trello_model.TrelloCard)
+	for _, c := range export.Cards {
+		cardsByList[c.IDList] = append(cardsByList[c.IDList], c)
+	}
+
+	data := &trello_model.BoardWithColumns{Title: report.BoardTitle}
+	cardNotes := make(map[string]string)
+	knownLists := make(map[string]bool, len(lists))
+
+	for i, list := range lists {
+		knownLists[list.ID] = true
+
+		column := &trello_model.Column{Title: list.Name, Position: i + 1, IsArchived: list.Closed}
+		if column.Title == "" {
+			column.Title = "Untitled list"
+		}
+		if list.Closed {
+			report.ArchivedColumns++
+		} else {
+			report.Columns++
+		}
+
+		cards := cardsByList[list.ID]
+		sort.SliceStable(cards, func(a, b int) bool { return cards[a].Pos < cards[b].Pos })
+
+		for j, c := range cards {
+			card := &trello_model.Card{
+				ID:         uuid.New().String(),
+				Content:    c.Name,
+				Position:   j + 1,
+				IsArchived: c.Closed,
+			}
+			if c.Closed {
+				report.ArchivedCards++
+			} else {
+				report.Cards++
+			}
+
+			note, labelCount, itemCount := trelloCardNote(c, labels, checklistsByCard[c.ID])
+			report.Labels += labelCount
+			report.ChecklistItems += itemCount
+			if note != "" {
+				cardNotes[card.ID] = note
+			}
+
+			column.Cards = append(column.Cards, card)
+		}
+
+		data.Columns = append(data.Columns, column)
+	}
+
+	for _, c := range export.Cards {
+		if !knownLists[c.IDList] {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("card %q skipped: list %s not found", c.Name, c.IDList))
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	board, err := s.Repo.ImportBoard(ctx, userID, data, cardNotes)
+	if err != nil {
+		return nil, err
+	}
+	report.BoardID = board.ID
+
+	return report, nil
+}
+
+// skipNull убирает пустые (null) элементы и добавляет предупреждение о каждом.
+func skipNull[T any](report *trello_model.ImportReport, kind string, items []*T) []*T {
+	kept := items[:0:0]
+	for i, item := range items {
+		if item == nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s #%d skipped: empty entry", kind, i+1))
+			continue
+		}
+		kept = append(kept, item)
+	}
+	return kept
+}
+
+func trelloCardNote(c *trello_model.TrelloCard, labels map[string]*trello_model.TrelloLabel, checklists []*trello_model.TrelloChecklist) (string, int, int) {
+	var parts []string
+	labelCount, itemCount := 0, 0
+
+	if desc := strings.TrimSpace(c.Desc); desc != "" {
+		parts = append(parts, desc)
+	}
+
+	var labelNames []string
+	for _, id := range c.IDLabels {
+		l, ok := labels[id]
+		if !ok {
+			continue
+		}
+		name := l.Name
+		if name == "" {
+			name = l.Color
+		}
+		labelNames = append(labelNames, name)
+		labelCount++
+	}
+	if len(labelNames) > 0 {
+		parts = append(parts, "**Labels:** "+strings.Join(labelNames, ", "))
+	}
+
+	for _, cl := range checklists {
+		items := append([]*trello_model.TrelloCheckItem(nil), cl.CheckItems...)
+		sort.SliceStable(items, func(i, j int) bool { return items[i].Pos < items[j].Pos })
+
+		lines := []string{"**" + cl.Name + "**"}
+		for _, item := range items {
+			mark := " "
+			if item.State == "complete" {
+				mark = "x"
+			}
+			lines = append(lines, fmt.Sprintf("- [%s] %s", mark, item.Name))
+			itemCount++
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(parts, "\n\n"), labelCount, itemCount
+}
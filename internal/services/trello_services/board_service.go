@@ -26,8 +26,31 @@ func (s *BoardService) GetAllUserBoards(ctx context.Context, userID int) ([]*tre
 	return s.Repo.GetAllUserBoards(ctx, userID)
 }
 
+// DeleteBoard больше не удаляет доску сразу: она уходит в архив
+// и окончательно удаляется задачей очистки после срока хранения.
 func (s *BoardService) DeleteBoard(ctx context.Context, boardID string) error {
-	return s.Repo.DeleteBoard(ctx, boardID)
+	_, err := s.Repo.ArchiveBoard(ctx, boardID)
+	return err
+}
+
+func (s *BoardService) ArchiveBoard(ctx context.Context, boardID string) (*trello_model.Board, error) {
+	return s.Repo.ArchiveBoard(ctx, boardID)
+}
+
+func (s *BoardService) UnarchiveBoard(ctx context.Context, boardID string) (*trello_model.Board, error) {
+	return s.Repo.UnarchiveBoard(ctx, boardID)
+}
+
+func (s *BoardService) GetAllArchivedBoards(ctx context.Context, userID int) ([]*trello_model.Board, error) {
+	return s.Repo.GetAllArchivedBoards(ctx, userID)
+}
+
+func (s *BoardService) GetArchivedItems(ctx context.Context, boardID string) (*trello_model.ArchivedBoardItems, error) {
+	return s.Repo.GetArchivedItems(ctx, boardID)
+}
+
+func (s *BoardService) PurgeArchived(ctx context.Context, retentionDays int) (int64, error) {
+	return s.Repo.PurgeArchived(ctx, retentionDays)
 }
 
 func (s *BoardService) RenameBoard(ctx context.Context, boardID string, newName string) (*trello_model.Board, error) {
@@ -37,3 +60,7 @@ func (s *BoardService) RenameBoard(ctx context.Context, boardID string, newName
 func (s *BoardService) UpdateBoard(ctx context.Context, boardID string, userID int, boardData []*trello_model.Column) error {
 	return s.Repo.UpdateBoard(ctx, boardID, userID, boardData)
 }
+
+func (s *BoardService) CopyBoard(ctx context.Context, boardID string, userID int, title string, includeCards bool) (*trello_model.Board, error) {
+	return s.Repo.CopyBoard(ctx, boardID, userID, title, includeCards)
+}
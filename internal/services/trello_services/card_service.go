@@ -14,14 +14,32 @@ func NewCardService(r *trello_repository.CardRepo) *CardService {
 	return &CardService{Repo: r}
 }
 
-func (s *CardService) CreateCard(ctx context.Context, columnID, cardTitle string) (*trello_model.Card, error) {
-	return s.Repo.CreateCard(ctx, columnID, cardTitle)
+func (s *CardService) CreateCard(ctx context.Context, userID int, columnID, cardTitle string) (*trello_model.Card, error) {
+	return s.Repo.CreateCard(ctx, userID, columnID, cardTitle)
 }
 
-func (s *CardService) DeleteCard(ctx context.Context, columnID, cardID string) error {
-	return s.Repo.DeleteCard(ctx, columnID, cardID)
+// DeleteCard переносит карточку в архив.
+func (s *CardService) DeleteCard(ctx context.Context, userID int, columnID, cardID string) error {
+	_, err := s.Repo.ArchiveCard(ctx, userID, columnID, cardID)
+	return err
 }
 
-func (s *CardService) RenameCard(ctx context.Context, columnID, cardID, newName string) (*trello_model.Card, error) {
-	return s.Repo.RenameCard(ctx, columnID, cardID, newName)
+func (s *CardService) RenameCard(ctx context.Context, userID int, columnID, cardID, newName string) (*trello_model.Card, error) {
+	return s.Repo.RenameCard(ctx, userID, columnID, cardID, newName)
+}
+
+func (s *CardService) MoveCard(ctx context.Context, userID int, fromColumnID, cardID, toColumnID string, position int) (*trello_model.Card, error) {
+	return s.Repo.MoveCard(ctx, userID, fromColumnID, cardID, toColumnID, position)
+}
+
+func (s *CardService) ArchiveCard(ctx context.Context, userID int, columnID, cardID string) (*trello_model.Card, error) {
+	return s.Repo.ArchiveCard(ctx, userID, columnID, cardID)
+}
+
+func (s *CardService) UnarchiveCard(ctx context.Context, userID int, columnID, cardID string) (*trello_model.Card, error) {
+	return s.Repo.UnarchiveCard(ctx, userID, columnID, cardID)
+}
+
+func (s *CardService) CopyCard(ctx context.Context, userID int, columnID, cardID, toColumnID string, position int) (*trello_model.Card, error) {
+	return s.Repo.CopyCard(ctx, userID, columnID, cardID, toColumnID, position)
 }
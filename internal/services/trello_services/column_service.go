@@ -14,14 +14,35 @@ func NewColumnService(r *trello_repository.ColumnRepo) *ColumnService {
 	return &ColumnService{Repo: r}
 }
 
-func (s *ColumnService) CreateColumn(ctx context.Context, boardID, columnTitle string) (*trello_model.Column, error) {
-	return s.Repo.CreateColumn(ctx, boardID, columnTitle)
+func (s *ColumnService) CreateColumn(ctx context.Context, userID int, boardID, columnTitle string) (*trello_model.Column, error) {
+	return s.Repo.CreateColumn(ctx, userID, boardID, columnTitle)
 }
 
-func (s *ColumnService) DeleteColumn(ctx context.Context, boardID, columnID string) error {
-	return s.Repo.DeleteColumn(ctx, boardID, columnID)
+// DeleteColumn переносит колонку в архив вместе с карточками.
+func (s *ColumnService) DeleteColumn(ctx context.Context, userID int, boardID, columnID string) error {
+	_, err := s.Repo.ArchiveColumn(ctx, userID, boardID, columnID)
+	return err
 }
 
-func (s *ColumnService) RenameColumn(ctx context.Context, boardID, columnID, newName string) (*trello_model.Column, error) {
-	return s.Repo.RenameColumn(ctx, boardID, columnID, newName)
+func (s *ColumnService) RenameColumn(ctx context.Context, userID int, boardID, columnID, newName string) (*trello_model.Column, error) {
+	return s.Repo.RenameColumn(ctx, userID, boardID, columnID, newName)
+}
+
+func (s *ColumnService) ArchiveColumn(ctx context.Context, userID int, boardID, columnID string) (*trello_model.Column, error) {
+	return s.Repo.ArchiveColumn(ctx, userID, boardID, columnID)
+}
+
+func (s *ColumnService) UnarchiveColumn(ctx context.Context, userID int, boardID, columnID string) (*trello_model.Column, error) {
+	return s.Repo.UnarchiveColumn(ctx, userID, boardID, columnID)
+}
+
+func (s *ColumnService) CopyColumn(ctx context.Context, userID int, boardID, columnID, toBoardID, title string) (*trello_model.Column, error) {
+	return s.Repo.CopyColumn(ctx, userID, boardID, columnID, toBoardID, title)
+}
+
+func (s *ColumnService) SetWipLimit(ctx context.Context, boardID, columnID string, limit *int, policy string) (*trello_model.Column, error) {
+	if policy == "" {
+		policy = trello_model.WipPolicyWarn
+	}
+	return s.Repo.SetWipLimit(ctx, boardID, columnID, limit, policy)
 }
@@ -0,0 +1,60 @@
+package trello_services
+
+import (
+	"anemone_notes/internal/model/trello_model"
+	"anemone_notes/internal/repository/trello_repository"
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const MaxCommentLength = 10000
+
+var (
+	ErrCommentEmpty   = errors.New("comment body is empty")
+	ErrCommentTooLong = errors.New("comment body is too long")
+)
+
+type CommentService struct {
+	Repo *trello_repository.CommentRepo
+}
+
+func NewCommentService(r *trello_repository.CommentRepo) *CommentService {
+	return &CommentService{Repo: r}
+}
+
+// Тело комментария хранится как markdown без изменений, отрисовка — на клиенте.
+func validateCommentBody(body string) (string, error) {
+	if strings.TrimSpace(body) == "" {
+		return "", ErrCommentEmpty
+	}
+	if utf8.RuneCountInString(body) > MaxCommentLength {
+		return "", ErrCommentTooLong
+	}
+	return body, nil
+}
+
+func (s *CommentService) GetCardComments(ctx context.Context, columnID, cardID string) ([]*trello_model.Comment, error) {
+	return s.Repo.GetCardComments(ctx, columnID, cardID)
+}
+
+func (s *CommentService) CreateComment(ctx context.Context, userID int, columnID, cardID, body string) (*trello_model.Comment, error) {
+	body, err := validateCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.CreateComment(ctx, userID, columnID, cardID, body)
+}
+
+func (s *CommentService) UpdateComment(ctx context.Context, userID int, columnID, cardID, commentID, body string) (*trello_model.Comment, error) {
+	body, err := validateCommentBody(body)
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.UpdateComment(ctx, userID, columnID, cardID, commentID, body)
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, userID int, columnID, cardID, commentID string) error {
+	return s.Repo.DeleteComment(ctx, userID, columnID, cardID, commentID)
+}
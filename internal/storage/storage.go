@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"anemone_notes/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// Storage хранит содержимое вложений по ключу. Ключи выбирает вызывающий код,
+// хранилище только кладёт, отдаёт и удаляет байты.
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New выбирает хранилище по ATTACHMENTS_STORAGE: local (по умолчанию) или s3.
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.AttachmentsStorage {
+	case "", "local":
+		return NewLocalStorage(cfg.AttachmentsDir)
+	case "s3":
+		return NewS3Storage(S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+	}
+	return nil, fmt.Errorf("unknown attachments storage %q", cfg.AttachmentsStorage)
+}
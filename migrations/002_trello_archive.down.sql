@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS idx_cards_archived_at;
+DROP INDEX IF EXISTS idx_columns_archived_at;
+DROP INDEX IF EXISTS idx_boards_archived_at;
+
+DELETE FROM cards WHERE is_archived;
+DELETE FROM columns WHERE is_archived;
+DELETE FROM boards WHERE is_archived;
+
+DROP INDEX IF EXISTS idx_cards_column_id_position_active;
+ALTER TABLE cards ADD CONSTRAINT cards_column_id_position_key UNIQUE (column_id, position);
+
+DROP INDEX IF EXISTS idx_columns_board_id_position_active;
+ALTER TABLE columns ADD CONSTRAINT columns_board_id_position_key UNIQUE (board_id, position);
+
+ALTER TABLE cards DROP COLUMN IF EXISTS archived_at, DROP COLUMN IF EXISTS is_archived;
+ALTER TABLE columns DROP COLUMN IF EXISTS archived_at, DROP COLUMN IF EXISTS is_archived;
+ALTER TABLE boards DROP COLUMN IF EXISTS archived_at, DROP COLUMN IF EXISTS is_archived;
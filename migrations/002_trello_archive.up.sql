@@ -0,0 +1,26 @@
+-- Anemone Trello
+-- Архивирование досок, колонок и карточек вместо жёсткого удаления
+ALTER TABLE boards
+    ADD COLUMN is_archived BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN archived_at TIMESTAMPTZ;
+
+ALTER TABLE columns
+    ADD COLUMN is_archived BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN archived_at TIMESTAMPTZ;
+
+ALTER TABLE cards
+    ADD COLUMN is_archived BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN archived_at TIMESTAMPTZ;
+
+-- Позиции уникальны только среди активных элементов:
+-- архивные сохраняют старую позицию и не мешают порядку на доске
+ALTER TABLE columns DROP CONSTRAINT IF EXISTS columns_board_id_position_key;
+CREATE UNIQUE INDEX idx_columns_board_id_position_active ON columns (board_id, position) WHERE NOT is_archived;
+
+ALTER TABLE cards DROP CONSTRAINT IF EXISTS cards_column_id_position_key;
+CREATE UNIQUE INDEX idx_cards_column_id_position_active ON cards (column_id, position) WHERE NOT is_archived;
+
+-- Индексы для задачи очистки архива
+CREATE INDEX idx_boards_archived_at ON boards (archived_at) WHERE is_archived;
+CREATE INDEX idx_columns_archived_at ON columns (archived_at) WHERE is_archived;
+CREATE INDEX idx_cards_archived_at ON cards (archived_at) WHERE is_archived;
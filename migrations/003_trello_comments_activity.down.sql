@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS board_activity;
+DROP TABLE IF EXISTS card_comments;
@@ -0,0 +1,29 @@
+-- Anemone Trello
+-- Комментарии к карточкам (markdown)
+CREATE TABLE card_comments (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    card_id    UUID NOT NULL REFERENCES cards (id) ON DELETE CASCADE,
+    user_id    INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    body       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ
+);
+
+-- Индекс для быстрого поиска комментариев по карточке
+CREATE INDEX idx_card_comments_card_id ON card_comments (card_id, created_at);
+
+-- Лента активности доски
+CREATE TABLE board_activity (
+    id         BIGSERIAL PRIMARY KEY,
+    board_id   UUID NOT NULL REFERENCES boards (id) ON DELETE CASCADE,
+    column_id  UUID REFERENCES columns (id) ON DELETE SET NULL,
+    card_id    UUID REFERENCES cards (id) ON DELETE SET NULL,
+    user_id    INT REFERENCES users (id) ON DELETE SET NULL,
+    action     VARCHAR(50) NOT NULL,
+    data       JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Индексы для постраничной выдачи ленты по доске и по карточке
+CREATE INDEX idx_board_activity_board_id ON board_activity (board_id, id DESC);
+CREATE INDEX idx_board_activity_card_id ON board_activity (card_id, id DESC) WHERE card_id IS NOT NULL;
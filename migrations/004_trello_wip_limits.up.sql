@@ -0,0 +1,5 @@
+-- Anemone Trello
+-- WIP-лимиты колонок: warn — только подсветка, block — запрет добавления карточек
+ALTER TABLE columns
+    ADD COLUMN wip_limit INTEGER CHECK (wip_limit > 0),
+    ADD COLUMN wip_policy VARCHAR(10) NOT NULL DEFAULT 'warn' CHECK (wip_policy IN ('warn', 'block'));
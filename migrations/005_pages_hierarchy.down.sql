@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_pages_parent_page_id;
+ALTER TABLE pages DROP COLUMN IF EXISTS position, DROP COLUMN IF EXISTS parent_page_id;
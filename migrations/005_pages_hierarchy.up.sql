@@ -0,0 +1,17 @@
+-- Anemone Notes
+-- Вложенные страницы: родительская страница и порядок среди соседей
+ALTER TABLE pages
+    ADD COLUMN parent_page_id INT REFERENCES pages (id) ON DELETE CASCADE,
+    ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+-- Существующие страницы получают порядок по дате создания
+UPDATE pages p
+SET position = o.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id, parent_page_id ORDER BY created_at, id) AS rn
+    FROM pages
+) o
+WHERE p.id = o.id;
+
+-- Индекс для быстрого поиска дочерних страниц
+CREATE INDEX idx_pages_parent_page_id ON pages (parent_page_id, position);
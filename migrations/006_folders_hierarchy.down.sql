@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notes_folder_parent;
+ALTER TABLE notes_folder DROP COLUMN IF EXISTS position, DROP COLUMN IF EXISTS parent_folder_id;
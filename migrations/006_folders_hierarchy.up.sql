@@ -0,0 +1,17 @@
+-- Anemone Notes
+-- Вложенные папки: родительская папка и ручной порядок среди соседей
+ALTER TABLE notes_folder
+    ADD COLUMN parent_folder_id INT REFERENCES notes_folder (id) ON DELETE CASCADE,
+    ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+-- Существующие папки получают порядок по дате создания
+UPDATE notes_folder f
+SET position = o.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at, id) AS rn
+    FROM notes_folder
+) o
+WHERE f.id = o.id;
+
+-- Индекс для быстрого поиска дочерних папок
+CREATE INDEX idx_notes_folder_parent ON notes_folder (user_id, parent_folder_id, position);
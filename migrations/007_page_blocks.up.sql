@@ -0,0 +1,25 @@
+-- Anemone Notes
+-- Блочное содержимое страниц
+CREATE TABLE IF NOT EXISTS page_blocks (
+    id SERIAL PRIMARY KEY,
+    page_id INT NOT NULL REFERENCES pages (id) ON DELETE CASCADE,
+    type TEXT NOT NULL CHECK (type IN ('paragraph', 'heading', 'todo', 'code', 'quote', 'image', 'divider', 'embed')),
+    content TEXT NOT NULL DEFAULT '',
+    props JSONB NOT NULL DEFAULT '{}',
+    position INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Индекс для сборки страницы по порядку блоков
+CREATE INDEX idx_page_blocks_page_id ON page_blocks (page_id, position);
+
+-- Существующий текст страниц разбивается на абзацы по пустым строкам
+INSERT INTO page_blocks (page_id, type, content, position)
+SELECT page_id, 'paragraph', part, ROW_NUMBER() OVER (PARTITION BY page_id ORDER BY ord)
+FROM (
+    SELECT p.id AS page_id, btrim(t.part, E' \t\r\n') AS part, t.ord
+    FROM pages p,
+         regexp_split_to_table(COALESCE(p.content, ''), E'\\r?\\n\\s*\\r?\\n') WITH ORDINALITY AS t(part, ord)
+) s
+WHERE part <> '';
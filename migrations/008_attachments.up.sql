@@ -0,0 +1,25 @@
+-- Anemone Notes
+-- Содержимое вложений хранится один раз на sha256, записи вложений ссылаются на него
+CREATE TABLE IF NOT EXISTS attachment_blobs (
+    sha256 TEXT PRIMARY KEY,
+    size BIGINT NOT NULL,
+    mime_type TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Вложение принадлежит ровно одному родителю: странице или карточке
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    blob_sha256 TEXT NOT NULL REFERENCES attachment_blobs (sha256),
+    file_name TEXT NOT NULL,
+    page_id INT REFERENCES pages (id) ON DELETE CASCADE,
+    card_id UUID REFERENCES cards (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((page_id IS NULL) <> (card_id IS NULL))
+);
+
+CREATE INDEX idx_attachments_page_id ON attachments (page_id) WHERE page_id IS NOT NULL;
+CREATE INDEX idx_attachments_card_id ON attachments (card_id) WHERE card_id IS NOT NULL;
+CREATE INDEX idx_attachments_blob ON attachments (blob_sha256);
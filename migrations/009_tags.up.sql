@@ -0,0 +1,20 @@
+-- Anemone Notes
+-- Теги страниц: у пользователя имена тегов уникальны без учёта регистра
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_tags_user_name ON tags (user_id, lower(name));
+
+-- Связь многие-ко-многим между страницами и тегами
+CREATE TABLE IF NOT EXISTS page_tags (
+    page_id INT NOT NULL REFERENCES pages (id) ON DELETE CASCADE,
+    tag_id INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (page_id, tag_id)
+);
+
+CREATE INDEX idx_page_tags_tag_id ON page_tags (tag_id);
@@ -0,0 +1,19 @@
+-- Anemone Notes
+-- Вики-ссылки [[Название]] между страницами. Храним название как написано
+-- в тексте, а страницу ищем по нему при чтении: так ссылка на ещё не
+-- созданную страницу «оживает», как только страница появится.
+CREATE TABLE IF NOT EXISTS page_links (
+    source_page_id INT NOT NULL REFERENCES pages (id) ON DELETE CASCADE,
+    target_title TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_page_links_source_target ON page_links (source_page_id, lower(target_title));
+CREATE INDEX idx_page_links_target ON page_links (lower(target_title));
+
+-- Ссылки из уже существующих страниц
+INSERT INTO page_links (source_page_id, target_title)
+SELECT p.id, btrim(m[1])
+FROM pages p, regexp_matches(p.content, '\[\[([^\[\]|\n]+)(\|[^\[\]\n]*)?\]\]', 'g') AS m
+WHERE btrim(m[1]) <> ''
+ON CONFLICT (source_page_id, lower(target_title)) DO NOTHING;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS recent_items;
+DROP TABLE IF EXISTS favorites;
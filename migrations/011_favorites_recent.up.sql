@@ -0,0 +1,31 @@
+-- Anemone Notes
+-- Избранное: страница, папка или доска. Закреплённые идут в сайдбаре первыми.
+CREATE TABLE IF NOT EXISTS favorites (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    page_id INT REFERENCES pages (id) ON DELETE CASCADE,
+    folder_id INT REFERENCES notes_folder (id) ON DELETE CASCADE,
+    board_id UUID REFERENCES boards (id) ON DELETE CASCADE,
+    pinned BOOLEAN NOT NULL DEFAULT false,
+    position INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (num_nonnulls(page_id, folder_id, board_id) = 1)
+);
+
+CREATE UNIQUE INDEX idx_favorites_user_page ON favorites (user_id, page_id);
+CREATE UNIQUE INDEX idx_favorites_user_folder ON favorites (user_id, folder_id);
+CREATE UNIQUE INDEX idx_favorites_user_board ON favorites (user_id, board_id);
+
+-- Недавно открытые страницы и доски; лишние записи срезаются при каждом просмотре
+CREATE TABLE IF NOT EXISTS recent_items (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    page_id INT REFERENCES pages (id) ON DELETE CASCADE,
+    board_id UUID REFERENCES boards (id) ON DELETE CASCADE,
+    viewed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((page_id IS NULL) <> (board_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_recent_items_user_page ON recent_items (user_id, page_id);
+CREATE UNIQUE INDEX idx_recent_items_user_board ON recent_items (user_id, board_id);
+CREATE INDEX idx_recent_items_user_viewed ON recent_items (user_id, viewed_at DESC);
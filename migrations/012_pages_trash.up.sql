@@ -0,0 +1,8 @@
+-- Anemone Notes
+-- Время удаления в корзину: по нему показываем корзину и чистим её по сроку хранения
+ALTER TABLE pages ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Для уже удалённых страниц точного времени нет, берём время последнего изменения
+UPDATE pages SET deleted_at = updated_at WHERE is_deleted = true;
+
+CREATE INDEX idx_pages_deleted_at ON pages (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS daily_notes;
+DROP TABLE IF EXISTS daily_note_settings;
+DROP INDEX IF EXISTS idx_pages_templates;
+ALTER TABLE pages DROP COLUMN IF EXISTS is_template;
@@ -0,0 +1,23 @@
+-- Anemone Notes
+-- Шаблоны: обычные страницы пользователя с пометкой is_template
+ALTER TABLE pages ADD COLUMN is_template BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_pages_templates ON pages (user_id) WHERE is_template;
+
+-- Настройки ежедневных заметок: папка, шаблон и часовой пояс для «сегодня»
+CREATE TABLE IF NOT EXISTS daily_note_settings (
+    user_id INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    folder_id INT REFERENCES notes_folder (id) ON DELETE SET NULL,
+    template_page_id INT REFERENCES pages (id) ON DELETE SET NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Страница ежедневной заметки за каждую дату
+CREATE TABLE IF NOT EXISTS daily_notes (
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    note_date DATE NOT NULL,
+    page_id INT NOT NULL REFERENCES pages (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, note_date)
+);
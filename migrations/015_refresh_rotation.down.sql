@@ -0,0 +1,12 @@
+-- Исходные токены по хэшу не восстановить, поэтому все сессии сбрасываются
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_user;
+DROP INDEX IF EXISTS idx_refresh_tokens_family;
+DROP INDEX IF EXISTS idx_refresh_tokens_hash;
+
+ALTER TABLE refresh_tokens ADD COLUMN token TEXT NOT NULL;
+ALTER TABLE refresh_tokens ALTER COLUMN expires_at TYPE TIMESTAMP;
+ALTER TABLE refresh_tokens DROP COLUMN rotated_at;
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
+ALTER TABLE refresh_tokens DROP COLUMN token_hash;
@@ -0,0 +1,19 @@
+-- Anemone Notes
+-- Ротация refresh-токенов. Вместо самого токена храним его sha256, токены
+-- одной сессии объединены в семейство family_id. Использованный токен остаётся
+-- в таблице с rotated_at, чтобы распознать его повторное предъявление.
+ALTER TABLE refresh_tokens ADD COLUMN token_hash TEXT;
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE refresh_tokens ADD COLUMN rotated_at TIMESTAMPTZ;
+
+-- Действующие токены не сбрасываем: каждый становится отдельной сессией
+UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens ALTER COLUMN token_hash SET NOT NULL;
+ALTER TABLE refresh_tokens ALTER COLUMN family_id DROP DEFAULT;
+ALTER TABLE refresh_tokens ALTER COLUMN expires_at TYPE TIMESTAMPTZ;
+ALTER TABLE refresh_tokens DROP COLUMN token;
+
+CREATE UNIQUE INDEX idx_refresh_tokens_hash ON refresh_tokens (token_hash);
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_user ON refresh_tokens (user_id);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS access_denylist;
+
+ALTER TABLE refresh_tokens DROP COLUMN access_expires_at;
+ALTER TABLE refresh_tokens DROP COLUMN access_jti;
+ALTER TABLE refresh_tokens DROP COLUMN last_used_at;
+ALTER TABLE refresh_tokens DROP COLUMN session_created_at;
+ALTER TABLE refresh_tokens DROP COLUMN ip_address;
+ALTER TABLE refresh_tokens DROP COLUMN user_agent;
@@ -0,0 +1,18 @@
+-- Anemone Notes
+-- Сессии: семейство refresh-токенов и есть сессия. Метаданные устройства
+-- копируются в каждый новый токен семейства при ротации.
+ALTER TABLE refresh_tokens ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN ip_address TEXT NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN session_created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE refresh_tokens ADD COLUMN last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+-- jti access-токена, выданного вместе с этим refresh-токеном
+ALTER TABLE refresh_tokens ADD COLUMN access_jti TEXT;
+ALTER TABLE refresh_tokens ADD COLUMN access_expires_at TIMESTAMPTZ;
+
+UPDATE refresh_tokens SET session_created_at = created_at, last_used_at = created_at;
+
+-- Access-токены отозванных сессий. Запись нужна только до истечения токена.
+CREATE TABLE IF NOT EXISTS access_denylist (
+    jti TEXT PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,22 @@
+-- Anemone Notes
+-- Двухфакторная аутентификация (TOTP). Пока confirmed_at пуст, секрет только
+-- выдан пользователю и вход не меняет. last_used_step не даёт использовать
+-- один и тот же код дважды.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret TEXT NOT NULL,
+    confirmed_at TIMESTAMPTZ,
+    last_used_step BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Одноразовые коды восстановления, в базе только sha256
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_user_recovery_codes_hash ON user_recovery_codes (user_id, code_hash);
@@ -0,0 +1,11 @@
+DELETE FROM user_tokens WHERE purpose = 'sso_login';
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('verify_email', 'reset_password'));
+
+DROP TABLE IF EXISTS oidc_login_states;
+DROP TABLE IF EXISTS user_identities;
+
+-- Пользователи без пароля получают недостижимый хэш и входят через сброс пароля
+UPDATE users SET password = '!' WHERE password IS NULL;
+ALTER TABLE users ALTER COLUMN password SET NOT NULL;
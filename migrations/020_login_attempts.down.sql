@@ -0,0 +1,6 @@
+DELETE FROM user_tokens WHERE purpose = 'unlock_account';
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('verify_email', 'reset_password', 'sso_login'));
+
+DROP TABLE IF EXISTS login_attempts;
@@ -0,0 +1,13 @@
+-- Anemone Notes
+-- Счётчики неудачных входов по аккаунту и по IP (ключи "account:<email>", "ip:<адрес>")
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+-- Ссылка из письма о блокировке снимает её досрочно
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('verify_email', 'reset_password', 'sso_login', 'unlock_account'));
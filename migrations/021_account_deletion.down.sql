@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS data_exports;
+
+DROP INDEX IF EXISTS idx_users_deletion_requested_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_requested_at;
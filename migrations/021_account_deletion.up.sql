@@ -0,0 +1,24 @@
+-- Anemone Notes
+-- Удаление аккаунта: до окончательного удаления есть льготный период,
+-- в течение которого его можно отменить
+ALTER TABLE users ADD COLUMN deletion_requested_at TIMESTAMPTZ;
+
+CREATE INDEX idx_users_deletion_requested_at ON users (deletion_requested_at) WHERE deletion_requested_at IS NOT NULL;
+
+-- Выгрузки данных пользователя (GDPR). После удаления аккаунта запись
+-- остаётся без владельца, чтобы задача очистки удалила и сам архив
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id INT REFERENCES users (id) ON DELETE SET NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'ready', 'failed')),
+    storage_key TEXT,
+    size BIGINT,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_data_exports_user_id ON data_exports (user_id, created_at DESC);
+CREATE INDEX idx_data_exports_pending ON data_exports (created_at) WHERE status = 'pending';
@@ -0,0 +1,8 @@
+DELETE FROM user_tokens WHERE purpose = 'change_email';
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('verify_email', 'reset_password', 'sso_login', 'unlock_account'));
+
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
+
+DROP TABLE IF EXISTS user_profiles;
@@ -0,0 +1,21 @@
+-- Anemone Notes
+-- Профиль и настройки интерфейса. Строка появляется при первом изменении,
+-- до этого действуют значения по умолчанию
+CREATE TABLE IF NOT EXISTS user_profiles (
+    user_id INT PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    display_name TEXT NOT NULL DEFAULT '',
+    avatar_url TEXT,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    locale TEXT NOT NULL DEFAULT 'en',
+    theme TEXT NOT NULL DEFAULT 'system' CHECK (theme IN ('system', 'light', 'dark')),
+    default_board_id UUID REFERENCES boards (id) ON DELETE SET NULL,
+    default_folder_id INT REFERENCES notes_folder (id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Смена почты: новый адрес действует только после перехода по ссылке из письма
+ALTER TABLE users ADD COLUMN pending_email TEXT;
+
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+    CHECK (purpose IN ('verify_email', 'reset_password', 'sso_login', 'unlock_account', 'change_email'));
@@ -0,0 +1,5 @@
+-- Anemone Notes
+-- Почта сравнивается без учёта регистра: новые адреса хранятся в нижнем
+-- регистре, старые могли сохраниться как ввёл пользователь. Индекс не
+-- уникальный, чтобы миграция не падала на уже существующих дублях
+CREATE INDEX IF NOT EXISTS idx_users_email_lower ON users (lower(email));
@@ -0,0 +1,4 @@
+ALTER TABLE daily_note_settings ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+
+UPDATE daily_note_settings d SET timezone = p.timezone
+FROM user_profiles p WHERE p.user_id = d.user_id;
@@ -0,0 +1,10 @@
+-- Anemone Notes
+-- Часовой пояс пользователя хранится только в профиле: ежедневные заметки
+-- и шаблоны берут его оттуда. Пояс из настроек ежедневных заметок переносим
+-- в профиль, если там он ещё не выбран
+INSERT INTO user_profiles (user_id, timezone)
+SELECT user_id, timezone FROM daily_note_settings WHERE timezone <> 'UTC'
+ON CONFLICT (user_id) DO UPDATE SET timezone = EXCLUDED.timezone, updated_at = NOW()
+WHERE user_profiles.timezone = 'UTC';
+
+ALTER TABLE daily_note_settings DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1 @@
+-- Перенумерация необратима и не требует отката
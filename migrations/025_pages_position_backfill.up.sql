@@ -0,0 +1,15 @@
+-- Anemone Notes
+-- Страницы, созданные до 005, остались с position = 0: перенумеровываем
+-- только такие группы соседей, сохраняя уже заданный порядок
+UPDATE pages p
+SET position = o.rn
+FROM (
+    SELECT id, ROW_NUMBER() OVER (
+        PARTITION BY user_id, parent_page_id ORDER BY position, created_at, id
+    ) AS rn
+    FROM pages
+    WHERE (user_id, COALESCE(parent_page_id, 0)) IN (
+        SELECT user_id, COALESCE(parent_page_id, 0) FROM pages WHERE position = 0
+    )
+) o
+WHERE p.id = o.id;